@@ -0,0 +1,214 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idp"
+	"github.com/pkg/errors"
+)
+
+const (
+	SourceCache       = "cache"
+	SourceFetch       = "fetch"
+	SourceMemoryCache = "memory_cache" // served from serve memory cache
+
+	CloudTypeAlibabaCloud = "AlibabaCloud"
+	CloudTypeAws          = "Aws"
+	CloudTypeCloudAccount = "CloudAccount"
+	CloudTypeOidcToken    = "OidcToken"
+
+	// keys in cache context, stored along with cached token, so cache hit can be audited as well
+	ContextKeyRoleSessionName = "audit_role_session_name"
+	ContextKeyOidcSubject     = "audit_oidc_subject"
+	ContextKeyOidcJti         = "audit_oidc_jti"
+
+	auditFilename       = "audit.jsonl"
+	auditFilePrefix     = "audit-"
+	auditFileSuffix     = ".jsonl"
+	auditFileTimeFormat = "20060102-150405.000"
+
+	maxAuditFileSize      = 10 * 1024 * 1024
+	maxRotatedAuditFiles  = 10
+	auditFilePerm         = 0600
+	auditDirPerm          = 0700
+	auditRecordTimeFormat = time.RFC3339Nano
+	auditExpirationFormat = time.RFC3339
+)
+
+var (
+	DisableAudit = idaaslog.IsOn(os.Getenv(constants.EnvDisableAudit))
+
+	auditMutex   sync.Mutex
+	auditCommand string
+)
+
+// Record is one credential issuance, MUST NOT contain any secret(token, key secret etc.)
+type Record struct {
+	Time                       string `json:"time"`
+	Pid                        int    `json:"pid"`
+	Command                    string `json:"command,omitempty"`
+	Profile                    string `json:"profile"`
+	CloudType                  string `json:"cloud_type"`
+	RoleArn                    string `json:"role_arn,omitempty"`
+	CloudAccountRoleExternalId string `json:"cloud_account_role_external_id,omitempty"`
+	RoleSessionName            string `json:"role_session_name,omitempty"`
+	OidcSubject                string `json:"oidc_subject,omitempty"`
+	OidcJti                    string `json:"oidc_jti,omitempty"`
+	Expiration                 string `json:"expiration,omitempty"`
+	Source                     string `json:"source"`
+}
+
+func (r *Record) GetTime() (time.Time, error) {
+	return time.Parse(auditRecordTimeFormat, r.Time)
+}
+
+// SetCommand sets source command of audit records, e.g. fetch-token, serve
+func SetCommand(command string) {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	auditCommand = command
+}
+
+// PutOidcTokenClaims puts subject and jti of OIDC token(JWT) to cache context, token is not stored
+func PutOidcTokenClaims(context map[string]interface{}, oidcToken string) {
+	claims, err := idp.ParseJwtTokenClaim(oidcToken)
+	if err != nil {
+		idaaslog.Debug.PrintfLn("Parse OIDC token for audit failed: %v", err)
+		return
+	}
+	context[ContextKeyOidcSubject] = claims.Subject
+	context[ContextKeyOidcJti] = claims.JwtId
+}
+
+// FillFromCacheContext fills role session name and OIDC claims from cache context
+func (r *Record) FillFromCacheContext(context map[string]interface{}, fromCache bool) {
+	r.RoleSessionName = getContextString(context, ContextKeyRoleSessionName)
+	r.OidcSubject = getContextString(context, ContextKeyOidcSubject)
+	r.OidcJti = getContextString(context, ContextKeyOidcJti)
+	if fromCache {
+		r.Source = SourceCache
+	} else {
+		r.Source = SourceFetch
+	}
+}
+
+func (r *Record) SetExpiration(expiration time.Time) {
+	if !expiration.IsZero() {
+		r.Expiration = expiration.UTC().Format(auditExpirationFormat)
+	}
+}
+
+// Log appends record to audit file, audit failure never breaks credential issuance
+func Log(record *Record) {
+	if DisableAudit {
+		return
+	}
+	auditDir, err := GetAuditDir()
+	if err != nil {
+		idaaslog.Warn.PrintfLn("Get audit dir failed: %v", err)
+		return
+	}
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	record.Time = time.Now().Format(auditRecordTimeFormat)
+	record.Pid = os.Getpid()
+	if record.Command == "" {
+		record.Command = auditCommand
+	}
+	err = appendRecord(auditDir, record)
+	if err != nil {
+		idaaslog.Warn.PrintfLn("Write audit record failed: %v", err)
+	}
+}
+
+func GetAuditDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get user home dir failed")
+	}
+	return filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir, constants.AuditDir), nil
+}
+
+func appendRecord(auditDir string, record *Record) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "marshal audit record failed")
+	}
+	if err = os.MkdirAll(auditDir, auditDirPerm); err != nil {
+		return errors.Wrapf(err, "create audit dir: %s failed", auditDir)
+	}
+	auditFile := filepath.Join(auditDir, auditFilename)
+	rotateAuditFile(auditDir, auditFile)
+
+	// append a single line with O_APPEND, so concurrent processes never interleave lines
+	f, err := os.OpenFile(auditFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, auditFilePerm)
+	if err != nil {
+		return errors.Wrapf(err, "open audit file: %s failed", auditFile)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = f.Write(append(recordBytes, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "write audit file: %s failed", auditFile)
+	}
+	return nil
+}
+
+func rotateAuditFile(auditDir, auditFile string) {
+	fileInfo, err := os.Stat(auditFile)
+	if err != nil || fileInfo.Size() < maxAuditFileSize {
+		return
+	}
+	rotatedFile := filepath.Join(auditDir,
+		fmt.Sprintf("%s%s%s", auditFilePrefix, time.Now().Format(auditFileTimeFormat), auditFileSuffix))
+	// other process may rotate at the same time, ignore error
+	if err = os.Rename(auditFile, rotatedFile); err != nil {
+		idaaslog.Debug.PrintfLn("Rotate audit file: %s failed: %v", auditFile, err)
+		return
+	}
+	rotatedFiles, err := listRotatedAuditFiles(auditDir)
+	if err != nil {
+		return
+	}
+	for len(rotatedFiles) > maxRotatedAuditFiles {
+		_ = os.Remove(rotatedFiles[0])
+		rotatedFiles = rotatedFiles[1:]
+	}
+}
+
+// listRotatedAuditFiles returns rotated audit files, the oldest first
+func listRotatedAuditFiles(auditDir string) ([]string, error) {
+	entries, err := os.ReadDir(auditDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, auditFilePrefix) && strings.HasSuffix(name, auditFileSuffix) {
+			files = append(files, filepath.Join(auditDir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func getContextString(context map[string]interface{}, key string) string {
+	if context == nil {
+		return ""
+	}
+	if value, ok := context[key].(string); ok {
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndReadRecords(t *testing.T) {
+	auditDir := t.TempDir()
+	now := time.Now()
+	records := []*Record{
+		{Time: now.Add(-2 * time.Hour).Format(auditRecordTimeFormat), Profile: "p1", CloudType: CloudTypeAws, Source: SourceFetch},
+		{Time: now.Add(-1 * time.Hour).Format(auditRecordTimeFormat), Profile: "p2", CloudType: CloudTypeAlibabaCloud, Source: SourceCache},
+		{Time: now.Format(auditRecordTimeFormat), Profile: "p1", CloudType: CloudTypeAws, Source: SourceCache},
+	}
+	for _, record := range records {
+		if err := appendRecord(auditDir, record); err != nil {
+			t.Fatalf("append record failed: %v", err)
+		}
+	}
+	fileInfo, err := os.Stat(filepath.Join(auditDir, auditFilename))
+	if err != nil {
+		t.Fatalf("stat audit file failed: %v", err)
+	}
+	if fileInfo.Mode().Perm() != auditFilePerm {
+		t.Errorf("audit file perm: %o, expected: %o", fileInfo.Mode().Perm(), auditFilePerm)
+	}
+
+	all, err := readRecords(auditDir, nil)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("read all records: %d, %v", len(all), err)
+	}
+	byProfile, _ := readRecords(auditDir, &Filter{Profiles: []string{"p1"}})
+	if len(byProfile) != 2 {
+		t.Errorf("filter by profile: %d, expected 2", len(byProfile))
+	}
+	byTime, _ := readRecords(auditDir, &Filter{Since: now.Add(-90 * time.Minute), Until: now.Add(-30 * time.Minute)})
+	if len(byTime) != 1 || byTime[0].Profile != "p2" {
+		t.Errorf("filter by time: %+v", byTime)
+	}
+}
+
+func TestRotateAuditFile(t *testing.T) {
+	auditDir := t.TempDir()
+	auditFile := filepath.Join(auditDir, auditFilename)
+	for i := 0; i < maxRotatedAuditFiles+2; i++ {
+		oldFile := filepath.Join(auditDir, auditFilePrefix+"20250101-00000"+string(rune('a'+i))+auditFileSuffix)
+		if err := os.WriteFile(oldFile, []byte("{}\n"), auditFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(auditFile, []byte(strings.Repeat("x", maxAuditFileSize)), auditFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendRecord(auditDir, &Record{Profile: "p1"}); err != nil {
+		t.Fatalf("append record failed: %v", err)
+	}
+	rotatedFiles, err := listRotatedAuditFiles(auditDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotatedFiles) != maxRotatedAuditFiles {
+		t.Errorf("rotated files: %d, expected: %d", len(rotatedFiles), maxRotatedAuditFiles)
+	}
+	records, err := readRecordsFromFile(auditFile, nil)
+	if err != nil || len(records) != 1 {
+		t.Errorf("current audit file records: %d, %v", len(records), err)
+	}
+}
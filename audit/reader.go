@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/pkg/errors"
+)
+
+type Filter struct {
+	Profiles []string
+	Since    time.Time
+	Until    time.Time
+}
+
+func (f *Filter) Match(record *Record) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Profiles) > 0 && !slices.Contains(f.Profiles, record.Profile) {
+		return false
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		recordTime, err := record.GetTime()
+		if err != nil {
+			return false
+		}
+		if !f.Since.IsZero() && recordTime.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && recordTime.After(f.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadRecords reads audit records from rotated and current audit files, the oldest first
+func ReadRecords(filter *Filter) ([]*Record, error) {
+	auditDir, err := GetAuditDir()
+	if err != nil {
+		return nil, err
+	}
+	return readRecords(auditDir, filter)
+}
+
+func readRecords(auditDir string, filter *Filter) ([]*Record, error) {
+	files, err := listRotatedAuditFiles(auditDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "list audit dir: %s failed", auditDir)
+	}
+	files = append(files, filepath.Join(auditDir, auditFilename))
+
+	var records []*Record
+	for _, file := range files {
+		fileRecords, err := readRecordsFromFile(file, filter)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
+
+func readRecordsFromFile(file string, filter *Filter) ([]*Record, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "open audit file: %s failed", file)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var records []*Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			idaaslog.Warn.PrintfLn("Parse audit record in file: %s failed: %v, ignore", file, err)
+			continue
+		}
+		if filter.Match(&record) {
+			records = append(records, &record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "read audit file: %s failed", file)
+	}
+	return records, nil
+}
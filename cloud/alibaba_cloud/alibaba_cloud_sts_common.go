@@ -43,6 +43,18 @@ type StsTokenCredentialsUri struct {
 	Expiration      string `json:"Expiration"`
 }
 
+// StsTokenEcsMetadata
+// http://100.100.100.200/latest/meta-data/ram/security-credentials/<role-name>
+// https://help.aliyun.com/zh/ecs/user-guide/attach-an-instance-ram-role-to-an-ecs-instance
+type StsTokenEcsMetadata struct {
+	Code            string `json:"Code"`
+	LastUpdated     string `json:"LastUpdated"`
+	AccessKeyId     string `json:"AccessKeyId"`
+	AccessKeySecret string `json:"AccessKeySecret"`
+	StsToken        string `json:"SecurityToken"`
+	Expiration      string `json:"Expiration"`
+}
+
 func (t *StsToken) ConvertToOssutilv2() *StsTokenOssutilv2 {
 	stsTokenOssutilv2 := &StsTokenOssutilv2{
 		AccessKeyId:     t.AccessKeyId,
@@ -65,6 +77,18 @@ func (t *StsToken) ConvertToCredentialsUri() *StsTokenCredentialsUri {
 	return stsTokenCredentialsUri
 }
 
+func (t *StsToken) ConvertToEcsMetadata(lastUpdated time.Time) *StsTokenEcsMetadata {
+	stsTokenEcsMetadata := &StsTokenEcsMetadata{
+		Code:            "Success",
+		LastUpdated:     lastUpdated.UTC().Format(time.RFC3339),
+		AccessKeyId:     t.AccessKeyId,
+		AccessKeySecret: t.AccessKeySecret,
+		StsToken:        t.StsToken,
+		Expiration:      t.Expiration,
+	}
+	return stsTokenEcsMetadata
+}
+
 func (t *StsToken) Marshal() (string, error) {
 	if t == nil {
 		return "null", nil
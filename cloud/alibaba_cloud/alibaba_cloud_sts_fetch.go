@@ -8,6 +8,7 @@ import (
 	sts20150401 "github.com/alibabacloud-go/sts-20150401/v2/client"
 	util "github.com/alibabacloud-go/tea-utils/v2/service"
 	"github.com/alibabacloud-go/tea/tea"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_common"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
@@ -67,14 +68,15 @@ func FetchStsWithOidcConfig(profile string, alibabaCloudStsConfig *config.Alibab
 
 func FetchStsWithOidc(profile string, alibabaCloudStsConfig *config.AlibabaCloudStsConfig, options *FetchStsWithOidcOptions) (*StsToken, error) {
 	digest := alibabaCloudStsConfig.Digest()
+	cacheContext := map[string]interface{}{
+		"profile": profile,
+		"digest":  digest,
+		"config":  alibabaCloudStsConfig,
+	}
 	readCacheFileOptions := &utils.ReadCacheOptions{
-		Context: map[string]interface{}{
-			"profile": profile,
-			"digest":  digest,
-			"config":  alibabaCloudStsConfig,
-		},
+		Context: cacheContext,
 		FetchContent: func() (int, string, error) {
-			return fetchContent(options)
+			return fetchContent(options, cacheContext)
 		},
 		ForceNew: options.ForceNew,
 		IsContentExpiringOrExpired: func(s *utils.StringWithTime) bool {
@@ -83,20 +85,35 @@ func FetchStsWithOidc(profile string, alibabaCloudStsConfig *config.AlibabaCloud
 		IsContentExpired: func(s *utils.StringWithTime) bool {
 			return isContentExpired(s)
 		},
+		GetContentExpiration: getContentExpiration,
 	}
 
-	cacheKey := fmt.Sprintf("%s_%s", profile, digest[0:32])
+	cacheKey := CloudTokenCacheKey(profile, alibabaCloudStsConfig)
 	idaaslog.Debug.PrintfLn("Cache key: %s %s", constants.CategoryCloudToken, cacheKey)
-	stsTokenStr, err := utils.ReadCacheFileWithEncryptionCallback(
+	stsTokenResult, err := utils.ReadCacheFileWithEncryptionCallbackResult(
 		constants.CategoryCloudToken, cacheKey, readCacheFileOptions)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error fetch cloud_token token with OIDC: %v", err)
 		return nil, err
 	}
-	return UnmarshalStsToken(stsTokenStr)
+	stsToken, err := UnmarshalStsToken(stsTokenResult.Content)
+	if err != nil {
+		return nil, err
+	}
+	auditRecord := &audit.Record{
+		Profile:   profile,
+		CloudType: audit.CloudTypeAlibabaCloud,
+		RoleArn:   alibabaCloudStsConfig.RoleArn,
+	}
+	auditRecord.FillFromCacheContext(stsTokenResult.Context, stsTokenResult.FromCache)
+	if expiration, parseErr := time.Parse(time.RFC3339Nano, stsToken.Expiration); parseErr == nil {
+		auditRecord.SetExpiration(expiration)
+	}
+	audit.Log(auditRecord)
+	return stsToken, nil
 }
 
-func fetchContent(options *FetchStsWithOidcOptions) (int, string, error) {
+func fetchContent(options *FetchStsWithOidcOptions, cacheContext map[string]interface{}) (int, string, error) {
 	client, err := createStsClient(options.Endpoint)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error creating sts client: %v", err)
@@ -107,7 +124,10 @@ func fetchContent(options *FetchStsWithOidcOptions) (int, string, error) {
 		idaaslog.Error.PrintfLn("Error fetching oidc token: %v", err)
 		return 600, "", err
 	}
-	stsResponse, err := assumeRoleWithOidc(client, oidcToken, options)
+	roleSessionName := resolveRoleSessionName(oidcToken, options)
+	cacheContext[audit.ContextKeyRoleSessionName] = roleSessionName
+	audit.PutOidcTokenClaims(cacheContext, oidcToken)
+	stsResponse, err := assumeRoleWithOidc(client, oidcToken, roleSessionName, options)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error assuming role: %v", err)
 		return 600, "", err
@@ -143,6 +163,18 @@ func isContentExpiringOrExpired(s *utils.StringWithTime) bool {
 	return !valid
 }
 
+func getContentExpiration(content string) time.Time {
+	stsToken, err := UnmarshalStsToken(content)
+	if err != nil {
+		return time.Time{}
+	}
+	expiration, err := time.Parse(time.RFC3339Nano, stsToken.Expiration)
+	if err != nil {
+		return time.Time{}
+	}
+	return expiration
+}
+
 func isContentExpired(s *utils.StringWithTime) bool {
 	stsToken, err := UnmarshalStsToken(s.Content)
 	if err != nil {
@@ -153,17 +185,19 @@ func isContentExpired(s *utils.StringWithTime) bool {
 	return !valid
 }
 
-func assumeRoleWithOidc(client *sts20150401.Client, oidcToken string, options *FetchStsWithOidcOptions) (
-	*sts20150401.AssumeRoleWithOIDCResponse, error) {
-
-	var roleSessionName string
+func resolveRoleSessionName(oidcToken string, options *FetchStsWithOidcOptions) string {
 	if options.RoleSessionName != "" {
-		roleSessionName = options.RoleSessionName
-	} else {
-		roleSessionName = cloud_common.GenerateRoleSessionName(oidcToken)
-		idaaslog.Info.PrintfLn(
-			"Assume role session name not specified, use role session name %s", roleSessionName)
+		return options.RoleSessionName
 	}
+	roleSessionName := cloud_common.GenerateRoleSessionName(oidcToken)
+	idaaslog.Info.PrintfLn(
+		"Assume role session name not specified, use role session name %s", roleSessionName)
+	return roleSessionName
+}
+
+func assumeRoleWithOidc(client *sts20150401.Client, oidcToken, roleSessionName string, options *FetchStsWithOidcOptions) (
+	*sts20150401.AssumeRoleWithOIDCResponse, error) {
+
 	idaaslog.Debug.PrintfLn("Assume role, OIDCProviderArn: %s, RoleArn: %s, RoleSessionName: %s",
 		options.OidcProviderArn, options.RoleArn, options.RoleSessionName)
 	assumeRoleWithOidcRequest := &sts20150401.AssumeRoleWithOIDCRequest{
@@ -194,3 +228,8 @@ func createStsClient(endpoint string) (*sts20150401.Client, error) {
 	}
 	return client, err
 }
+
+// CloudTokenCacheKey cache key of category cloud_token, also used by cache and logout commands
+func CloudTokenCacheKey(profile string, alibabaCloudStsConfig *config.AlibabaCloudStsConfig) string {
+	return fmt.Sprintf("%s_%s", profile, alibabaCloudStsConfig.Digest()[0:32])
+}
@@ -8,6 +8,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	FormatAwsCredentialProcess = "aws-credential-process"
+)
+
 type AwsStsToken struct {
 	Version         int       `json:"Version"`
 	AccessKeyId     string    `json:"AccessKeyId"`
@@ -16,6 +20,68 @@ type AwsStsToken struct {
 	Expiration      time.Time `json:"Expiration"`
 }
 
+// AwsStsTokenContainerCredentials is compatible with AWS container credential provider,
+// which is used by AWS_CONTAINER_CREDENTIALS_FULL_URI and AWS_CONTAINER_AUTHORIZATION_TOKEN
+type AwsStsTokenContainerCredentials struct {
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+	Expiration      string `json:"Expiration"`
+}
+
+// AwsStsTokenImdsCredentials is compatible with EC2 instance metadata service
+// http://169.254.169.254/latest/meta-data/iam/security-credentials/<role-name>
+type AwsStsTokenImdsCredentials struct {
+	Code            string `json:"Code"`
+	LastUpdated     string `json:"LastUpdated"`
+	Type            string `json:"Type"`
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+	Expiration      string `json:"Expiration"`
+}
+
+func (t *AwsStsToken) ConvertToImdsCredentials(lastUpdated time.Time) *AwsStsTokenImdsCredentials {
+	return &AwsStsTokenImdsCredentials{
+		Code:            "Success",
+		LastUpdated:     lastUpdated.UTC().Format(time.RFC3339),
+		Type:            "AWS-HMAC",
+		AccessKeyId:     t.AccessKeyId,
+		SecretAccessKey: t.SecretAccessKey,
+		Token:           t.SessionToken,
+		Expiration:      t.Expiration.UTC().Format(time.RFC3339),
+	}
+}
+
+// AwsStsTokenCredentialProcess
+// https://docs.aws.amazon.com/cli/v1/userguide/cli-configure-sourcing-external.html
+type AwsStsTokenCredentialProcess struct {
+	Version         int    `json:"Version"`
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Expiration      string `json:"Expiration"`
+}
+
+func (t *AwsStsToken) ConvertToCredentialProcess() *AwsStsTokenCredentialProcess {
+	return &AwsStsTokenCredentialProcess{
+		Version:         1,
+		AccessKeyId:     t.AccessKeyId,
+		SecretAccessKey: t.SecretAccessKey,
+		SessionToken:    t.SessionToken,
+		Expiration:      t.Expiration.UTC().Format(time.RFC3339),
+	}
+}
+
+func (t *AwsStsToken) ConvertToContainerCredentials() *AwsStsTokenContainerCredentials {
+	return &AwsStsTokenContainerCredentials{
+		AccessKeyId:     t.AccessKeyId,
+		SecretAccessKey: t.SecretAccessKey,
+		Token:           t.SessionToken,
+		Expiration:      t.Expiration.UTC().Format(time.RFC3339),
+	}
+}
+
 func (t *AwsStsToken) Marshal() (string, error) {
 	if t == nil {
 		return "null", nil
@@ -27,6 +93,24 @@ func (t *AwsStsToken) Marshal() (string, error) {
 	return string(tokenBytes), nil
 }
 
+// MarshalWithFormat Alibaba Cloud formats aliyuncli and ossutilv2 output raw token,
+// compatible with versions before aws-credential-process is supported
+func (t *AwsStsToken) MarshalWithFormat(format string) (string, error) {
+	var token any
+	if format == "" || format == "raw" || format == "aliyuncli" || format == "ossutilv2" {
+		token = t
+	} else if format == FormatAwsCredentialProcess {
+		token = t.ConvertToCredentialProcess()
+	} else {
+		return "", errors.New("unknown format " + format)
+	}
+	tokenBytes, err := json.Marshal(token)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal aws sts token failed")
+	}
+	return string(tokenBytes), nil
+}
+
 func UnmarshalStsToken(token string) (*AwsStsToken, error) {
 	var awsStsToken AwsStsToken
 	err := json.Unmarshal([]byte(token), &awsStsToken)
@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMarshalWithFormat(t *testing.T) {
+	awsStsToken := &AwsStsToken{
+		AccessKeyId:     "ASIA-TEST",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		Expiration:      time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	// Alibaba Cloud formats output raw token, compatible with previous versions
+	for _, format := range []string{"", "raw", "aliyuncli", "ossutilv2"} {
+		output, err := awsStsToken.MarshalWithFormat(format)
+		if err != nil {
+			t.Fatalf("format: %s, error: %v", format, err)
+		}
+		var token AwsStsToken
+		if err = json.Unmarshal([]byte(output), &token); err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessKeyId != "ASIA-TEST" || token.SessionToken != "token" {
+			t.Errorf("format: %s, unexpected output: %s", format, output)
+		}
+	}
+
+	output, err := awsStsToken.MarshalWithFormat(FormatAwsCredentialProcess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"Version":1,"AccessKeyId":"ASIA-TEST","SecretAccessKey":"secret","SessionToken":"token","Expiration":"2030-01-02T03:04:05Z"}`
+	if output != expected {
+		t.Errorf("unexpected credential process output: %s", output)
+	}
+
+	if _, err = awsStsToken.MarshalWithFormat("unknown"); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_common"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
@@ -57,14 +58,15 @@ func FetchAwsStsWithOidcConfig(profile string, awsCloudStsConfig *config.AwsClou
 
 func FetchStsWithOidc(profile string, awsCloudStsConfig *config.AwsCloudStsConfig, options *FetchAwsStsWithOidcOptions) (*AwsStsToken, error) {
 	digest := awsCloudStsConfig.Digest()
+	cacheContext := map[string]interface{}{
+		"profile": profile,
+		"digest":  digest,
+		"config":  awsCloudStsConfig,
+	}
 	readCacheFileOptions := &utils.ReadCacheOptions{
-		Context: map[string]interface{}{
-			"profile": profile,
-			"digest":  digest,
-			"config":  awsCloudStsConfig,
-		},
+		Context: cacheContext,
 		FetchContent: func() (int, string, error) {
-			return fetchContent(options)
+			return fetchContent(options, cacheContext)
 		},
 		IsContentExpiringOrExpired: func(s *utils.StringWithTime) bool {
 			return isContentExpiringOrExpired(s)
@@ -72,21 +74,34 @@ func FetchStsWithOidc(profile string, awsCloudStsConfig *config.AwsCloudStsConfi
 		IsContentExpired: func(s *utils.StringWithTime) bool {
 			return isContentExpired(s)
 		},
-		ForceNew: options.ForceNew,
+		GetContentExpiration: getContentExpiration,
+		ForceNew:             options.ForceNew,
 	}
 
-	cacheKey := fmt.Sprintf("%s_%s", profile, digest[0:32])
+	cacheKey := CloudTokenCacheKey(profile, awsCloudStsConfig)
 	idaaslog.Debug.PrintfLn("Cache key: %s %s", constants.CategoryCloudToken, cacheKey)
-	stsTokenStr, err := utils.ReadCacheFileWithEncryptionCallback(
+	stsTokenResult, err := utils.ReadCacheFileWithEncryptionCallbackResult(
 		constants.CategoryCloudToken, cacheKey, readCacheFileOptions)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error fetch cloud_token token with OIDC: %v", err)
 		return nil, err
 	}
-	return UnmarshalStsToken(stsTokenStr)
+	stsToken, err := UnmarshalStsToken(stsTokenResult.Content)
+	if err != nil {
+		return nil, err
+	}
+	auditRecord := &audit.Record{
+		Profile:   profile,
+		CloudType: audit.CloudTypeAws,
+		RoleArn:   awsCloudStsConfig.RoleArn,
+	}
+	auditRecord.FillFromCacheContext(stsTokenResult.Context, stsTokenResult.FromCache)
+	auditRecord.SetExpiration(stsToken.Expiration)
+	audit.Log(auditRecord)
+	return stsToken, nil
 }
 
-func fetchContent(options *FetchAwsStsWithOidcOptions) (int, string, error) {
+func fetchContent(options *FetchAwsStsWithOidcOptions, cacheContext map[string]interface{}) (int, string, error) {
 	client, err := createAwsStsClient(options.Region)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error creating aws sts client: %v", err)
@@ -97,7 +112,10 @@ func fetchContent(options *FetchAwsStsWithOidcOptions) (int, string, error) {
 		idaaslog.Error.PrintfLn("Error fetching oidc token: %v", err)
 		return 600, "", err
 	}
-	stsResponse, err := assumeRoleWithWebIdentity(client, oidcToken, options)
+	roleSessionName := resolveRoleSessionName(oidcToken, options)
+	cacheContext[audit.ContextKeyRoleSessionName] = roleSessionName
+	audit.PutOidcTokenClaims(cacheContext, oidcToken)
+	stsResponse, err := assumeRoleWithWebIdentity(client, oidcToken, roleSessionName, options)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error assuming role: %v", err)
 		return 600, "", err
@@ -128,6 +146,14 @@ func isContentExpiringOrExpired(s *utils.StringWithTime) bool {
 	return !valid
 }
 
+func getContentExpiration(content string) time.Time {
+	stsToken, err := UnmarshalStsToken(content)
+	if err != nil {
+		return time.Time{}
+	}
+	return stsToken.Expiration
+}
+
 func isContentExpired(s *utils.StringWithTime) bool {
 	stsToken, err := UnmarshalStsToken(s.Content)
 	if err != nil {
@@ -138,17 +164,19 @@ func isContentExpired(s *utils.StringWithTime) bool {
 	return !valid
 }
 
-func assumeRoleWithWebIdentity(client *sts.Client, oidcToken string, options *FetchAwsStsWithOidcOptions) (
-	*sts.AssumeRoleWithWebIdentityOutput, error) {
-
-	var roleSessionName string
+func resolveRoleSessionName(oidcToken string, options *FetchAwsStsWithOidcOptions) string {
 	if options.RoleSessionName != "" {
-		roleSessionName = options.RoleSessionName
-	} else {
-		roleSessionName = cloud_common.GenerateRoleSessionName(oidcToken)
-		idaaslog.Info.PrintfLn(
-			"Assume role session name not specified, use role session name %s", roleSessionName)
+		return options.RoleSessionName
 	}
+	roleSessionName := cloud_common.GenerateRoleSessionName(oidcToken)
+	idaaslog.Info.PrintfLn(
+		"Assume role session name not specified, use role session name %s", roleSessionName)
+	return roleSessionName
+}
+
+func assumeRoleWithWebIdentity(client *sts.Client, oidcToken, roleSessionName string, options *FetchAwsStsWithOidcOptions) (
+	*sts.AssumeRoleWithWebIdentityOutput, error) {
+
 	idaaslog.Debug.PrintfLn("Assume role, RoleArn: %s, RoleSessionName: %s",
 		options.RoleArn, options.RoleSessionName)
 	assumeRoleWithWebIdentityInput := &sts.AssumeRoleWithWebIdentityInput{
@@ -181,3 +209,8 @@ func createAwsStsClient(region string) (*sts.Client, error) {
 	client := sts.NewFromConfig(cfg)
 	return client, nil
 }
+
+// CloudTokenCacheKey cache key of category cloud_token, also used by cache and logout commands
+func CloudTokenCacheKey(profile string, awsCloudStsConfig *config.AwsCloudStsConfig) string {
+	return fmt.Sprintf("%s_%s", profile, awsCloudStsConfig.Digest()[0:32])
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
@@ -27,6 +28,14 @@ type FetchCloudAccountTokenWithOidcOptions struct {
 	ForceNew         bool
 }
 
+// GetAccessTokenProvider returns a copy of token provider which fetches Access Token,
+// MUST be Access Token for Cloud Account Token obtain, token provider in config is not changed
+func GetAccessTokenProvider(tokenProvider *config.OidcTokenProviderConfig) *config.OidcTokenProviderConfig {
+	accessTokenProvider := *tokenProvider
+	accessTokenProvider.TokenType = oidc.TokenAccessToken
+	return &accessTokenProvider
+}
+
 func FetchCloudAccountTokenWithOidcConfig(profile string, cloudAccountTokenConfig *config.CloudAccountTokenConfig,
 	configOptions *FetchCloudAccountTokenWithOidcConfigOptions) (
 	*CloudAccountToken, error) {
@@ -48,9 +57,8 @@ func FetchCloudAccountTokenWithOidcConfig(profile string, cloudAccountTokenConfi
 				ForceNew: configOptions.ForceNew,
 				CacheKey: cloudAccountTokenConfig.AccessTokenProvider.GetCacheKey(),
 			}
-			// MUST be Access Token for Cloud Account Token obtain
-			cloudAccountTokenConfig.AccessTokenProvider.TokenType = oidc.TokenAccessToken
-			return idp.FetchOidcToken(profile, cloudAccountTokenConfig.AccessTokenProvider, fetchOidcTokenOptions)
+			accessTokenProvider := GetAccessTokenProvider(cloudAccountTokenConfig.AccessTokenProvider)
+			return idp.FetchOidcToken(profile, accessTokenProvider, fetchOidcTokenOptions)
 		},
 		ForceNew: configOptions.ForceNew || configOptions.ForceNewCloudToken,
 	}
@@ -59,14 +67,15 @@ func FetchCloudAccountTokenWithOidcConfig(profile string, cloudAccountTokenConfi
 
 func FetchCloudAccountTokenWithOidc(profile string, cloudAccountTokenConfig *config.CloudAccountTokenConfig, options *FetchCloudAccountTokenWithOidcOptions) (*CloudAccountToken, error) {
 	digest := cloudAccountTokenConfig.Digest()
+	cacheContext := map[string]interface{}{
+		"profile": profile,
+		"digest":  digest,
+		"config":  cloudAccountTokenConfig,
+	}
 	readCacheFileOptions := &utils.ReadCacheOptions{
-		Context: map[string]interface{}{
-			"profile": profile,
-			"digest":  digest,
-			"config":  cloudAccountTokenConfig,
-		},
+		Context: cacheContext,
 		FetchContent: func() (int, string, error) {
-			return fetchContent(options)
+			return fetchContent(options, cacheContext)
 		},
 		ForceNew: options.ForceNew,
 		IsContentExpiringOrExpired: func(s *utils.StringWithTime) bool {
@@ -75,25 +84,42 @@ func FetchCloudAccountTokenWithOidc(profile string, cloudAccountTokenConfig *con
 		IsContentExpired: func(s *utils.StringWithTime) bool {
 			return isContentExpired(s)
 		},
+		GetContentExpiration: getContentExpiration,
 	}
 
-	cacheKey := fmt.Sprintf("%s_%s", profile, digest[0:32])
+	cacheKey := CloudTokenCacheKey(profile, cloudAccountTokenConfig)
 	idaaslog.Debug.PrintfLn("Cache key: %s %s", constants.CategoryCloudToken, cacheKey)
-	cloudAccountTokenStr, err := utils.ReadCacheFileWithEncryptionCallback(
+	cloudAccountTokenResult, err := utils.ReadCacheFileWithEncryptionCallbackResult(
 		constants.CategoryCloudToken, cacheKey, readCacheFileOptions)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error fetch cloud_token token with OIDC: %v", err)
 		return nil, err
 	}
-	return UnmarshalCloudAccountToken(cloudAccountTokenStr)
+	cloudAccountToken, err := UnmarshalCloudAccountToken(cloudAccountTokenResult.Content)
+	if err != nil {
+		return nil, err
+	}
+	auditRecord := &audit.Record{
+		Profile:   profile,
+		CloudType: audit.CloudTypeCloudAccount,
+
+		CloudAccountRoleExternalId: cloudAccountTokenConfig.CloudAccountRoleExternalId,
+	}
+	auditRecord.FillFromCacheContext(cloudAccountTokenResult.Context, cloudAccountTokenResult.FromCache)
+	if cloudAccountToken.CloudAccountRoleAccessCredential != nil {
+		auditRecord.SetExpiration(time.Unix(cloudAccountToken.CloudAccountRoleAccessCredential.AccessCredentialExpiresAt, 0))
+	}
+	audit.Log(auditRecord)
+	return cloudAccountToken, nil
 }
 
-func fetchContent(options *FetchCloudAccountTokenWithOidcOptions) (int, string, error) {
+func fetchContent(options *FetchCloudAccountTokenWithOidcOptions, cacheContext map[string]interface{}) (int, string, error) {
 	accessToken, err := options.FetchAccessToken()
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error fetching access token: %v", err)
 		return 600, "", err
 	}
+	audit.PutOidcTokenClaims(cacheContext, accessToken)
 	cloudAccountTokenJson, err := fetchCloudAccountToken(options.Endpoint, options.RoleExternalId, accessToken)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error fetching Cloud Account token: %v", err)
@@ -112,6 +138,14 @@ func isContentExpiringOrExpired(s *utils.StringWithTime) bool {
 	return !valid
 }
 
+func getContentExpiration(content string) time.Time {
+	cloudAccountToken, err := UnmarshalCloudAccountToken(content)
+	if err != nil || cloudAccountToken.CloudAccountRoleAccessCredential == nil {
+		return time.Time{}
+	}
+	return time.Unix(cloudAccountToken.CloudAccountRoleAccessCredential.AccessCredentialExpiresAt, 0)
+}
+
 func isContentExpired(s *utils.StringWithTime) bool {
 	cloudAccountToken, err := UnmarshalCloudAccountToken(s.Content)
 	if err != nil {
@@ -142,3 +176,8 @@ func fetchCloudAccountToken(cloudAccountEndpoint, cloudAccountRoleExternalId, ac
 	idaaslog.Unsafe.PrintfLn("Fetch cloud account token: %s", cloudAccountTokenJson)
 	return cloudAccountTokenJson, nil
 }
+
+// CloudTokenCacheKey cache key of category cloud_token, also used by cache and logout commands
+func CloudTokenCacheKey(profile string, cloudAccountTokenConfig *config.CloudAccountTokenConfig) string {
+	return fmt.Sprintf("%s_%s", profile, cloudAccountTokenConfig.Digest()[0:32])
+}
@@ -1,8 +1,14 @@
 package cloud
 
 import (
+	"time"
+
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_account"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/kms"
+	"github.com/pkg/errors"
 )
 
 func ConvertCloudAccountTokenAlibabaCloudStsTokenToAlibabaStsToken(t *cloud_account.CloudAccountTokenAlibabaCloudStsToken) *alibaba_cloud.StsToken {
@@ -17,3 +23,84 @@ func ConvertCloudAccountTokenAlibabaCloudStsTokenToAlibabaStsToken(t *cloud_acco
 		Expiration:      t.Expiration,
 	}
 }
+
+// FetchKmsCredential fetches cloud STS token of profile in config file of KMS signer, used as KMS signer credential
+func FetchKmsCredential(configFilename, profile string) (*kms.Credential, error) {
+	sts, _, err := FetchCloudStsFromDefaultConfig(configFilename, profile, &FetchCloudStsOptions{IgnoreParseFromProfile: true})
+	if err != nil {
+		return nil, err
+	}
+	switch t := sts.(type) {
+	case *alibaba_cloud.StsToken:
+		return &kms.Credential{AccessKeyId: t.AccessKeyId, AccessKeySecret: t.AccessKeySecret, SecurityToken: t.StsToken}, nil
+	case *aws.AwsStsToken:
+		return &kms.Credential{AccessKeyId: t.AccessKeyId, AccessKeySecret: t.SecretAccessKey, SecurityToken: t.SessionToken}, nil
+	case *cloud_account.CloudAccountToken:
+		if t.IsAlibabaCloudToken() && t.CloudAccountRoleAccessCredential != nil {
+			stsToken := ConvertCloudAccountTokenAlibabaCloudStsTokenToAlibabaStsToken(t.CloudAccountRoleAccessCredential.AlibabaCloudStsToken)
+			if stsToken != nil {
+				return &kms.Credential{AccessKeyId: stsToken.AccessKeyId, AccessKeySecret: stsToken.AccessKeySecret, SecurityToken: stsToken.StsToken}, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("profile %s does not issue Alibaba Cloud or AWS STS token, type: %s", profile, GetStsType(sts))
+}
+
+// GetStsExpiration returns expiration of cloud STS token, expiration of OIDC token is the earlier one of
+// ID token and access token, returns false when expiration is unknown
+func GetStsExpiration(sts any) (time.Time, bool) {
+	switch t := sts.(type) {
+	case *alibaba_cloud.StsToken:
+		expiration, err := time.Parse(time.RFC3339Nano, t.Expiration)
+		return expiration, err == nil
+	case *aws.AwsStsToken:
+		return t.Expiration, true
+	case *cloud_account.CloudAccountToken:
+		if t.CloudAccountRoleAccessCredential == nil {
+			return time.Time{}, false
+		}
+		return time.Unix(t.CloudAccountRoleAccessCredential.AccessCredentialExpiresAt, 0), true
+	case *oidc.OidcToken:
+		var expiration time.Time
+		if t.IdToken != "" {
+			if idTokenPayload, err := oidc.ParseIdTokenPayload(t.IdToken); err == nil && idTokenPayload.Exp > 0 {
+				expiration = time.Unix(idTokenPayload.Exp, 0)
+			}
+		}
+		if t.ExpiresAt > 0 && (expiration.IsZero() || t.ExpiresAt < expiration.Unix()) {
+			expiration = time.Unix(t.ExpiresAt, 0)
+		}
+		return expiration, !expiration.IsZero()
+	}
+	return time.Time{}, false
+}
+
+// IsStsExpiringOrExpired returns expiring and expired of cloud STS token, uses the same thresholds as file cache,
+// token with unknown expiration is expired
+func IsStsExpiringOrExpired(sts any) (bool, bool) {
+	expiringThreshold, expiredThreshold := 20*time.Minute, 3*time.Minute
+	if _, ok := sts.(*oidc.OidcToken); ok {
+		expiringThreshold, expiredThreshold = 3*time.Minute, 1*time.Minute
+	}
+	expiration, ok := GetStsExpiration(sts)
+	if !ok {
+		return true, true
+	}
+	validDuration := time.Until(expiration)
+	return validDuration <= expiringThreshold, validDuration <= expiredThreshold
+}
+
+// GetStsType returns cloud STS token type name
+func GetStsType(sts any) string {
+	switch sts.(type) {
+	case *alibaba_cloud.StsToken:
+		return "AlibabaCloud"
+	case *aws.AwsStsToken:
+		return "Aws"
+	case *cloud_account.CloudAccountToken:
+		return "CloudAccount"
+	case *oidc.OidcToken:
+		return "OidcToken"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,43 @@
+package cloud
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/oidc"
+)
+
+func TestIsStsExpiringOrExpired(t *testing.T) {
+	now := time.Now()
+	idToken := func(exp time.Time) string {
+		payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+		return "e30." + payload + ".sig"
+	}
+	tests := []struct {
+		name     string
+		sts      any
+		expiring bool
+		expired  bool
+	}{
+		{"aws valid", &aws.AwsStsToken{Expiration: now.Add(time.Hour)}, false, false},
+		{"aws expiring", &aws.AwsStsToken{Expiration: now.Add(10 * time.Minute)}, true, false},
+		{"aws expired", &aws.AwsStsToken{Expiration: now.Add(time.Minute)}, true, true},
+		{"alibaba cloud expiring", &alibaba_cloud.StsToken{Expiration: now.Add(10 * time.Minute).UTC().Format(time.RFC3339)}, true, false},
+		{"alibaba cloud bad expiration", &alibaba_cloud.StsToken{Expiration: "bad"}, true, true},
+		{"oidc valid", &oidc.OidcToken{ExpiresAt: now.Add(10 * time.Minute).Unix()}, false, false},
+		{"oidc ID token expiring", &oidc.OidcToken{IdToken: idToken(now.Add(2 * time.Minute)),
+			ExpiresAt: now.Add(time.Hour).Unix()}, true, false},
+		{"oidc unknown expiration", &oidc.OidcToken{}, true, true},
+	}
+	for _, test := range tests {
+		expiring, expired := IsStsExpiringOrExpired(test.sts)
+		if expiring != test.expiring || expired != test.expired {
+			t.Errorf("%s: expected expiring: %t, expired: %t, got: %t, %t",
+				test.name, test.expiring, test.expired, expiring, expired)
+		}
+	}
+}
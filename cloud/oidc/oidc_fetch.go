@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
@@ -35,18 +36,42 @@ func FetchOidcToken(profile string, oidcTokenProviderConfig *config.OidcTokenPro
 		IsContentExpired: func(s *utils.StringWithTime) bool {
 			return isContentExpired(options.FetchTokenType, s)
 		},
-		ForceNew: options.ForceNew || options.ForceNewCloudToken,
+		GetContentExpiration: getContentExpiration,
+		ForceNew:             options.ForceNew || options.ForceNewCloudToken,
 	}
 
-	cacheKey := fmt.Sprintf("%s_%s", profile, digest[0:32])
+	cacheKey := CloudTokenCacheKey(profile, oidcTokenProviderConfig)
 	idaaslog.Debug.PrintfLn("Cache key: %s %s", constants.CategoryCloudToken, cacheKey)
-	oidcTokenStr, err := utils.ReadCacheFileWithEncryptionCallback(
+	oidcTokenResult, err := utils.ReadCacheFileWithEncryptionCallbackResult(
 		constants.CategoryCloudToken, cacheKey, readCacheFileOptions)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Error fetch cloud_token token with OIDC: %v", err)
 		return nil, err
 	}
-	return UnmarshalOidcToken(oidcTokenStr)
+	oidcToken, err := UnmarshalOidcToken(oidcTokenResult.Content)
+	if err != nil {
+		return nil, err
+	}
+	auditOidcToken(profile, oidcToken, oidcTokenResult.FromCache)
+	return oidcToken, nil
+}
+
+func auditOidcToken(profile string, oidcToken *OidcToken, fromCache bool) {
+	claimsContext := map[string]interface{}{}
+	if oidcToken.IdToken != "" {
+		audit.PutOidcTokenClaims(claimsContext, oidcToken.IdToken)
+	} else if oidcToken.AccessToken != "" {
+		audit.PutOidcTokenClaims(claimsContext, oidcToken.AccessToken)
+	}
+	auditRecord := &audit.Record{
+		Profile:   profile,
+		CloudType: audit.CloudTypeOidcToken,
+	}
+	auditRecord.FillFromCacheContext(claimsContext, fromCache)
+	if oidcToken.ExpiresAt > 0 {
+		auditRecord.SetExpiration(time.Unix(oidcToken.ExpiresAt, 0))
+	}
+	audit.Log(auditRecord)
 }
 
 func fetchContent(oidcTokenProviderConfig *config.OidcTokenProviderConfig, options *FetchOidcTokenConfigOptions) (int, string, error) {
@@ -80,6 +105,14 @@ func isContentExpiringOrExpired(fetchTokenType FetchOidcTokenType, s *utils.Stri
 	return !valid
 }
 
+func getContentExpiration(content string) time.Time {
+	oidcToken, err := UnmarshalOidcToken(content)
+	if err != nil || oidcToken.ExpiresAt <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(oidcToken.ExpiresAt, 0)
+}
+
 func isContentExpired(fetchTokenType FetchOidcTokenType, s *utils.StringWithTime) bool {
 	oidcToken, err := UnmarshalOidcToken(s.Content)
 	if err != nil {
@@ -89,3 +122,8 @@ func isContentExpired(fetchTokenType FetchOidcTokenType, s *utils.StringWithTime
 	idaaslog.Debug.PrintfLn("Check OIDC Token is expired: %s", !valid)
 	return !valid
 }
+
+// CloudTokenCacheKey cache key of category cloud_token, also used by cache and logout commands
+func CloudTokenCacheKey(profile string, oidcTokenProviderConfig *config.OidcTokenProviderConfig) string {
+	return fmt.Sprintf("%s_%s", profile, oidcTokenProviderConfig.Digest()[0:32])
+}
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idp"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+type AgentOptions struct {
+	ConfigFilename     string
+	Profiles           []string
+	Interval           time.Duration
+	Socket             string
+	ForceNew           bool
+	ForceNewCloudToken bool
+}
+
+type AgentStatus struct {
+	Pid      int              `json:"pid"`
+	Startup  int64            `json:"startup"` // Unix Epoch(milliseconds)
+	Interval string           `json:"interval"`
+	Profiles []*ProfileStatus `json:"profiles"`
+}
+
+type ProfileStatus struct {
+	Profile     string `json:"profile"`
+	Type        string `json:"type"`
+	Expiration  string `json:"expiration,omitempty"`
+	LastRefresh int64  `json:"last_refresh"` // Unix Epoch(milliseconds)
+	LastError   string `json:"last_error,omitempty"`
+}
+
+type agent struct {
+	options  *AgentOptions
+	startup  time.Time
+	mutex    sync.Mutex
+	statuses map[string]*ProfileStatus
+}
+
+func runAgent(options *AgentOptions) error {
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(options.ConfigFilename)
+	if err != nil {
+		return err
+	}
+	profiles := options.Profiles
+	if len(profiles) == 0 {
+		for profile, cloudStsConfig := range cloudCredentialConfig.Profile {
+			if cloudStsConfig != nil && (cloudStsConfig.AlibabaCloud != nil || cloudStsConfig.Aws != nil ||
+				cloudStsConfig.OidcToken != nil || cloudStsConfig.CloudAccount != nil) {
+				profiles = append(profiles, profile)
+			}
+		}
+		sort.Strings(profiles)
+	}
+	if len(profiles) == 0 {
+		return errors.New("no profile found")
+	}
+	for _, profile := range profiles {
+		if cloudCredentialConfig.Profile[profile] == nil {
+			return errors.Errorf("profile: %s not found", profile)
+		}
+	}
+
+	// agent runs in background, MUST NOT block on device code or authorization code flow,
+	// OIDC tokens are refreshed via refresh tokens cached in token_response
+	idp.DisableInteractiveFlow()
+
+	a := &agent{
+		options:  options,
+		startup:  time.Now(),
+		statuses: map[string]*ProfileStatus{},
+	}
+	for _, profile := range profiles {
+		a.statuses[profile] = &ProfileStatus{Profile: profile}
+	}
+
+	server, err := listenStatusSocket(options.Socket, a.getStatus)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = server.Close()
+		_ = os.Remove(options.Socket)
+	}()
+	utils.Stderr.Fprintf("Agent started, profiles: %v, status socket: %s\n", profiles, options.Socket)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	// ForceNew and ForceNewCloudToken only take effect at first refresh
+	a.refreshAll(profiles, cloudCredentialConfig, options.ForceNew, options.ForceNewCloudToken)
+	for {
+		select {
+		case <-signals:
+			utils.Stderr.Fprintf("Agent stopped\n")
+			return nil
+		case <-ticker.C:
+			// reload config, so config changes take effect without restart
+			reloadedConfig, err := config.LoadCloudCredentialConfig(options.ConfigFilename)
+			if err != nil {
+				idaaslog.Error.PrintfLn("Agent reload config failed: %v", err)
+			} else {
+				cloudCredentialConfig = reloadedConfig
+			}
+			a.refreshAll(profiles, cloudCredentialConfig, false, false)
+		}
+	}
+}
+
+func (a *agent) refreshAll(profiles []string, cloudCredentialConfig *config.CloudCredentialConfig,
+	forceNew, forceNewCloudToken bool) {
+	for _, profile := range profiles {
+		cloudStsConfig := cloudCredentialConfig.Profile[profile]
+		if cloudStsConfig == nil {
+			a.updateStatus(profile, nil, errors.Errorf("profile: %s not found", profile))
+			continue
+		}
+		// file cache fetches new token when it is expiring(IsValidAtLeastThreshold), or else cached token is used
+		options := &cloud.FetchCloudStsOptions{
+			ForceNew:           forceNew,
+			ForceNewCloudToken: forceNewCloudToken,
+		}
+		sts, err := cloud.FetchCloudSts(profile, cloudStsConfig, options)
+		if err != nil {
+			idaaslog.Error.PrintfLn("Agent refresh profile: %s failed: %v", profile, err)
+		}
+		a.updateStatus(profile, sts, err)
+	}
+}
+
+func (a *agent) updateStatus(profile string, sts any, err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	status := a.statuses[profile]
+	if err != nil {
+		status.LastError = err.Error()
+		return
+	}
+	status.LastError = ""
+	status.Type = cloud.GetStsType(sts)
+	status.LastRefresh = time.Now().UnixMilli()
+	if expiration, ok := cloud.GetStsExpiration(sts); ok {
+		status.Expiration = expiration.Format(time.RFC3339)
+	}
+}
+
+func (a *agent) getStatus() *AgentStatus {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	agentStatus := &AgentStatus{
+		Pid:      os.Getpid(),
+		Startup:  a.startup.UnixMilli(),
+		Interval: a.options.Interval.String(),
+	}
+	var profiles []string
+	for profile := range a.statuses {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		status := *a.statuses[profile]
+		agentStatus.Profiles = append(agentStatus.Profiles, &status)
+	}
+	return agentStatus
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+)
+
+func TestAgentRefreshAndStatus(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCacheBackend, utils.CacheBackendFile)
+
+	fetchCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchCount++
+		_ = json.NewEncoder(w).Encode(&oidc.TokenResponse{AccessToken: "at1", TokenType: "Bearer", ExpiresIn: 3600})
+	}))
+	defer server.Close()
+
+	cloudCredentialConfig := &config.CloudCredentialConfig{
+		Profile: map[string]*config.CloudStsConfig{
+			"p1": {OidcToken: &config.OidcTokenProviderConfig{
+				OidcTokenProviderClientCredentials: &config.OidcTokenProviderClientCredentialsConfig{
+					TokenEndpoint: server.URL + "/token",
+					ClientId:      "client1",
+					ClientSecret:  "secret1",
+				},
+			}},
+		},
+	}
+	a := &agent{
+		options:  &AgentOptions{Interval: time.Minute},
+		startup:  time.Now(),
+		statuses: map[string]*ProfileStatus{"p1": {Profile: "p1"}, "p2": {Profile: "p2"}},
+	}
+	// second refresh uses file cache, token is not expiring
+	for i := 0; i < 2; i++ {
+		a.refreshAll([]string{"p1", "p2"}, cloudCredentialConfig, false, false)
+	}
+	if fetchCount != 1 {
+		t.Errorf("expected 1 fetch, got: %d", fetchCount)
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	statusServer, err := listenStatusSocket(socket, a.getStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer statusServer.Close()
+	if _, err = listenStatusSocket(socket, a.getStatus); err == nil {
+		t.Error("second agent should not listen the same socket")
+	}
+
+	agentStatus, err := fetchAgentStatus(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agentStatus.Profiles) != 2 || agentStatus.Interval != "1m0s" {
+		t.Fatalf("unexpected agent status: %+v", agentStatus)
+	}
+	p1, p2 := agentStatus.Profiles[0], agentStatus.Profiles[1]
+	if p1.Profile != "p1" || p1.Type != "OidcToken" || p1.LastError != "" || p1.LastRefresh == 0 {
+		t.Errorf("unexpected status of p1: %+v", p1)
+	}
+	if expiration, err := time.Parse(time.RFC3339, p1.Expiration); err != nil || time.Until(expiration) < 50*time.Minute {
+		t.Errorf("unexpected expiration of p1: %s", p1.Expiration)
+	}
+	if p2.Profile != "p2" || p2.LastError == "" || p2.LastRefresh != 0 {
+		t.Errorf("missing profile p2 should be error: %+v", p2)
+	}
+}
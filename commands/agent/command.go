@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	agentSocketFilename = "agent.sock"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	stringSliceFlagProfile = &cli.StringSliceFlag{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Usage:   "IDaaS Profile, can be assigned multiple times (default all profiles)",
+	}
+	durationFlagInterval = &cli.DurationFlag{
+		Name:  "interval",
+		Usage: "Check interval (default 1m)",
+	}
+	stringFlagSocket = &cli.StringFlag{
+		Name:  "socket",
+		Usage: "Agent status unix socket (default ~/.aliyun/alibaba-cloud-idaas/agent.sock)",
+	}
+	boolFlagForceNew = &cli.BoolFlag{
+		Name:    "force-new",
+		Aliases: []string{"N"},
+		Usage:   "Force fetch cloud token at first refresh, ignore all cache",
+	}
+	boolFlagForceNewCloudToken = &cli.BoolFlag{
+		Name:  "force-new-cloud-token",
+		Usage: "Force fetch cloud token at first refresh (lower cache enabled)",
+	}
+	boolFlagJson = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Output status in JSON format",
+	}
+	boolFlagNoColor = &cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Output without color",
+	}
+)
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		stringSliceFlagProfile,
+		durationFlagInterval,
+		stringFlagSocket,
+		boolFlagForceNew,
+		boolFlagForceNewCloudToken,
+	}
+	return &cli.Command{
+		Name:  "agent",
+		Usage: "Run agent, refresh cloud tokens in background",
+		Flags: flags,
+		Subcommands: []*cli.Command{
+			buildStatusCommand(),
+		},
+		Action: func(context *cli.Context) error {
+			socket, err := getSocketFilename(context.String("socket"))
+			if err != nil {
+				return err
+			}
+			interval := context.Duration("interval")
+			if interval == 0 {
+				interval = time.Minute
+			}
+			if interval < 10*time.Second {
+				return errors.Errorf("interval %s is too short, at least 10s", interval)
+			}
+			return runAgent(&AgentOptions{
+				ConfigFilename:     context.String("config"),
+				Profiles:           context.StringSlice("profile"),
+				Interval:           interval,
+				Socket:             socket,
+				ForceNew:           context.Bool("force-new"),
+				ForceNewCloudToken: context.Bool("force-new-cloud-token"),
+			})
+		},
+	}
+}
+
+func buildStatusCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagSocket,
+		boolFlagJson,
+		boolFlagNoColor,
+	}
+	return &cli.Command{
+		Name:  "status",
+		Usage: "Show agent status",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			socket, err := getSocketFilename(context.String("socket"))
+			if err != nil {
+				return err
+			}
+			agentStatus, err := fetchAgentStatus(socket)
+			if err != nil {
+				return err
+			}
+			if context.Bool("json") {
+				agentStatusJson, err := json.MarshalIndent(agentStatus, "", "  ")
+				if err != nil {
+					return err
+				}
+				utils.Stdout.Println(string(agentStatusJson))
+				return nil
+			}
+			showAgentStatus(agentStatus, !context.Bool("no-color"))
+			return nil
+		},
+	}
+}
+
+func showAgentStatus(agentStatus *AgentStatus, color bool) {
+	utils.Stdout.Fprintf("Agent pid: %d, startup: %s, interval: %s\n",
+		agentStatus.Pid, time.UnixMilli(agentStatus.Startup).Format(time.RFC3339), agentStatus.Interval)
+	for _, profileStatus := range agentStatus.Profiles {
+		var state string
+		if profileStatus.LastError != "" {
+			state = utils.Red("error", color)
+		} else if profileStatus.LastRefresh == 0 {
+			state = utils.Yellow("pending", color)
+		} else {
+			state = utils.Green("ok", color)
+		}
+		if profileStatus.Type != "" {
+			utils.Stdout.Fprintf(" - %s [%s] %s\n", profileStatus.Profile, profileStatus.Type, state)
+		} else {
+			utils.Stdout.Fprintf(" - %s %s\n", profileStatus.Profile, state)
+		}
+		if profileStatus.Expiration != "" {
+			utils.Stdout.Fprintf("   Expiration  : %s\n", profileStatus.Expiration)
+		}
+		if profileStatus.LastRefresh > 0 {
+			utils.Stdout.Fprintf("   Last refresh: %s\n", time.UnixMilli(profileStatus.LastRefresh).Format(time.RFC3339))
+		}
+		if profileStatus.LastError != "" {
+			utils.Stdout.Fprintf("   Last error  : %s\n", profileStatus.LastError)
+		}
+	}
+}
+
+func getSocketFilename(socket string) (string, error) {
+	if socket != "" {
+		return socket, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get home dir failed")
+	}
+	return filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir, agentSocketFilename), nil
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	statusPath = "/status"
+)
+
+func listenStatusSocket(socket string, getStatus func() *AgentStatus) (*http.Server, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return nil, errors.Wrapf(err, "create socket dir failed")
+	}
+	if err := utils.RemoveStaleUnixSocket(socket); err != nil {
+		if errors.Is(err, utils.ErrUnixSocketInUse) {
+			return nil, errors.Errorf("agent is already running, socket: %s", socket)
+		}
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen unix socket: %s failed", socket)
+	}
+	if err = os.Chmod(socket, 0600); err != nil {
+		_ = listener.Close()
+		return nil, errors.Wrapf(err, "chmod unix socket: %s failed", socket)
+	}
+
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc(statusPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		statusJson, err := json.Marshal(getStatus())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(statusJson)
+	})
+	server := &http.Server{
+		Handler:           serveMux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	return server, nil
+}
+
+func fetchAgentStatus(socket string) (*AgentStatus, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	response, err := client.Get("http://agent" + statusPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connect agent socket: %s failed, is agent running?", socket)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read agent status failed")
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetch agent status failed, status: %d", response.StatusCode)
+	}
+	var agentStatus AgentStatus
+	if err = json.Unmarshal(body, &agentStatus); err != nil {
+		return nil, errors.Wrap(err, "parse agent status failed")
+	}
+	return &agentStatus, nil
+}
@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	stringSliceFlagProfile = &cli.StringSliceFlag{
+		Name:     "profile",
+		Aliases:  []string{"p"},
+		Usage:    "IDaaS Profile, can be assigned multiple times",
+		Required: true,
+	}
+	boolFlagDryRun = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Show cache entries to be removed only",
+	}
+	boolFlagNoColor = &cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Output without color",
+	}
+)
+
+func BuildCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Inspect and manage cache",
+		Subcommands: []*cli.Command{
+			buildLsCommand(),
+			buildPruneCommand(),
+			buildRmCommand(),
+		},
+	}
+}
+
+func buildLsCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		boolFlagNoColor,
+	}
+	return &cli.Command{
+		Name:  "ls",
+		Usage: "List cache entries",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			cloudCredentialConfig, err := loadConfig(context.String("config"))
+			if err != nil {
+				utils.Stderr.Fprintf("Load config failed: %v, profiles are resolved from cache only\n", err)
+			}
+			if err = checkFileCacheBackend(); err != nil {
+				return err
+			}
+			entries, err := listCacheEntries(NewProfileCacheKeys(cloudCredentialConfig))
+			if err != nil {
+				return err
+			}
+			showCacheEntries(entries, time.Now(), !context.Bool("no-color"))
+			return nil
+		},
+	}
+}
+
+func buildPruneCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		boolFlagDryRun,
+	}
+	return &cli.Command{
+		Name:  "prune",
+		Usage: "Remove expired cache entries, and entries not matching any configured profile",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			// without config, all entries would be treated as not matching
+			cloudCredentialConfig, err := loadConfig(context.String("config"))
+			if err != nil {
+				return err
+			}
+			if err = checkFileCacheBackend(); err != nil {
+				return err
+			}
+			return pruneCache(NewProfileCacheKeys(cloudCredentialConfig), time.Now(), context.Bool("dry-run"))
+		},
+	}
+}
+
+func buildRmCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		stringSliceFlagProfile,
+		boolFlagDryRun,
+	}
+	return &cli.Command{
+		Name:  "rm",
+		Usage: "Remove cache entries of profiles in all categories",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			cloudCredentialConfig, err := loadConfig(context.String("config"))
+			if err != nil {
+				utils.Stderr.Fprintf("Load config failed: %v, profiles are resolved from cache only\n", err)
+			}
+			return RemoveProfilesCache(cloudCredentialConfig, context.StringSlice("profile"), context.Bool("dry-run"))
+		},
+	}
+}
+
+func loadConfig(configFilename string) (*config.CloudCredentialConfig, error) {
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		// cache backend of default config file is still applied
+		config.ApplyDefaultConfigCacheBackend()
+		return nil, err
+	}
+	return cloudCredentialConfig, nil
+}
+
+func checkFileCacheBackend() error {
+	if cacheBackend := utils.GetCacheBackend(); cacheBackend != utils.CacheBackendFile {
+		return errors.Errorf("list cache is not supported by cache backend: %s", cacheBackend)
+	}
+	return nil
+}
+
+func showCacheEntries(entries []*cacheEntry, now time.Time, color bool) {
+	if len(entries) == 0 {
+		utils.Stdout.Fprintf("No cache entries found\n")
+		return
+	}
+	format := "%-24s %-16s %-25s %-12s %8s\n"
+	utils.Stdout.Fprintf("%s", utils.Bold(fmt.Sprintf(format, "PROFILE", "CATEGORY", "EXPIRATION", "REMAINING", "SIZE"), color))
+	hasUnmatched := false
+	for _, entry := range entries {
+		profile := "-"
+		if len(entry.Profiles) > 0 {
+			profile = strings.Join(entry.Profiles, ",")
+		}
+		if !entry.Matched {
+			profile += " *"
+			hasUnmatched = true
+		}
+		expiration := "-"
+		remaining := "-"
+		remainingColor := func(str string, color bool) string { return str }
+		if entry.Err != nil {
+			remaining, remainingColor = "unreadable", utils.Red
+		} else if !entry.Expiration.IsZero() {
+			expiration = entry.Expiration.Format(time.RFC3339)
+			if entry.IsExpired(now) {
+				remaining, remainingColor = "expired", utils.Red
+			} else {
+				remaining, remainingColor = entry.Expiration.Sub(now).Truncate(time.Second).String(), utils.Green
+			}
+		}
+		// pad before color, escape codes are not printable
+		utils.Stdout.Fprintf("%-24s %-16s %-25s %s %8d\n", profile, entry.Category, expiration,
+			remainingColor(fmt.Sprintf("%-12s", remaining), color), entry.Size)
+	}
+	if hasUnmatched {
+		utils.Stdout.Fprintf("* cache key does not match any configured profile\n")
+	}
+}
+
+func pruneCache(cacheKeys ProfileCacheKeys, now time.Time, dryRun bool) error {
+	entries, err := listCacheEntries(cacheKeys)
+	if err != nil {
+		return err
+	}
+	pruneCount := 0
+	for _, entry := range entries {
+		// JWKS is not a credential of any profile, it is cached by validate-jwt and refreshed when kid not found
+		if entry.Category == constants.CategoryJwks {
+			continue
+		}
+		var reason string
+		if entry.IsExpired(now) {
+			reason = "expired"
+		} else if !entry.Matched {
+			reason = "no matching profile"
+		} else {
+			continue
+		}
+		if dryRun {
+			utils.Stderr.Fprintf("Prune cache [%s, %s]: %s (dry run)\n", entry.Category, entry.Key, reason)
+			pruneCount++
+			continue
+		}
+		if err := utils.RemoveCacheFile(entry.Category, entry.Key); err != nil {
+			utils.Stderr.Fprintf("Prune cache [%s, %s] failed: %v\n", entry.Category, entry.Key, err)
+			continue
+		}
+		pruneCount++
+		utils.Stderr.Fprintf("Prune cache [%s, %s]: %s\n", entry.Category, entry.Key, reason)
+	}
+	utils.Stderr.Fprintf("Prune: %d of %d entries\n", pruneCount, len(entries))
+	return nil
+}
+
+// RemoveProfilesCache removes cache entries of profiles in all categories, entries shared with other profiles are kept
+func RemoveProfilesCache(cloudCredentialConfig *config.CloudCredentialConfig, profiles []string, dryRun bool) error {
+	cacheKeys := NewProfileCacheKeys(cloudCredentialConfig)
+	removeKeys := map[string][]string{}
+	for _, category := range constants.CacheCategories {
+		for key, keyProfiles := range cacheKeys[category] {
+			if !containsAny(keyProfiles, profiles) {
+				continue
+			}
+			// e.g. token response of the same token provider, other profiles MUST NOT be logged out
+			if cacheKeys.IsSharedWithOthers(category, key, profiles) {
+				utils.Stderr.Fprintf("Keep cache [%s, %s] shared with profile(s): %s\n",
+					category, key, strings.Join(keyProfiles, ", "))
+				continue
+			}
+			removeKeys[category] = append(removeKeys[category], key)
+		}
+	}
+	// entries of changed profile config cannot be matched by cache key, only file cache can be listed
+	if utils.GetCacheBackend() == utils.CacheBackendFile {
+		entries, err := listCacheEntries(cacheKeys)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.Matched && containsAny(entry.Profiles, profiles) {
+				removeKeys[entry.Category] = append(removeKeys[entry.Category], entry.Key)
+			}
+		}
+	}
+
+	removeCount := 0
+	for _, category := range constants.CacheCategories {
+		keys := removeKeys[category]
+		sort.Strings(keys)
+		for _, key := range keys {
+			if dryRun {
+				utils.Stderr.Fprintf("Remove cache [%s, %s] (dry run)\n", category, key)
+				removeCount++
+				continue
+			}
+			if err := utils.RemoveCache(category, key); err != nil {
+				utils.Stderr.Fprintf("Remove cache [%s, %s] failed: %v\n", category, key, err)
+				continue
+			}
+			removeCount++
+			utils.Stderr.Fprintf("Remove cache [%s, %s] success\n", category, key)
+		}
+	}
+	utils.Stderr.Fprintf("Remove: %d entries of profile(s): %s\n", removeCount, strings.Join(profiles, ", "))
+	return nil
+}
+
+func containsAny(values, targets []string) bool {
+	for _, target := range targets {
+		if slices.Contains(values, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(values, targets []string) bool {
+	for _, target := range targets {
+		if !slices.Contains(values, target) {
+			return false
+		}
+	}
+	return true
+}
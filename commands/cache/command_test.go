@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+)
+
+func writeCacheEntry(t *testing.T, category, key, profile string, expiration time.Time) {
+	stringWithTime := &utils.StringWithTime{
+		CacheTime: time.Now().UnixMilli(),
+		Context: map[string]interface{}{
+			"profile":                  profile,
+			utils.ContextKeyExpiration: expiration.Unix(),
+		},
+		Content: "content",
+	}
+	content, err := stringWithTime.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = utils.WriteCacheFileWithEncryption(category, key, content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneAndRemoveCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCacheBackend, utils.CacheBackendFile)
+
+	oidcTokenProvider := &config.OidcTokenProviderConfig{
+		OidcTokenProviderDeviceCode: &config.OidcTokenProviderDeviceCodeConfig{
+			Issuer:   "https://example.com",
+			ClientId: "client1",
+		},
+	}
+	alibabaCloudStsConfig := &config.AlibabaCloudStsConfig{
+		RoleArn:           "acs:ram::1:role/role1",
+		OidcTokenProvider: oidcTokenProvider,
+	}
+	cloudCredentialConfig := &config.CloudCredentialConfig{
+		Version: config.Version1,
+		Profile: map[string]*config.CloudStsConfig{
+			"p1": {AlibabaCloud: alibabaCloudStsConfig},
+		},
+	}
+	cacheKeys := NewProfileCacheKeys(cloudCredentialConfig)
+
+	now := time.Now()
+	cloudTokenKey := alibaba_cloud.CloudTokenCacheKey("p1", alibabaCloudStsConfig)
+	writeCacheEntry(t, constants.CategoryCloudToken, cloudTokenKey, "p1", now.Add(time.Hour))
+	writeCacheEntry(t, constants.CategoryCloudToken, "p2_removed", "p2", now.Add(time.Hour))
+	writeCacheEntry(t, constants.CategoryOidcToken, oidcTokenProvider.GetCacheKey(), "p1", now.Add(-time.Hour))
+	if err := utils.WriteCacheFileWithEncryption(constants.CategoryTokenResponse, oidcTokenProvider.GetCacheKey(), "{}"); err != nil {
+		t.Fatal(err)
+	}
+
+	writeCacheEntry(t, constants.CategoryJwks, "jwks1", "", now.Add(-time.Hour))
+
+	if err := pruneCache(cacheKeys, now, false); err != nil {
+		t.Fatal(err)
+	}
+	assertCacheKeys(t, constants.CategoryCloudToken, cloudTokenKey)
+	assertCacheKeys(t, constants.CategoryOidcToken)
+	assertCacheKeys(t, constants.CategoryTokenResponse, oidcTokenProvider.GetCacheKey())
+	assertCacheKeys(t, constants.CategoryJwks, "jwks1")
+
+	// entry of changed profile config is removed by profile in cache context
+	writeCacheEntry(t, constants.CategoryCloudToken, "p1_changed", "p1", now.Add(time.Hour))
+	writeCacheEntry(t, constants.CategoryCloudToken, "p2_other", "p2", now.Add(time.Hour))
+
+	if err := RemoveProfilesCache(cloudCredentialConfig, []string{"p1"}, false); err != nil {
+		t.Fatal(err)
+	}
+	assertCacheKeys(t, constants.CategoryCloudToken, "p2_other")
+	assertCacheKeys(t, constants.CategoryTokenResponse)
+}
+
+func TestRemoveSharedCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCacheBackend, utils.CacheBackendFile)
+
+	oidcTokenProvider := &config.OidcTokenProviderConfig{
+		OidcTokenProviderDeviceCode: &config.OidcTokenProviderDeviceCodeConfig{
+			Issuer:   "https://example.com",
+			ClientId: "client1",
+		},
+	}
+	cloudCredentialConfig := &config.CloudCredentialConfig{
+		Version: config.Version1,
+		Profile: map[string]*config.CloudStsConfig{
+			"p1": {AlibabaCloud: &config.AlibabaCloudStsConfig{RoleArn: "acs:ram::1:role/role1", OidcTokenProvider: oidcTokenProvider}},
+			"p2": {AlibabaCloud: &config.AlibabaCloudStsConfig{RoleArn: "acs:ram::1:role/role2", OidcTokenProvider: oidcTokenProvider}},
+		},
+	}
+	if err := utils.WriteCacheFileWithEncryption(constants.CategoryTokenResponse, oidcTokenProvider.GetCacheKey(), "{}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveProfilesCache(cloudCredentialConfig, []string{"p1"}, false); err != nil {
+		t.Fatal(err)
+	}
+	assertCacheKeys(t, constants.CategoryTokenResponse, oidcTokenProvider.GetCacheKey())
+
+	if err := RemoveProfilesCache(cloudCredentialConfig, []string{"p1", "p2"}, false); err != nil {
+		t.Fatal(err)
+	}
+	assertCacheKeys(t, constants.CategoryTokenResponse)
+}
+
+func assertCacheKeys(t *testing.T, category string, expectedKeys ...string) {
+	keys, err := utils.ListCacheFiles(category)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	slices.Sort(expectedKeys)
+	if !slices.Equal(keys, expectedKeys) {
+		t.Errorf("category: %s, keys: %v, expected: %v", category, keys, expectedKeys)
+	}
+}
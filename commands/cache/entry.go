@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_account"
+	cloudoidc "github.com/aliyunidaas/alibaba-cloud-idaas/cloud/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idp"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+type cacheEntry struct {
+	Category   string
+	Key        string
+	Profiles   []string
+	Size       int64
+	CacheTime  time.Time
+	Expiration time.Time // zero when unknown, e.g. token response, OpenID configuration or cached by old version
+	Matched    bool      // cache key matches a configured profile
+	Err        error
+}
+
+func (e *cacheEntry) IsExpired(now time.Time) bool {
+	return !e.Expiration.IsZero() && e.Expiration.Before(now)
+}
+
+// ProfileCacheKeys category -> cache key -> profiles, cache keys are generated by fetchers
+type ProfileCacheKeys map[string]map[string][]string
+
+func (k ProfileCacheKeys) add(category, key, profile string) {
+	if k[category] == nil {
+		k[category] = map[string][]string{}
+	}
+	if slices.Contains(k[category][key], profile) {
+		return
+	}
+	k[category][key] = append(k[category][key], profile)
+}
+
+func (k ProfileCacheKeys) Get(category, key string) []string {
+	return k[category][key]
+}
+
+// IsSharedWithOthers cache entry is used by profiles other than profiles
+func (k ProfileCacheKeys) IsSharedWithOthers(category, key string, profiles []string) bool {
+	return !containsAll(profiles, k.Get(category, key))
+}
+
+func NewProfileCacheKeys(cloudCredentialConfig *config.CloudCredentialConfig) ProfileCacheKeys {
+	cacheKeys := ProfileCacheKeys{}
+	if cloudCredentialConfig == nil {
+		return cacheKeys
+	}
+	for profile, cloudStsConfig := range cloudCredentialConfig.Profile {
+		if cloudStsConfig == nil {
+			continue
+		}
+		if cloudStsConfig.AlibabaCloud != nil {
+			cacheKeys.add(constants.CategoryCloudToken, alibaba_cloud.CloudTokenCacheKey(profile, cloudStsConfig.AlibabaCloud), profile)
+		}
+		if cloudStsConfig.Aws != nil {
+			cacheKeys.add(constants.CategoryCloudToken, aws.CloudTokenCacheKey(profile, cloudStsConfig.Aws), profile)
+		}
+		if cloudStsConfig.OidcToken != nil {
+			cacheKeys.add(constants.CategoryCloudToken, cloudoidc.CloudTokenCacheKey(profile, cloudStsConfig.OidcToken), profile)
+		}
+		if cloudStsConfig.CloudAccount != nil {
+			cacheKeys.add(constants.CategoryCloudToken, cloud_account.CloudTokenCacheKey(profile, cloudStsConfig.CloudAccount), profile)
+		}
+		for _, oidcTokenProvider := range GetProfileOidcTokenProviders(cloudStsConfig) {
+			cacheKeys.add(constants.CategoryTokenResponse, oidcTokenProvider.TokenResponseCacheKey(), profile)
+			cacheKeys.add(constants.CategoryOidcToken, oidcTokenProvider.OidcTokenCacheKey(), profile)
+			if issuer := oidcTokenProvider.Issuer(); issuer != "" {
+				cacheKeys.add(constants.CategoryOidc, oidc.OpenIdConfigurationCacheKey(issuer), profile)
+			}
+		}
+	}
+	return cacheKeys
+}
+
+// ProfileOidcTokenProvider OIDC token provider used by profile
+type ProfileOidcTokenProvider struct {
+	Config *config.OidcTokenProviderConfig
+	// AccessToken token type is overridden to access token, e.g. cloud account and agent
+	AccessToken bool
+}
+
+func GetProfileOidcTokenProviders(cloudStsConfig *config.CloudStsConfig) []*ProfileOidcTokenProvider {
+	var oidcTokenProviders []*ProfileOidcTokenProvider
+	addOidcTokenProvider := func(oidcTokenProviderConfig *config.OidcTokenProviderConfig, accessToken bool) {
+		if oidcTokenProviderConfig != nil {
+			oidcTokenProviders = append(oidcTokenProviders, &ProfileOidcTokenProvider{
+				Config:      oidcTokenProviderConfig,
+				AccessToken: accessToken,
+			})
+		}
+	}
+	if cloudStsConfig.AlibabaCloud != nil {
+		addOidcTokenProvider(cloudStsConfig.AlibabaCloud.OidcTokenProvider, false)
+	}
+	if cloudStsConfig.Aws != nil {
+		addOidcTokenProvider(cloudStsConfig.Aws.OidcTokenProvider, false)
+	}
+	addOidcTokenProvider(cloudStsConfig.OidcToken, false)
+	if cloudStsConfig.CloudAccount != nil {
+		addOidcTokenProvider(cloudStsConfig.CloudAccount.AccessTokenProvider, true)
+	}
+	if cloudStsConfig.Agent != nil {
+		addOidcTokenProvider(cloudStsConfig.Agent.AccessTokenProvider, true)
+	}
+	return oidcTokenProviders
+}
+
+// TokenResponseCacheKey is generated from token provider in config, token type is not overridden
+func (p *ProfileOidcTokenProvider) TokenResponseCacheKey() string {
+	return p.Config.GetCacheKey()
+}
+
+func (p *ProfileOidcTokenProvider) OidcTokenCacheKey() string {
+	if !p.AccessToken {
+		return idp.OidcTokenCacheKey(p.Config)
+	}
+	return idp.OidcTokenCacheKey(cloud_account.GetAccessTokenProvider(p.Config))
+}
+
+// IsAccessToken cached OIDC token is access token
+func (p *ProfileOidcTokenProvider) IsAccessToken() bool {
+	return p.AccessToken || p.Config.TokenType == oidc.TokenAccessToken
+}
+
+// Issuer only device code and authorization code have issuer
+func (p *ProfileOidcTokenProvider) Issuer() string {
+	if p.Config.OidcTokenProviderDeviceCode != nil {
+		return p.Config.OidcTokenProviderDeviceCode.Issuer
+	}
+	if p.Config.OidcTokenProviderAuthorizationCode != nil {
+		return p.Config.OidcTokenProviderAuthorizationCode.Issuer
+	}
+	return ""
+}
+
+// ClientId client of token provider, client secret is resolved for issuer only, see Issuer
+func (p *ProfileOidcTokenProvider) ClientId() string {
+	return p.Config.GetId()
+}
+
+// ClientSecret client secret of issuer, see Issuer, client secret reference is resolved
+func (p *ProfileOidcTokenProvider) ClientSecret() (string, error) {
+	var clientSecret string
+	if p.Config.OidcTokenProviderDeviceCode != nil {
+		clientSecret = p.Config.OidcTokenProviderDeviceCode.ClientSecret
+	} else if p.Config.OidcTokenProviderAuthorizationCode != nil {
+		clientSecret = p.Config.OidcTokenProviderAuthorizationCode.ClientSecret
+	}
+	clientSecret, err := utils.ResolveSecret(clientSecret)
+	if err != nil {
+		return "", errors.Wrap(err, "resolve client secret failed")
+	}
+	return clientSecret, nil
+}
+
+// listCacheEntries lists file cache entries, entries cannot be decrypted are listed with Err
+func listCacheEntries(cacheKeys ProfileCacheKeys) ([]*cacheEntry, error) {
+	var entries []*cacheEntry
+	for _, category := range constants.CacheCategories {
+		keys, err := utils.ListCacheFiles(category)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			entries = append(entries, readCacheEntry(cacheKeys, category, key))
+		}
+	}
+	return entries, nil
+}
+
+func readCacheEntry(cacheKeys ProfileCacheKeys, category, key string) *cacheEntry {
+	entry := &cacheEntry{
+		Category: category,
+		Key:      key,
+		Profiles: cacheKeys.Get(category, key),
+	}
+	entry.Matched = len(entry.Profiles) > 0
+	if fileInfo, err := utils.StatCacheFile(category, key); err == nil {
+		entry.Size = fileInfo.Size()
+	}
+	content, err := utils.ReadCacheFileWithEncryption(category, key)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	// token response is stored without cache time and context
+	if category == constants.CategoryTokenResponse {
+		return entry
+	}
+	stringWithTime, err := utils.UnmarshalStringWithTime(content)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	entry.CacheTime = time.UnixMilli(stringWithTime.CacheTime)
+	if expiration, ok := stringWithTime.GetExpiration(); ok {
+		entry.Expiration = expiration
+	}
+	if !entry.Matched {
+		if profile := stringWithTime.GetContextString("profile"); profile != "" {
+			entry.Profiles = []string{profile}
+		}
+	}
+	return entry
+}
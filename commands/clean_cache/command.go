@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
@@ -18,6 +19,7 @@ func BuildCommand() *cli.Command {
 		Usage: "Clean cache",
 		Flags: flags,
 		Action: func(context *cli.Context) error {
+			config.ApplyDefaultConfigCacheBackend()
 			return cleanCache()
 		},
 	}
@@ -46,6 +48,27 @@ func cleanCache() error {
 	tokenResponseCacheDir := filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir, constants.CategoryTokenResponse)
 	deleteFiles(tokenResponseCacheDir, func(filename string) bool { return true })
 
+	jwksCacheDir := filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir, constants.CategoryJwks)
+	deleteFiles(jwksCacheDir, func(filename string) bool { return true })
+
+	cacheBackend := utils.GetCacheBackend()
+	if cacheBackend != utils.CacheBackendFile {
+		return clearCacheBackend(cacheBackend)
+	}
+	return nil
+}
+
+func clearCacheBackend(cacheBackend string) error {
+	cacheReadWrite, err := utils.GetCacheReadWrite()
+	if err != nil {
+		return err
+	}
+	for _, category := range constants.CacheCategories {
+		utils.Stderr.Fprintf("Clearing %s cache: %s ...\n", cacheBackend, category)
+		if err := cacheReadWrite.Clear(category); err != nil {
+			utils.Stderr.Fprintf("Clear %s cache: %s failed: %s\n", cacheBackend, category, err)
+		}
+	}
 	return nil
 }
 
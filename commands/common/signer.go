@@ -0,0 +1,77 @@
+package common
+
+import (
+	"crypto"
+	"slices"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
+)
+
+// ClientAssertionSigner signer of profile, Path is JSON path of signer config in profile
+type ClientAssertionSigner struct {
+	Config *config.ExSingerConfig
+	Path   []string
+}
+
+// FindClientAssertionSigner finds client assertion signer, or private CA certificate key signer
+func FindClientAssertionSigner(cloudStsConfig *config.CloudStsConfig) *ClientAssertionSigner {
+	type oidcTokenProviderWithPath struct {
+		config *config.OidcTokenProviderConfig
+		path   []string
+	}
+	var oidcTokenProviders []*oidcTokenProviderWithPath
+	if cloudStsConfig.CloudAccount != nil {
+		oidcTokenProviders = append(oidcTokenProviders, &oidcTokenProviderWithPath{
+			cloudStsConfig.CloudAccount.AccessTokenProvider, []string{"cloud_account_token", "access_token_provider"}})
+	}
+	if cloudStsConfig.Aws != nil {
+		oidcTokenProviders = append(oidcTokenProviders, &oidcTokenProviderWithPath{
+			cloudStsConfig.Aws.OidcTokenProvider, []string{"aws_sts", "oidc_token_provider"}})
+	}
+	if cloudStsConfig.AlibabaCloud != nil {
+		oidcTokenProviders = append(oidcTokenProviders, &oidcTokenProviderWithPath{
+			cloudStsConfig.AlibabaCloud.OidcTokenProvider, []string{"alibaba_cloud_sts", "oidc_token_provider"}})
+	}
+	oidcTokenProviders = append(oidcTokenProviders, &oidcTokenProviderWithPath{
+		cloudStsConfig.OidcToken, []string{"oidc_token"}})
+
+	for _, oidcTokenProvider := range oidcTokenProviders {
+		if oidcTokenProvider.config == nil || oidcTokenProvider.config.OidcTokenProviderClientCredentials == nil {
+			continue
+		}
+		clientCredentials := oidcTokenProvider.config.OidcTokenProviderClientCredentials
+		path := slices.Concat(oidcTokenProvider.path, []string{"client_credentials"})
+		if clientCredentials.ClientAssertionSinger != nil {
+			return &ClientAssertionSigner{
+				Config: clientCredentials.ClientAssertionSinger,
+				Path:   slices.Concat(path, []string{"client_assertion_singer"}),
+			}
+		}
+		privateCaConfig := clientCredentials.ClientAssertionPrivateCaConfig
+		if privateCaConfig != nil && privateCaConfig.CertificateKeySigner != nil {
+			return &ClientAssertionSigner{
+				Config: privateCaConfig.CertificateKeySigner,
+				Path:   slices.Concat(path, []string{"client_assertion_private_ca", "certificate_key_signer"}),
+			}
+		}
+	}
+	return nil
+}
+
+// NewSignerJwk JWK of signer public key, kid is key_id of signer, or RFC7638 thumbprint when key_id is empty
+func NewSignerJwk(exSingerConfig *config.ExSingerConfig, publicKey crypto.PublicKey) (*jwt.Jwk, error) {
+	jwk, err := jwt.NewJwk(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	jwk.KeyId = exSingerConfig.KeyID
+	if jwk.KeyId == "" {
+		if jwk.KeyId, err = jwk.Thumbprint(); err != nil {
+			return nil, err
+		}
+	}
+	jwk.Algorithm = exSingerConfig.Algorithm
+	jwk.Use = "sig"
+	return jwk, nil
+}
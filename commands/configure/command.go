@@ -0,0 +1,313 @@
+package configure
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_account"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/common"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idp"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	stringFlagProfile = &cli.StringFlag{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Usage:   "IDaaS Profile to create or edit",
+	}
+	boolFlagNonInteractive = &cli.BoolFlag{
+		Name:  "non-interactive",
+		Usage: "Do not prompt, values are from flags and existing profile, for scripting",
+	}
+	boolFlagSkipVerify = &cli.BoolFlag{
+		Name:  "skip-verify",
+		Usage: "Skip dry-run fetch before write config",
+	}
+	boolFlagSetCurrent = &cli.BoolFlag{
+		Name:  "set-current",
+		Usage: "Set profile as current_profile",
+	}
+	boolFlagForce = &cli.BoolFlag{
+		Name:  "force",
+		Usage: "Edit existing profile without confirmation, required to edit profile in non-interactive mode",
+	}
+
+	// valueFlags name and usage, name is also key of prompter values and defaults
+	valueFlags = [][2]string{
+		{"type", "Profile type: alibaba_cloud_sts, aws_sts, oidc_token, cloud_account_token or agent"},
+		{"region", "Alibaba Cloud or AWS region"},
+		{"oidc-provider-arn", "Alibaba Cloud RAM OIDC provider ARN"},
+		{"role-arn", "Alibaba Cloud RAM role ARN or AWS IAM role ARN"},
+		{"instance-id", "IDaaS instance ID, for cloud_account_token and agent"},
+		{"developer-api-endpoint", "IDaaS developer API endpoint, for cloud_account_token and agent"},
+		{"cloud-account-role-external-id", "Cloud account role external ID, for cloud_account_token"},
+		{"issuer", "OIDC issuer, token endpoint and provider are discovered from it"},
+		{"provider", "Token provider: device_code, authorization_code or client_credentials"},
+		{"client-id", "Client ID"},
+		{"client-secret", "Client secret or secret reference, e.g. env:CLIENT_SECRET"},
+		{"scope", "Scope"},
+		{"token-endpoint", "Token endpoint, for client_credentials"},
+		{"client-auth", "Client authentication: client_secret or signer, for client_credentials"},
+		{"signer", "Signer: key_file, pkcs11, yubikey_piv, external_command or kms"},
+		{"algorithm", "Signer algorithm, e.g. ES256"},
+		{"key-id", "Signer key ID(kid)"},
+		{"key-file", "Private key file, for key_file signer"},
+		{"pkcs11-library-path", "PKCS#11 library path"},
+		{"pkcs11-token-label", "PKCS#11 token label"},
+		{"pkcs11-key-label", "PKCS#11 key label"},
+		{"yubikey-slot", "YubiKey PIV slot"},
+		{"yubikey-pin-policy", "YubiKey PIN policy: none, once or always"},
+		{"external-command", "External signer command"},
+		{"external-parameter", "External signer parameter"},
+		{"external-protocol", "External signer protocol: v1 or v2"},
+		{"kms-provider", "KMS provider: alibaba_cloud or aws"},
+		{"kms-key-id", "KMS key ID"},
+		{"kms-key-version-id", "KMS key version ID, for alibaba_cloud"},
+		{"kms-region", "KMS region"},
+		{"kms-profile", "Profile issues KMS credentials"},
+	}
+)
+
+type configureOptions struct {
+	configFilename string
+	profile        string
+	nonInteractive bool
+	skipVerify     bool
+	setCurrent     bool
+	force          bool
+	values         map[string]string
+}
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		stringFlagProfile,
+		boolFlagNonInteractive,
+		boolFlagSkipVerify,
+		boolFlagSetCurrent,
+		boolFlagForce,
+	}
+	for _, valueFlag := range valueFlags {
+		flags = append(flags, &cli.StringFlag{Name: valueFlag[0], Usage: valueFlag[1]})
+	}
+	return &cli.Command{
+		Name:  "configure",
+		Usage: "Create or edit profile interactively, or non-interactively by flags",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			values := map[string]string{}
+			for _, valueFlag := range valueFlags {
+				values[valueFlag[0]] = context.String(valueFlag[0])
+			}
+			return configure(&configureOptions{
+				configFilename: context.String("config"),
+				profile:        context.String("profile"),
+				nonInteractive: context.Bool("non-interactive"),
+				skipVerify:     context.Bool("skip-verify"),
+				setCurrent:     context.Bool("set-current"),
+				force:          context.Bool("force"),
+				values:         values,
+			})
+		},
+	}
+}
+
+func configure(options *configureOptions) error {
+	configFilename := options.configFilename
+	if configFilename == "" {
+		var err error
+		if configFilename, err = config.GetDefaultCloudCredentialConfigFile(); err != nil {
+			return err
+		}
+	}
+	// YAML and TOML comments cannot be kept
+	if format := config.GetConfigFormat(configFilename); format != config.ConfigFormatJson {
+		return errors.Errorf("configure supports JSON config file only, config file: %s is %s", configFilename, format)
+	}
+	if _, err := os.Stat(configFilename); os.IsNotExist(err) {
+		if err = config.CreateCloudCredentialConfig(configFilename); err != nil {
+			return err
+		}
+		utils.Stderr.Fprintf("Created config file: %s\n", configFilename)
+	}
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		return err
+	}
+
+	p := newPrompter(options.nonInteractive, options.values, map[string]string{})
+	profile := options.profile
+	if profile == "" {
+		p.setDefault("profile", "default")
+		if profile, err = p.ask("profile", "Profile name", true); err != nil {
+			return err
+		}
+	}
+	if existingProfile := cloudCredentialConfig.Profile[profile]; existingProfile != nil {
+		if source := cloudCredentialConfig.ProfileSources[profile]; source != "" && source != configFilename {
+			return errors.Errorf("profile: %s is in file: %s, edit it manually", profile, source)
+		}
+		// fields from parent profile or shared token provider would be copied into the profile
+		if inherits := cloudCredentialConfig.ProfileInherits[profile]; len(inherits) > 0 {
+			return errors.Errorf("profile: %s uses %s, edit it manually", profile, strings.Join(inherits, ", "))
+		}
+		if !options.force {
+			if options.nonInteractive {
+				return errors.Errorf("profile: %s exists, use --force to edit it in non-interactive mode", profile)
+			}
+			if !p.confirm(fmt.Sprintf("Profile: %s exists, edit it?", profile)) {
+				utils.Stderr.Fprintf("Profile: %s is not changed\n", profile)
+				return nil
+			}
+		}
+		p.defaults = profileDefaults(existingProfile)
+	}
+
+	profileType, profileConfig, err := buildProfile(p)
+	if err != nil {
+		return err
+	}
+	cloudStsConfig, err := toCloudStsConfig(profileType, profileConfig)
+	if err != nil {
+		return err
+	}
+	if !options.skipVerify {
+		if err = verifyProfile(profile, cloudStsConfig, options.nonInteractive); err != nil {
+			if options.nonInteractive {
+				return errors.Wrap(err, "verify profile failed, config is not written, use --skip-verify to skip")
+			}
+			utils.Stderr.Fprintf("%s\n", utils.Red(fmt.Sprintf("Verify profile failed: %v", err), true))
+			if !p.confirm("Write profile anyway?") {
+				return nil
+			}
+		}
+	}
+	if err = writeProfile(configFilename, profile, profileType, profileConfig, options.setCurrent); err != nil {
+		return err
+	}
+	if common.FindClientAssertionSigner(cloudStsConfig) != nil {
+		utils.Stderr.Fprintf("Register signer public key to IDaaS application, get it by: "+
+			"alibaba-cloud-idaas show-signer-public-key --profile %s\n", profile)
+	}
+	return nil
+}
+
+func toCloudStsConfig(profileType string, profileConfig map[string]interface{}) (*config.CloudStsConfig, error) {
+	profileJson, err := json.Marshal(map[string]interface{}{profileType: profileConfig})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal profile failed")
+	}
+	var cloudStsConfig config.CloudStsConfig
+	if err = json.Unmarshal(profileJson, &cloudStsConfig); err != nil {
+		return nil, errors.Wrap(err, "unmarshal profile failed")
+	}
+	return &cloudStsConfig, nil
+}
+
+// verifyProfile dry-run fetches token of profile, token is cached as fetched by other commands
+func verifyProfile(profile string, cloudStsConfig *config.CloudStsConfig, nonInteractive bool) error {
+	var err error
+	if nonInteractive {
+		idp.DisableInteractiveFlow()
+	}
+	utils.Stderr.Fprintf("Verify profile: %s by fetching token...\n", profile)
+	if cloudStsConfig.Agent != nil {
+		tokenProvider := cloud_account.GetAccessTokenProvider(cloudStsConfig.Agent.AccessTokenProvider)
+		_, err = idp.FetchOidcToken(profile, tokenProvider, &idp.FetchOidcTokenOptions{CacheKey: tokenProvider.GetCacheKey()})
+	} else {
+		_, err = cloud.FetchCloudSts(profile, cloudStsConfig, &cloud.FetchCloudStsOptions{ForceNewCloudToken: true})
+	}
+	if err != nil {
+		return err
+	}
+	utils.Stderr.Fprintf("%s\n", utils.Green(fmt.Sprintf("Verify profile: %s succeeded", profile), true))
+	return nil
+}
+
+// writeProfile merges prompted values into profile, fields not prompted e.g. comment, environments and
+// duration_seconds are kept, order of fields is kept
+func writeProfile(configFilename, profile, profileType string, profileConfig map[string]interface{}, setCurrent bool) error {
+	content, err := os.ReadFile(configFilename)
+	if err != nil {
+		return errors.Wrapf(err, "read config file: %s failed", configFilename)
+	}
+	configObject, err := utils.UnmarshalJsonObject(content)
+	if err != nil {
+		return errors.Wrapf(err, "unmarshal config file: %s failed", configFilename)
+	}
+	profiles := configObject.GetObject("profile")
+	if profiles == nil {
+		profiles = utils.NewJsonObject()
+		configObject.Set("profile", profiles)
+	}
+	profileObject := profiles.GetObject(profile)
+	if profileObject == nil {
+		profileObject = utils.NewJsonObject()
+		profiles.Set(profile, profileObject)
+	}
+	mergeIntoObject(profileObject, map[string]interface{}{profileType: profileConfig})
+	if setCurrent {
+		configObject.Set("current_profile", profile)
+	}
+
+	newContent, err := json.MarshalIndent(configObject, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal config failed")
+	}
+	backupFilename, err := utils.BackupFile(configFilename)
+	if err != nil {
+		return err
+	}
+	if err = utils.WriteFileAtomic(configFilename, newContent, 0600); err != nil {
+		return err
+	}
+	utils.Stderr.Fprintf("Profile: %s is written to: %s, backup: %s\n", profile, configFilename, backupFilename)
+	return nil
+}
+
+// mergeIntoObject sets values into object recursively, fields exclusive with a value are removed,
+// e.g. device_code is removed when client_credentials is set
+func mergeIntoObject(object *utils.JsonObject, values map[string]interface{}) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, group := range exclusiveFieldGroups {
+			if !slices.Contains(group, name) {
+				continue
+			}
+			for _, other := range group {
+				if other != name {
+					object.Delete(other)
+				}
+			}
+		}
+		valueMap, isMap := values[name].(map[string]interface{})
+		if !isMap {
+			object.Set(name, values[name])
+			continue
+		}
+		valueObject := object.GetObject(name)
+		if valueObject == nil {
+			valueObject = utils.NewJsonObject()
+			object.Set(name, valueObject)
+		}
+		mergeIntoObject(valueObject, valueMap)
+	}
+}
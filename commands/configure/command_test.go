@@ -0,0 +1,166 @@
+package configure
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+)
+
+func TestConfigureNonInteractive(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCacheBackend, utils.CacheBackendFile)
+	t.Setenv("TEST_CONFIGURE_CLIENT_SECRET", "secret1")
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(&oidc.OpenIdConfiguration{
+				Issuer:        server.URL,
+				TokenEndpoint: server.URL + "/token",
+			})
+		case "/token":
+			if r.PostFormValue("client_id") != "client1" || r.PostFormValue("client_secret") != "secret1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&oidc.TokenResponse{AccessToken: "at1", TokenType: "Bearer", ExpiresIn: 3600})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	configFilename := filepath.Join(t.TempDir(), "idaas", "config.json")
+	values := map[string]string{
+		"type":          ProfileTypeOidcToken,
+		"issuer":        server.URL,
+		"provider":      ProviderClientCredentials,
+		"client-id":     "client1",
+		"client-auth":   ClientAuthClientSecret,
+		"client-secret": "env:TEST_CONFIGURE_CLIENT_SECRET",
+	}
+	err := configure(&configureOptions{configFilename: configFilename, profile: "p1",
+		nonInteractive: true, setCurrent: true, values: values})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileInfo, err := os.Stat(configFilename); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && fileInfo.Mode().Perm() != 0600 {
+		t.Errorf("config file permission: %04o", fileInfo.Mode().Perm())
+	}
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCredentials := cloudCredentialConfig.Profile["p1"].OidcToken.OidcTokenProviderClientCredentials
+	if cloudCredentialConfig.CurrentProfile != "p1" || clientCredentials.TokenEndpoint != server.URL+"/token" ||
+		clientCredentials.ClientSecret != "env:TEST_CONFIGURE_CLIENT_SECRET" {
+		t.Fatalf("unexpected profile: %s, %+v", cloudCredentialConfig.CurrentProfile, clientCredentials)
+	}
+
+	// verify failed, profile is not written
+	values["client-secret"] = "bad"
+	err = configure(&configureOptions{configFilename: configFilename, profile: "p2", nonInteractive: true, values: values})
+	if err == nil {
+		t.Fatal("verify should fail")
+	}
+	if cloudCredentialConfig, _ = config.LoadCloudCredentialConfig(configFilename); cloudCredentialConfig.Profile["p2"] != nil {
+		t.Error("profile p2 should not be written")
+	}
+
+	// existing profile is not edited without --force in non-interactive mode
+	err = configure(&configureOptions{configFilename: configFilename, profile: "p1", nonInteractive: true,
+		skipVerify: true, values: map[string]string{"scope": "scope1"}})
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("edit existing profile without --force should fail, error: %v", err)
+	}
+
+	// edit profile, values absent in flags are from existing profile
+	err = configure(&configureOptions{configFilename: configFilename, profile: "p1", nonInteractive: true,
+		skipVerify: true, force: true, values: map[string]string{"scope": "scope1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloudCredentialConfig, _ = config.LoadCloudCredentialConfig(configFilename)
+	clientCredentials = cloudCredentialConfig.Profile["p1"].OidcToken.OidcTokenProviderClientCredentials
+	if clientCredentials.Scope != "scope1" || clientCredentials.ClientId != "client1" ||
+		clientCredentials.ClientSecret != "env:TEST_CONFIGURE_CLIENT_SECRET" {
+		t.Errorf("unexpected edited profile: %+v", clientCredentials)
+	}
+}
+
+func TestConfigureKeepsProfileFields(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCacheBackend, utils.CacheBackendFile)
+	// OIDC discovery fails, it is not fatal
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	configFilename := filepath.Join(t.TempDir(), "config.json")
+	configJson := `{"version": "1", "profile": {
+"p1": {"comment": "c1", "aws_sts": {"region": "us-east-1", "role_arn": "arn:aws:iam::1:role/r1", "duration_seconds": 900,
+  "oidc_token_provider": {"device_code": {"issuer": "` + server.URL + `", "client_id": "client1", "show_qr_code": true}}}},
+"p2": {"extends": "p1"}}}`
+	if err := os.WriteFile(configFilename, []byte(configJson), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := configure(&configureOptions{configFilename: configFilename, profile: "p1", nonInteractive: true,
+		skipVerify: true, force: true, values: map[string]string{"role-arn": "arn:aws:iam::1:role/r2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Index(string(content), `"comment"`) > strings.Index(string(content), `"aws_sts"`) ||
+		strings.Index(string(content), `"region"`) > strings.Index(string(content), `"duration_seconds"`) {
+		t.Errorf("order of fields is not kept: %s", content)
+	}
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1 := cloudCredentialConfig.Profile["p1"]
+	deviceCode := p1.Aws.OidcTokenProvider.OidcTokenProviderDeviceCode
+	if p1.Comment != "c1" || p1.Aws.RoleArn != "arn:aws:iam::1:role/r2" || p1.Aws.DurationSeconds != 900 ||
+		deviceCode == nil || !deviceCode.ShowQrCode {
+		t.Errorf("unprompted fields are not kept: %s", content)
+	}
+
+	// switch provider, fields of previous provider are removed
+	err = configure(&configureOptions{configFilename: configFilename, profile: "p1", nonInteractive: true,
+		skipVerify: true, force: true, values: map[string]string{"provider": ProviderClientCredentials,
+			"token-endpoint": server.URL + "/token", "client-auth": ClientAuthClientSecret, "client-secret": "env:SECRET"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloudCredentialConfig, err = config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenProvider := cloudCredentialConfig.Profile["p1"].Aws.OidcTokenProvider
+	if tokenProvider.OidcTokenProviderDeviceCode != nil || tokenProvider.OidcTokenProviderClientCredentials == nil {
+		t.Errorf("unexpected token provider: %+v", tokenProvider)
+	}
+
+	// profile extends other profile is not edited
+	err = configure(&configureOptions{configFilename: configFilename, profile: "p2", nonInteractive: true,
+		skipVerify: true, force: true, values: map[string]string{}})
+	if err == nil || !strings.Contains(err.Error(), "extends: p1") {
+		t.Errorf("edit extends profile should fail, error: %v", err)
+	}
+}
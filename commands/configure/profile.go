@@ -0,0 +1,386 @@
+package configure
+
+import (
+	"path/filepath"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/external"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/kms"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	ProfileTypeAlibabaCloudSts   = "alibaba_cloud_sts"
+	ProfileTypeAwsSts            = "aws_sts"
+	ProfileTypeOidcToken         = "oidc_token"
+	ProfileTypeCloudAccountToken = "cloud_account_token"
+	ProfileTypeAgent             = "agent"
+
+	ProviderDeviceCode        = "device_code"
+	ProviderAuthorizationCode = "authorization_code"
+	ProviderClientCredentials = "client_credentials"
+
+	ClientAuthClientSecret = "client_secret"
+	ClientAuthSigner       = "signer"
+
+	SignerKeyFile         = "key_file"
+	SignerPkcs11          = "pkcs11"
+	SignerYubikeyPiv      = "yubikey_piv"
+	SignerExternalCommand = "external_command"
+	SignerKms             = "kms"
+)
+
+var (
+	profileTypes = []string{ProfileTypeAlibabaCloudSts, ProfileTypeAwsSts, ProfileTypeOidcToken,
+		ProfileTypeCloudAccountToken, ProfileTypeAgent}
+	providers   = []string{ProviderDeviceCode, ProviderAuthorizationCode, ProviderClientCredentials}
+	clientAuths = []string{ClientAuthClientSecret, ClientAuthSigner}
+	signerTypes = []string{SignerKeyFile, SignerPkcs11, SignerYubikeyPiv, SignerExternalCommand, SignerKms}
+	algorithms  = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+	// exclusiveFieldGroups only one field of group may be set in config object
+	exclusiveFieldGroups = [][]string{
+		profileTypes,
+		providers,
+		signerTypes,
+		{"client_secret", "client_assertion_singer", "client_assertion_pkcs7", "client_assertion_private_ca", "client_assertion_oidc_token"},
+	}
+)
+
+// buildProfile builds profile in generic map, so only configured fields are written to config file
+func buildProfile(p *prompter) (string, map[string]interface{}, error) {
+	profileType, err := p.choose("type", "Profile type", profileTypes)
+	if err != nil {
+		return "", nil, err
+	}
+	profile := map[string]interface{}{}
+	var tokenProviderName string
+	switch profileType {
+	case ProfileTypeAlibabaCloudSts:
+		if err = askInto(p, profile, []*field{
+			{"region", "region", "Region, e.g. cn-hangzhou", true},
+			{"oidc-provider-arn", "oidc_provider_arn", "RAM OIDC provider ARN", true},
+			{"role-arn", "role_arn", "RAM role ARN", true},
+		}); err != nil {
+			return "", nil, err
+		}
+		tokenProviderName = "oidc_token_provider"
+	case ProfileTypeAwsSts:
+		if err = askInto(p, profile, []*field{
+			{"region", "region", "Region, e.g. us-east-1", true},
+			{"role-arn", "role_arn", "IAM role ARN", true},
+		}); err != nil {
+			return "", nil, err
+		}
+		tokenProviderName = "oidc_token_provider"
+	case ProfileTypeCloudAccountToken:
+		if err = askInto(p, profile, []*field{
+			{"instance-id", "instance_id", "IDaaS instance ID", true},
+			{"developer-api-endpoint", "developer_api_endpoint", "IDaaS developer API endpoint", true},
+			{"cloud-account-role-external-id", "cloud_account_role_external_id", "Cloud account role external ID", true},
+		}); err != nil {
+			return "", nil, err
+		}
+		tokenProviderName = "access_token_provider"
+	case ProfileTypeAgent:
+		if err = askInto(p, profile, []*field{
+			{"instance-id", "instance_id", "IDaaS instance ID", true},
+			{"developer-api-endpoint", "developer_api_endpoint", "IDaaS developer API endpoint", true},
+		}); err != nil {
+			return "", nil, err
+		}
+		tokenProviderName = "access_token_provider"
+	}
+
+	tokenProvider, err := buildTokenProvider(p)
+	if err != nil {
+		return "", nil, err
+	}
+	if profileType == ProfileTypeOidcToken {
+		return profileType, tokenProvider, nil
+	}
+	profile[tokenProviderName] = tokenProvider
+	return profileType, profile, nil
+}
+
+func buildTokenProvider(p *prompter) (map[string]interface{}, error) {
+	issuer, err := p.ask("issuer", "OIDC issuer, token endpoint and provider are discovered from it", false)
+	if err != nil {
+		return nil, err
+	}
+	if issuer != "" {
+		discover(p, issuer)
+	}
+	provider, err := p.choose("provider", "Token provider", providers)
+	if err != nil {
+		return nil, err
+	}
+
+	providerConfig := map[string]interface{}{}
+	if provider == ProviderDeviceCode || provider == ProviderAuthorizationCode {
+		if issuer == "" {
+			if issuer, err = p.ask("issuer", "OIDC issuer", true); err != nil {
+				return nil, err
+			}
+		}
+		providerConfig["issuer"] = issuer
+		if err = askInto(p, providerConfig, []*field{
+			{"client-id", "client_id", "Client ID", true},
+			{"scope", "scope", "Scope, default openid", false},
+		}); err != nil {
+			return nil, err
+		}
+		clientSecret, err := p.askSecret("client-secret", "Client secret or secret reference, empty for public client", false)
+		if err != nil {
+			return nil, err
+		}
+		setIfNotEmpty(providerConfig, "client_secret", clientSecret)
+		providerConfig["auto_open_url"] = !p.nonInteractive
+		return map[string]interface{}{provider: providerConfig}, nil
+	}
+
+	if err = askInto(p, providerConfig, []*field{
+		{"token-endpoint", "token_endpoint", "Token endpoint", true},
+		{"client-id", "client_id", "Client ID", true},
+		{"scope", "scope", "Scope", false},
+	}); err != nil {
+		return nil, err
+	}
+	clientAuth, err := p.choose("client-auth", "Client authentication", clientAuths)
+	if err != nil {
+		return nil, err
+	}
+	if clientAuth == ClientAuthClientSecret {
+		clientSecret, err := p.askSecret("client-secret", "Client secret or secret reference, e.g. env:CLIENT_SECRET", true)
+		if err != nil {
+			return nil, err
+		}
+		providerConfig["client_secret"] = clientSecret
+	} else {
+		signerConfig, err := buildSigner(p)
+		if err != nil {
+			return nil, err
+		}
+		providerConfig["client_assertion_singer"] = signerConfig
+	}
+	return map[string]interface{}{provider: providerConfig}, nil
+}
+
+// discover pre-fills token endpoint and provider from OIDC discovery, discovery failure is not fatal
+func discover(p *prompter, issuer string) {
+	openIdConfiguration, err := oidc.FetchOpenIdConfiguration(issuer, &oidc.FetchOpenIdConfigurationOptions{})
+	if err != nil {
+		utils.Stderr.Fprintf("OIDC discovery on issuer: %s failed: %v\n", issuer, err)
+		return
+	}
+	p.setDefault("token-endpoint", openIdConfiguration.TokenEndpoint)
+	if openIdConfiguration.DeviceAuthorizationEndpoint != "" {
+		p.setDefault("provider", ProviderDeviceCode)
+	} else if openIdConfiguration.AuthorizationEndpoint != "" {
+		p.setDefault("provider", ProviderAuthorizationCode)
+	}
+}
+
+func buildSigner(p *prompter) (map[string]interface{}, error) {
+	signerType, err := p.choose("signer", "Signer", signerTypes)
+	if err != nil {
+		return nil, err
+	}
+	p.setDefault("algorithm", "ES256")
+	algorithm, err := p.choose("algorithm", "Signer algorithm", algorithms)
+	if err != nil {
+		return nil, err
+	}
+	signerConfig := map[string]interface{}{"algorithm": algorithm}
+	if err = askInto(p, signerConfig, []*field{
+		{"key-id", "key_id", "Key ID(kid), empty for JWK thumbprint", false},
+	}); err != nil {
+		return nil, err
+	}
+
+	typeConfig := map[string]interface{}{}
+	switch signerType {
+	case SignerKeyFile:
+		keyFile, err := p.ask("key-file", "Private key file", true)
+		if err != nil {
+			return nil, err
+		}
+		// config is used from any working dir
+		if keyFile, err = filepath.Abs(keyFile); err != nil {
+			return nil, errors.Wrap(err, "get absolute path of key file failed")
+		}
+		typeConfig["file"] = keyFile
+	case SignerPkcs11:
+		err = askInto(p, typeConfig, []*field{
+			{"pkcs11-library-path", "library_path", "PKCS#11 library path", true},
+			{"pkcs11-token-label", "token_label", "PKCS#11 token label", true},
+			{"pkcs11-key-label", "key_label", "PKCS#11 key label", true},
+		})
+	case SignerYubikeyPiv:
+		p.setDefault("yubikey-slot", "sign")
+		p.setDefault("yubikey-pin-policy", "once")
+		if err = askInto(p, typeConfig, []*field{
+			{"yubikey-slot", "slot", "YubiKey PIV slot, auth, sign or rN", true},
+		}); err == nil {
+			var pinPolicy string
+			pinPolicy, err = p.choose("yubikey-pin-policy", "YubiKey PIN policy", []string{"none", "once", "always"})
+			typeConfig["pin_policy"] = pinPolicy
+		}
+	case SignerExternalCommand:
+		p.setDefault("external-protocol", external.ProtocolV1)
+		if err = askInto(p, typeConfig, []*field{
+			{"external-command", "command", "External signer command", true},
+			{"external-parameter", "parameter", "External signer parameter", true},
+		}); err == nil {
+			var protocol string
+			protocol, err = p.choose("external-protocol", "External signer protocol", []string{external.ProtocolV1, external.ProtocolV2})
+			typeConfig["protocol"] = protocol
+		}
+	case SignerKms:
+		var kmsProvider string
+		if kmsProvider, err = p.choose("kms-provider", "KMS provider", []string{kms.ProviderAlibabaCloud, kms.ProviderAws}); err != nil {
+			return nil, err
+		}
+		typeConfig["provider"] = kmsProvider
+		err = askInto(p, typeConfig, []*field{
+			{"kms-key-id", "key_id", "KMS key ID", true},
+			{"kms-key-version-id", "key_version_id", "KMS key version ID", kmsProvider == kms.ProviderAlibabaCloud},
+			{"kms-region", "region", "KMS region", true},
+			{"kms-profile", "profile", "Profile issues KMS credentials", true},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	signerConfig[signerType] = typeConfig
+	return signerConfig, nil
+}
+
+// field flag name, config JSON name and prompt label
+type field struct {
+	flag     string
+	name     string
+	label    string
+	required bool
+}
+
+func askInto(p *prompter, m map[string]interface{}, fields []*field) error {
+	for _, f := range fields {
+		value, err := p.ask(f.flag, f.label, f.required)
+		if err != nil {
+			return err
+		}
+		setIfNotEmpty(m, f.name, value)
+	}
+	return nil
+}
+
+func setIfNotEmpty(m map[string]interface{}, name, value string) {
+	if value != "" {
+		m[name] = value
+	}
+}
+
+// profileDefaults flag name to value of existing profile, so editing profile keeps current values
+func profileDefaults(c *config.CloudStsConfig) map[string]string {
+	defaults := map[string]string{}
+	if c == nil {
+		return defaults
+	}
+	var tokenProvider *config.OidcTokenProviderConfig
+	switch {
+	case c.AlibabaCloud != nil:
+		defaults["type"] = ProfileTypeAlibabaCloudSts
+		defaults["region"] = c.AlibabaCloud.Region
+		defaults["oidc-provider-arn"] = c.AlibabaCloud.OidcProviderArn
+		defaults["role-arn"] = c.AlibabaCloud.RoleArn
+		tokenProvider = c.AlibabaCloud.OidcTokenProvider
+	case c.Aws != nil:
+		defaults["type"] = ProfileTypeAwsSts
+		defaults["region"] = c.Aws.Region
+		defaults["role-arn"] = c.Aws.RoleArn
+		tokenProvider = c.Aws.OidcTokenProvider
+	case c.OidcToken != nil:
+		defaults["type"] = ProfileTypeOidcToken
+		tokenProvider = c.OidcToken
+	case c.CloudAccount != nil:
+		defaults["type"] = ProfileTypeCloudAccountToken
+		defaults["instance-id"] = c.CloudAccount.GetInstanceId()
+		defaults["developer-api-endpoint"] = c.CloudAccount.GetEndpoint()
+		defaults["cloud-account-role-external-id"] = c.CloudAccount.CloudAccountRoleExternalId
+		tokenProvider = c.CloudAccount.AccessTokenProvider
+	case c.Agent != nil:
+		defaults["type"] = ProfileTypeAgent
+		defaults["instance-id"] = c.Agent.InstanceId
+		defaults["developer-api-endpoint"] = c.Agent.DeveloperApiEndpoint
+		tokenProvider = c.Agent.AccessTokenProvider
+	}
+	if tokenProvider == nil {
+		return defaults
+	}
+	if deviceCode := tokenProvider.OidcTokenProviderDeviceCode; deviceCode != nil {
+		defaults["provider"] = ProviderDeviceCode
+		defaults["issuer"] = deviceCode.Issuer
+		defaults["client-id"] = deviceCode.ClientId
+		defaults["client-secret"] = deviceCode.ClientSecret
+		defaults["scope"] = deviceCode.Scope
+	}
+	if authorizationCode := tokenProvider.OidcTokenProviderAuthorizationCode; authorizationCode != nil {
+		defaults["provider"] = ProviderAuthorizationCode
+		defaults["issuer"] = authorizationCode.Issuer
+		defaults["client-id"] = authorizationCode.ClientId
+		defaults["client-secret"] = authorizationCode.ClientSecret
+		defaults["scope"] = authorizationCode.Scope
+	}
+	if clientCredentials := tokenProvider.OidcTokenProviderClientCredentials; clientCredentials != nil {
+		defaults["provider"] = ProviderClientCredentials
+		defaults["token-endpoint"] = clientCredentials.TokenEndpoint
+		defaults["client-id"] = clientCredentials.ClientId
+		defaults["scope"] = clientCredentials.Scope
+		if clientCredentials.ClientSecret != "" {
+			defaults["client-auth"] = ClientAuthClientSecret
+			defaults["client-secret"] = clientCredentials.ClientSecret
+		}
+		if clientCredentials.ClientAssertionSinger != nil {
+			defaults["client-auth"] = ClientAuthSigner
+			signerDefaults(defaults, clientCredentials.ClientAssertionSinger)
+		}
+	}
+	return defaults
+}
+
+func signerDefaults(defaults map[string]string, c *config.ExSingerConfig) {
+	defaults["algorithm"] = c.Algorithm
+	defaults["key-id"] = c.KeyID
+	if c.KeyFile != nil {
+		defaults["signer"] = SignerKeyFile
+		defaults["key-file"] = c.KeyFile.File
+	}
+	if c.Pkcs11 != nil {
+		defaults["signer"] = SignerPkcs11
+		defaults["pkcs11-library-path"] = c.Pkcs11.LibraryPath
+		defaults["pkcs11-token-label"] = c.Pkcs11.TokenLabel
+		defaults["pkcs11-key-label"] = c.Pkcs11.KeyLabel
+	}
+	if c.YubikeyPiv != nil {
+		defaults["signer"] = SignerYubikeyPiv
+		defaults["yubikey-slot"] = c.YubikeyPiv.Slot
+		defaults["yubikey-pin-policy"] = c.YubikeyPiv.PinPolicy
+	}
+	if c.ExternalCommand != nil {
+		defaults["signer"] = SignerExternalCommand
+		defaults["external-command"] = c.ExternalCommand.Command
+		defaults["external-parameter"] = c.ExternalCommand.Parameter
+		defaults["external-protocol"] = c.ExternalCommand.Protocol
+	}
+	if c.Kms != nil {
+		defaults["signer"] = SignerKms
+		defaults["kms-provider"] = c.Kms.Provider
+		defaults["kms-key-id"] = c.Kms.KeyId
+		defaults["kms-key-version-id"] = c.Kms.KeyVersionId
+		defaults["kms-region"] = c.Kms.Region
+		defaults["kms-profile"] = c.Kms.Profile
+	}
+}
@@ -0,0 +1,133 @@
+package configure
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// prompter value priority: flag value, then user input, default value is from existing profile or OIDC discovery,
+// in non-interactive mode, default value is used and required value without default is an error
+type prompter struct {
+	reader         *bufio.Reader
+	nonInteractive bool
+	values         map[string]string
+	defaults       map[string]string
+}
+
+func newPrompter(nonInteractive bool, values, defaults map[string]string) *prompter {
+	return &prompter{
+		reader:         bufio.NewReader(os.Stdin),
+		nonInteractive: nonInteractive,
+		values:         values,
+		defaults:       defaults,
+	}
+}
+
+// setDefault sets default value only when absent, existing profile values have higher priority than discovery
+func (p *prompter) setDefault(name, value string) {
+	if p.defaults[name] == "" && value != "" {
+		p.defaults[name] = value
+	}
+}
+
+func (p *prompter) ask(name, label string, required bool) (string, error) {
+	if value := p.values[name]; value != "" {
+		return value, nil
+	}
+	defaultValue := p.defaults[name]
+	if p.nonInteractive {
+		if required && defaultValue == "" {
+			return "", errors.Errorf("flag --%s is required in non-interactive mode", name)
+		}
+		return defaultValue, nil
+	}
+	for {
+		if defaultValue != "" {
+			utils.Stderr.Fprintf("%s [%s]: ", label, defaultValue)
+		} else {
+			utils.Stderr.Fprintf("%s: ", label)
+		}
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = defaultValue
+		}
+		if answer != "" || !required {
+			return answer, nil
+		}
+		utils.Stderr.Fprintf("%s is required\n", label)
+	}
+}
+
+func (p *prompter) choose(name, label string, options []string) (string, error) {
+	for {
+		value, err := p.ask(name, fmt.Sprintf("%s (%s)", label, strings.Join(options, ", ")), true)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(options, value) {
+			return value, nil
+		}
+		if p.nonInteractive || p.values[name] != "" {
+			return "", errors.Errorf("invalid --%s: %s, must be one of: %s", name, value, strings.Join(options, ", "))
+		}
+		utils.Stderr.Fprintf("Invalid %s: %s\n", strings.ToLower(label), value)
+	}
+}
+
+// askSecret input is not echoed, secret reference e.g. env:NAME is accepted, default value is not shown
+func (p *prompter) askSecret(name, label string, required bool) (string, error) {
+	if value := p.values[name]; value != "" || p.nonInteractive {
+		return p.ask(name, label, required)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return p.ask(name, label, required)
+	}
+	defaultValue := p.defaults[name]
+	for {
+		if defaultValue != "" {
+			utils.Stderr.Fprintf("%s [keep current]: ", label)
+		} else {
+			utils.Stderr.Fprintf("%s: ", label)
+		}
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		utils.Stderr.Fprintf("\n")
+		if err != nil {
+			return "", errors.Wrap(err, "read secret failed")
+		}
+		answer := strings.TrimSpace(string(secret))
+		if answer == "" {
+			answer = defaultValue
+		}
+		if answer != "" || !required {
+			return answer, nil
+		}
+		utils.Stderr.Fprintf("%s is required\n", label)
+	}
+}
+
+// confirm is only for interactive mode
+func (p *prompter) confirm(label string) bool {
+	utils.Stderr.Fprintf("%s [y/N]: ", label)
+	answer, _ := p.readLine()
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+func (p *prompter) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", errors.Wrap(err, "read input failed")
+	}
+	return strings.TrimSpace(line), nil
+}
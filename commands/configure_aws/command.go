@@ -0,0 +1,205 @@
+package configure_aws
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	EnvAwsConfigFile = "AWS_CONFIG_FILE"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	stringSliceFlagProfile = &cli.StringSliceFlag{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Usage:   "IDaaS Profile, can be assigned multiple times (default all AWS profiles)",
+	}
+	stringFlagAwsConfig = &cli.StringFlag{
+		Name:  "aws-config",
+		Usage: "AWS config file (default $AWS_CONFIG_FILE or ~/.aws/config)",
+	}
+	stringFlagAwsProfilePrefix = &cli.StringFlag{
+		Name:  "aws-profile-prefix",
+		Usage: "AWS profile name prefix, AWS profile name is <prefix><IDaaS profile>",
+	}
+	boolFlagDryRun = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Print AWS config, do not write file",
+	}
+)
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		stringSliceFlagProfile,
+		stringFlagAwsConfig,
+		stringFlagAwsProfilePrefix,
+		boolFlagDryRun,
+	}
+	return &cli.Command{
+		Name:  "configure-aws",
+		Usage: "Configure AWS CLI credential_process for profiles",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			configFilename := context.String("config")
+			profiles := context.StringSlice("profile")
+			awsConfigFilename := context.String("aws-config")
+			awsProfilePrefix := context.String("aws-profile-prefix")
+			dryRun := context.Bool("dry-run")
+			return configureAws(configFilename, profiles, awsConfigFilename, awsProfilePrefix, dryRun)
+		},
+	}
+}
+
+func configureAws(configFilename string, profiles []string, awsConfigFilename, awsProfilePrefix string, dryRun bool) error {
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		for profile, cloudStsConfig := range cloudCredentialConfig.Profile {
+			if cloudStsConfig != nil && cloudStsConfig.Aws != nil {
+				profiles = append(profiles, profile)
+			}
+		}
+		sort.Strings(profiles)
+		if len(profiles) == 0 {
+			return errors.New("no AWS profile found")
+		}
+	}
+	for _, profile := range profiles {
+		cloudStsConfig := cloudCredentialConfig.Profile[profile]
+		if cloudStsConfig == nil {
+			return errors.Errorf("profile: %s not found", profile)
+		}
+		if cloudStsConfig.Aws == nil {
+			return errors.Errorf("profile: %s is not AWS profile", profile)
+		}
+	}
+
+	if awsConfigFilename == "" {
+		awsConfigFilename, err = getDefaultAwsConfigFile()
+		if err != nil {
+			return err
+		}
+	}
+	awsConfigContent, err := os.ReadFile(awsConfigFilename)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "read AWS config file: %s failed", awsConfigFilename)
+	}
+
+	content := string(awsConfigContent)
+	for _, profile := range profiles {
+		section := "profile " + awsProfilePrefix + profile
+		if awsProfilePrefix+profile == "default" {
+			section = "default"
+		}
+		keyValues := []utils.IniKeyValue{
+			{Key: "credential_process", Value: buildCredentialProcess(configFilename, profile)},
+		}
+		region := cloudCredentialConfig.Profile[profile].Aws.Region
+		if region != "" {
+			keyValues = append(keyValues, utils.IniKeyValue{Key: "region", Value: region})
+		}
+		content = utils.UpdateIniSection(content, section, keyValues)
+		utils.Stderr.Fprintf("Configure AWS profile: %s, IDaaS profile: %s\n", awsProfilePrefix+profile, profile)
+	}
+
+	if dryRun {
+		utils.Stdout.Print(content)
+		return nil
+	}
+	err = os.MkdirAll(filepath.Dir(awsConfigFilename), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "create AWS config dir failed")
+	}
+	err = utils.WriteFileAtomic(awsConfigFilename, []byte(content), 0600)
+	if err != nil {
+		return errors.Wrapf(err, "write AWS config file: %s failed", awsConfigFilename)
+	}
+	utils.Stderr.Fprintf("AWS config file: %s updated\n", awsConfigFilename)
+	return nil
+}
+
+func buildCredentialProcess(configFilename, profile string) string {
+	args := []string{"alibaba-cloud-idaas", "fetch-token"}
+	if configFilename != "" {
+		// credential_process may run in any working dir
+		absConfigFilename, err := filepath.Abs(configFilename)
+		if err == nil {
+			configFilename = absConfigFilename
+		}
+		args = append(args, "-c", quoteArg(configFilename))
+	}
+	args = append(args, "-p", quoteArg(profile), "-f", aws.FormatAwsCredentialProcess)
+	return strings.Join(args, " ")
+}
+
+// quoteArg quotes argument for AWS CLI and SDKs, which split credential_process like shell(POSIX)
+// or by Windows command line rules
+func quoteArg(arg string) string {
+	return quoteArgForOs(runtime.GOOS, arg)
+}
+
+func quoteArgForOs(goos, arg string) string {
+	if goos == "windows" {
+		if !strings.ContainsAny(arg, " \t\"") {
+			return arg
+		}
+		return quoteWindowsArg(arg)
+	}
+	if !strings.ContainsAny(arg, " \t\"'\\$`") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// quoteWindowsArg backslashes are literal unless followed by a quote, e.g. C:\Users\a b\config.json
+func quoteWindowsArg(arg string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	backslashes := 0
+	for _, c := range arg {
+		switch c {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			builder.WriteString(strings.Repeat(`\`, backslashes*2+1))
+		default:
+			builder.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		builder.WriteRune(c)
+		backslashes = 0
+	}
+	builder.WriteString(strings.Repeat(`\`, backslashes*2))
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+func getDefaultAwsConfigFile() (string, error) {
+	awsConfigFileFromEnv := os.Getenv(EnvAwsConfigFile)
+	if awsConfigFileFromEnv != "" {
+		return awsConfigFileFromEnv, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home dir")
+	}
+	return filepath.Join(homeDir, ".aws", "config"), nil
+}
@@ -0,0 +1,78 @@
+package configure_aws
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuoteArgForOs(t *testing.T) {
+	tests := []struct {
+		goos     string
+		arg      string
+		expected string
+	}{
+		{"linux", "aws1", "aws1"},
+		{"linux", "/home/user a/config.json", "'/home/user a/config.json'"},
+		{"linux", "it's", `'it'\''s'`},
+		{"windows", `C:\Users\a\config.json`, `C:\Users\a\config.json`},
+		{"windows", `C:\Users\a b\config.json`, `"C:\Users\a b\config.json"`},
+		{"windows", `C:\a b\`, `"C:\a b\\"`},
+		{"windows", `a "b"`, `"a \"b\""`},
+	}
+	for _, test := range tests {
+		if quoted := quoteArgForOs(test.goos, test.arg); quoted != test.expected {
+			t.Errorf("%s %s: expected %s, got: %s", test.goos, test.arg, test.expected, quoted)
+		}
+	}
+}
+
+func TestConfigureAws(t *testing.T) {
+	dir := t.TempDir()
+	configFilename := filepath.Join(dir, "config.json")
+	err := os.WriteFile(configFilename, []byte(`{
+  "version": "1",
+  "profile": {
+    "aws1": {"aws_sts": {"region": "us-east-1", "role_arn": "arn:aws:iam::123456789012:role/role1"}},
+    "aliyun1": {"alibaba_cloud_sts": {"region": "cn-hangzhou"}}
+  }
+}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	awsConfigFilename := filepath.Join(dir, "aws", "config")
+	if err = os.MkdirAll(filepath.Dir(awsConfigFilename), 0700); err != nil {
+		t.Fatal(err)
+	}
+	awsConfig := "[default]\nregion = us-west-2\n\n[profile idaas-aws1]\n# comment\ncredential_process = old\n"
+	if err = os.WriteFile(awsConfigFilename, []byte(awsConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = configureAws(configFilename, []string{"aliyun1"}, awsConfigFilename, "idaas-", false); err == nil {
+		t.Error("non AWS profile should be rejected")
+	}
+	if err = configureAws(configFilename, nil, awsConfigFilename, "idaas-", false); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(awsConfigFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[default]\nregion = us-west-2\n\n[profile idaas-aws1]\n# comment\n" +
+		"credential_process = alibaba-cloud-idaas fetch-token -c " + quoteArg(configFilename) +
+		" -p aws1 -f aws-credential-process\nregion = us-east-1\n"
+	if string(content) != expected {
+		t.Errorf("unexpected AWS config:\n%s", content)
+	}
+	if fileInfo, err := os.Stat(awsConfigFilename); err != nil || fileInfo.Mode().Perm() != 0600 {
+		t.Errorf("AWS config file permission should be kept 0600, error: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(awsConfigFilename))
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp") {
+			t.Errorf("temp file should be removed: %s", entry.Name())
+		}
+	}
+}
@@ -28,7 +28,7 @@ var (
 	stringFlagFormat = &cli.StringFlag{
 		Name:    "format",
 		Aliases: []string{"f"},
-		Usage:   "Cloud STS format, values aliyuncli(default), ossutilv2, raw",
+		Usage:   "Cloud STS format, values aliyuncli(default), ossutilv2, raw, aws-credential-process(AWS only)",
 	}
 	stringFlagOidcField = &cli.StringFlag{
 		Name:  "oidc-field",
@@ -104,7 +104,7 @@ func fetchToken(configFilename, profile, format, oidcField, oidcFormat, output s
 	if alibabaCloudSts, ok := sts.(*alibaba_cloud.StsToken); ok {
 		stdOutput, stdOutputErr = alibabaCloudSts.MarshalWithFormat(format)
 	} else if awsStsToken, ok := sts.(*aws.AwsStsToken); ok {
-		stdOutput, stdOutputErr = awsStsToken.Marshal()
+		stdOutput, stdOutputErr = awsStsToken.MarshalWithFormat(format)
 	} else if oidcToken, ok := sts.(*oidc.OidcToken); ok {
 		if oidcTokenType == oidc.FetchIdToken {
 			printNewLine = false
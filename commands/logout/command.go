@@ -0,0 +1,267 @@
+package logout
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+
+	cloudoidc "github.com/aliyunidaas/alibaba-cloud-idaas/cloud/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/cache"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	stringSliceFlagProfile = &cli.StringSliceFlag{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Usage:   "IDaaS Profile, can be assigned multiple times (default current profile)",
+	}
+	boolFlagAll = &cli.BoolFlag{
+		Name:  "all",
+		Usage: "Logout all profiles",
+	}
+	boolFlagNoRevoke = &cli.BoolFlag{
+		Name:  "no-revoke",
+		Usage: "Remove local cache only, do not revoke tokens",
+	}
+)
+
+// revokeToken token to be revoked, revocation endpoint is resolved from issuer
+type revokeToken struct {
+	issuer        string
+	clientId      string
+	clientSecret  string
+	token         string
+	tokenTypeHint string
+}
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		stringSliceFlagProfile,
+		boolFlagAll,
+		boolFlagNoRevoke,
+	}
+	return &cli.Command{
+		Name:  "logout",
+		Usage: "Revoke cached tokens (RFC7009) and remove cache of profiles",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			cloudCredentialConfig, err := config.LoadCloudCredentialConfig(context.String("config"))
+			if err != nil {
+				return err
+			}
+			profiles := context.StringSlice("profile")
+			if context.Bool("all") {
+				if len(profiles) > 0 {
+					return errors.New("--profile and --all cannot be used together")
+				}
+				for profile := range cloudCredentialConfig.Profile {
+					profiles = append(profiles, profile)
+				}
+				sort.Strings(profiles)
+			} else if len(profiles) == 0 {
+				profile, cloudStsConfig := cloudCredentialConfig.FindProfile("")
+				if cloudStsConfig == nil {
+					return errors.Errorf("profile: %s not found, specify profile by --profile or use --all", profile)
+				}
+				profiles = append(profiles, profile)
+			}
+			return logout(cloudCredentialConfig, profiles, !context.Bool("no-revoke"))
+		},
+	}
+}
+
+func logout(cloudCredentialConfig *config.CloudCredentialConfig, profiles []string, revoke bool) error {
+	revokeFailedCount := 0
+	if revoke {
+		cacheKeys := cache.NewProfileCacheKeys(cloudCredentialConfig)
+		var revokeTokens []*revokeToken
+		for _, profile := range profiles {
+			cloudStsConfig, ok := cloudCredentialConfig.Profile[profile]
+			if !ok || cloudStsConfig == nil {
+				utils.Stderr.Fprintf("Profile: %s not found, remove cache only\n", profile)
+				continue
+			}
+			revokeTokens = append(revokeTokens, collectRevokeTokens(profile, cloudStsConfig, cacheKeys, profiles)...)
+		}
+		revokeFailedCount = revokeAll(revokeTokens)
+	}
+
+	// cache is removed even revoke failed, local credentials MUST NOT be left
+	if err := cache.RemoveProfilesCache(cloudCredentialConfig, profiles, false); err != nil {
+		return err
+	}
+	if revokeFailedCount > 0 {
+		return errors.Errorf("revoke %d token(s) failed, local cache is removed", revokeFailedCount)
+	}
+	return nil
+}
+
+// collectRevokeTokens collects refresh tokens and access tokens from cache, ID token cannot be revoked,
+// tokens shared with profiles not logged out are not revoked
+func collectRevokeTokens(profile string, cloudStsConfig *config.CloudStsConfig,
+	cacheKeys cache.ProfileCacheKeys, profiles []string) []*revokeToken {
+	var revokeTokens []*revokeToken
+	for _, oidcTokenProvider := range cache.GetProfileOidcTokenProviders(cloudStsConfig) {
+		tokenResponseCacheKey := oidcTokenProvider.TokenResponseCacheKey()
+		oidcTokenCacheKey := oidcTokenProvider.OidcTokenCacheKey()
+		issuer := oidcTokenProvider.Issuer()
+		if issuer == "" {
+			// revocation endpoint is discovered from issuer, client credentials(e.g. private_key_jwt) and open api have none
+			if hasCache(constants.CategoryTokenResponse, tokenResponseCacheKey) ||
+				hasCache(constants.CategoryOidcToken, oidcTokenCacheKey) {
+				utils.Stderr.Fprintf("Profile: %s token of client: %s is not revoked, revocation requires "+
+					"device_code or authorization_code, token is valid until expired\n", profile, oidcTokenProvider.ClientId())
+			}
+			continue
+		}
+		if sharedProfiles := getSharedProfiles(cacheKeys, profiles, tokenResponseCacheKey, oidcTokenCacheKey); len(sharedProfiles) > 0 {
+			utils.Stderr.Fprintf("Profile: %s token of client: %s is shared with profile(s): %s, revoke skipped\n",
+				profile, oidcTokenProvider.ClientId(), strings.Join(sharedProfiles, ", "))
+			continue
+		}
+		clientSecret, err := oidcTokenProvider.ClientSecret()
+		if err != nil {
+			utils.Stderr.Fprintf("Profile: %s %s, revoke skipped\n", profile, err)
+			continue
+		}
+		addToken := func(token, tokenTypeHint string) {
+			if token != "" {
+				revokeTokens = append(revokeTokens, &revokeToken{
+					issuer:        issuer,
+					clientId:      oidcTokenProvider.ClientId(),
+					clientSecret:  clientSecret,
+					token:         token,
+					tokenTypeHint: tokenTypeHint,
+				})
+			}
+		}
+
+		var tokenResponse oidc.TokenResponse
+		if readCacheJson(constants.CategoryTokenResponse, tokenResponseCacheKey, false, &tokenResponse) {
+			addToken(tokenResponse.RefreshToken, oidc.TokenTypeHintRefreshToken)
+			addToken(tokenResponse.AccessToken, oidc.TokenTypeHintAccessToken)
+		}
+		if oidcTokenProvider.IsAccessToken() {
+			if accessToken := readCacheContent(constants.CategoryOidcToken, oidcTokenCacheKey); accessToken != "" {
+				addToken(accessToken, oidc.TokenTypeHintAccessToken)
+			}
+		}
+		if cloudStsConfig.OidcToken == oidcTokenProvider.Config {
+			var oidcToken cloudoidc.OidcToken
+			if readCacheJson(constants.CategoryCloudToken, cloudoidc.CloudTokenCacheKey(profile, cloudStsConfig.OidcToken), true, &oidcToken) {
+				addToken(oidcToken.RefreshToken, oidc.TokenTypeHintRefreshToken)
+				addToken(oidcToken.AccessToken, oidc.TokenTypeHintAccessToken)
+			}
+		}
+	}
+	return revokeTokens
+}
+
+// getSharedProfiles profiles not logged out but using the same token response or OIDC token
+func getSharedProfiles(cacheKeys cache.ProfileCacheKeys, profiles []string, tokenResponseCacheKey, oidcTokenCacheKey string) []string {
+	var sharedProfiles []string
+	for _, keyProfile := range append(cacheKeys.Get(constants.CategoryTokenResponse, tokenResponseCacheKey),
+		cacheKeys.Get(constants.CategoryOidcToken, oidcTokenCacheKey)...) {
+		if !slices.Contains(profiles, keyProfile) && !slices.Contains(sharedProfiles, keyProfile) {
+			sharedProfiles = append(sharedProfiles, keyProfile)
+		}
+	}
+	sort.Strings(sharedProfiles)
+	return sharedProfiles
+}
+
+// revokeAll revokes refresh tokens first, access tokens may be revoked with refresh token by server
+func revokeAll(revokeTokens []*revokeToken) int {
+	sort.SliceStable(revokeTokens, func(i, j int) bool {
+		return revokeTokens[i].tokenTypeHint == oidc.TokenTypeHintRefreshToken &&
+			revokeTokens[j].tokenTypeHint != oidc.TokenTypeHintRefreshToken
+	})
+	revokedTokens := map[string]bool{}
+	revocationEndpoints := map[string]string{}
+	failedCount := 0
+	for _, revokeToken := range revokeTokens {
+		if revokedTokens[revokeToken.token] {
+			continue
+		}
+		revokedTokens[revokeToken.token] = true
+
+		revocationEndpoint, ok := revocationEndpoints[revokeToken.issuer]
+		if !ok {
+			openIdConfiguration, err := oidc.FetchOpenIdConfiguration(revokeToken.issuer, &oidc.FetchOpenIdConfigurationOptions{})
+			if err != nil {
+				utils.Stderr.Fprintf("Fetch OpenID configuration of issuer: %s failed: %v\n", revokeToken.issuer, err)
+			} else {
+				revocationEndpoint = openIdConfiguration.RevocationEndpoint
+				if revocationEndpoint == "" {
+					utils.Stderr.Fprintf("Issuer: %s does not support token revocation\n", revokeToken.issuer)
+				}
+			}
+			revocationEndpoints[revokeToken.issuer] = revocationEndpoint
+		}
+		if revocationEndpoint == "" {
+			failedCount++
+			continue
+		}
+
+		errorResponse, err := oidc.RevokeToken(revocationEndpoint, &oidc.RevokeTokenOptions{
+			ClientId:      revokeToken.clientId,
+			ClientSecret:  revokeToken.clientSecret,
+			Token:         revokeToken.token,
+			TokenTypeHint: revokeToken.tokenTypeHint,
+		})
+		if err == nil && errorResponse != nil {
+			err = errors.Errorf("%s: %s", errorResponse.Error, errorResponse.ErrorDescription)
+		}
+		if err != nil {
+			failedCount++
+			utils.Stderr.Fprintf("Revoke %s of client: %s failed: %v\n", revokeToken.tokenTypeHint, revokeToken.clientId, err)
+			continue
+		}
+		utils.Stderr.Fprintf("Revoke %s of client: %s success\n", revokeToken.tokenTypeHint, revokeToken.clientId)
+	}
+	return failedCount
+}
+
+// readCacheContent reads content of StringWithTime cache
+func readCacheContent(category, key string) string {
+	data, err := utils.ReadCache(category, key)
+	if err != nil || data == "" {
+		return ""
+	}
+	stringWithTime, err := utils.UnmarshalStringWithTime(data)
+	if err != nil {
+		return ""
+	}
+	return stringWithTime.Content
+}
+
+func readCacheJson(category, key string, withTime bool, v interface{}) bool {
+	var data string
+	if withTime {
+		data = readCacheContent(category, key)
+	} else {
+		data, _ = utils.ReadCache(category, key)
+	}
+	if data == "" {
+		return false
+	}
+	return json.Unmarshal([]byte(data), v) == nil
+}
+
+func hasCache(category, key string) bool {
+	data, err := utils.ReadCache(category, key)
+	return err == nil && data != ""
+}
@@ -0,0 +1,134 @@
+package logout
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+)
+
+func TestLogout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCacheBackend, utils.CacheBackendFile)
+
+	var lock sync.Mutex
+	var revokedTokens []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(&oidc.OpenIdConfiguration{
+				Issuer:             server.URL,
+				RevocationEndpoint: server.URL + "/revoke",
+			})
+		case "/revoke":
+			if r.PostFormValue("client_id") != "client1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			lock.Lock()
+			revokedTokens = append(revokedTokens, r.PostFormValue("token_type_hint")+":"+r.PostFormValue("token"))
+			lock.Unlock()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oidcTokenProvider := &config.OidcTokenProviderConfig{
+		OidcTokenProviderDeviceCode: &config.OidcTokenProviderDeviceCodeConfig{
+			Issuer:   server.URL,
+			ClientId: "client1",
+		},
+	}
+	cloudCredentialConfig := &config.CloudCredentialConfig{
+		Version: config.Version1,
+		Profile: map[string]*config.CloudStsConfig{
+			"p1": {AlibabaCloud: &config.AlibabaCloudStsConfig{OidcTokenProvider: oidcTokenProvider}},
+		},
+	}
+	tokenResponse, _ := json.Marshal(&oidc.TokenResponse{AccessToken: "at1", RefreshToken: "rt1"})
+	if err := utils.WriteCacheFileWithEncryption(constants.CategoryTokenResponse, oidcTokenProvider.GetCacheKey(), string(tokenResponse)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := logout(cloudCredentialConfig, []string{"p1"}, true); err != nil {
+		t.Fatal(err)
+	}
+	expectedRevokedTokens := []string{"refresh_token:rt1", "access_token:at1"}
+	if !slices.Equal(revokedTokens, expectedRevokedTokens) {
+		t.Errorf("revoked tokens: %v, expected: %v", revokedTokens, expectedRevokedTokens)
+	}
+	keys, _ := utils.ListCacheFiles(constants.CategoryTokenResponse)
+	if len(keys) != 0 {
+		t.Errorf("token response is not removed: %v", keys)
+	}
+}
+
+func TestLogoutSharedToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCacheBackend, utils.CacheBackendFile)
+
+	var revokeCount atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(&oidc.OpenIdConfiguration{
+				Issuer:             server.URL,
+				RevocationEndpoint: server.URL + "/revoke",
+			})
+		case "/revoke":
+			revokeCount.Add(1)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oidcTokenProvider := &config.OidcTokenProviderConfig{
+		OidcTokenProviderDeviceCode: &config.OidcTokenProviderDeviceCodeConfig{
+			Issuer:   server.URL,
+			ClientId: "client1",
+		},
+	}
+	cloudCredentialConfig := &config.CloudCredentialConfig{
+		Version: config.Version1,
+		Profile: map[string]*config.CloudStsConfig{
+			"p1": {AlibabaCloud: &config.AlibabaCloudStsConfig{RoleArn: "acs:ram::1:role/role1", OidcTokenProvider: oidcTokenProvider}},
+			"p2": {AlibabaCloud: &config.AlibabaCloudStsConfig{RoleArn: "acs:ram::1:role/role2", OidcTokenProvider: oidcTokenProvider}},
+		},
+	}
+	tokenResponse, _ := json.Marshal(&oidc.TokenResponse{AccessToken: "at1", RefreshToken: "rt1"})
+	if err := utils.WriteCacheFileWithEncryption(constants.CategoryTokenResponse, oidcTokenProvider.GetCacheKey(), string(tokenResponse)); err != nil {
+		t.Fatal(err)
+	}
+
+	// token response is shared with p2, which is still logged in
+	if err := logout(cloudCredentialConfig, []string{"p1"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if revokeCount.Load() != 0 {
+		t.Errorf("shared token should not be revoked, revoke count: %d", revokeCount.Load())
+	}
+	keys, _ := utils.ListCacheFiles(constants.CategoryTokenResponse)
+	if len(keys) != 1 {
+		t.Errorf("shared token response should be kept: %v", keys)
+	}
+
+	if err := logout(cloudCredentialConfig, []string{"p1", "p2"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if revokeCount.Load() != 2 {
+		t.Errorf("token should be revoked when all profiles logged out, revoke count: %d", revokeCount.Load())
+	}
+}
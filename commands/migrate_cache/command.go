@@ -0,0 +1,112 @@
+package migrate_cache
+
+import (
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	boolFlagRotateKey = &cli.BoolFlag{
+		Name:  "rotate-key",
+		Usage: "Generate new cache key, e.g. change cache passphrase",
+	}
+	boolFlagDryRun = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Show cache entries to be migrated only",
+	}
+)
+
+type cacheEntry struct {
+	category string
+	key      string
+	content  string
+}
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		boolFlagRotateKey,
+		boolFlagDryRun,
+	}
+	return &cli.Command{
+		Name:  "migrate-cache",
+		Usage: "Re-encrypt cache entries with current cache encryption mode",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			configFilename := context.String("config")
+			if configFilename != "" {
+				if _, err := config.LoadCloudCredentialConfig(configFilename); err != nil {
+					return err
+				}
+			} else {
+				config.ApplyDefaultConfigCacheBackend()
+			}
+			return migrateCache(context.Bool("rotate-key"), context.Bool("dry-run"))
+		},
+	}
+}
+
+func migrateCache(rotateKey, dryRun bool) error {
+	if cacheBackend := utils.GetCacheBackend(); cacheBackend != utils.CacheBackendFile {
+		return errors.Errorf("migrate cache only supports cache backend: %s, current: %s",
+			utils.CacheBackendFile, cacheBackend)
+	}
+	encryptionMode := utils.GetCacheEncryptionMode()
+	utils.Stderr.Fprintf("Cache encryption mode: %s\n", encryptionMode)
+
+	// read all entries before rotate key, entries encrypted with old key cannot be read after rotated
+	var entries []*cacheEntry
+	skipCount := 0
+	for _, category := range constants.CacheCategories {
+		keys, err := utils.ListCacheFiles(category)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			content, err := utils.ReadCacheFileWithEncryption(category, key)
+			if err != nil {
+				skipCount++
+				utils.Stderr.Fprintf("Skip cache [%s, %s]: %v\n", category, key, err)
+				continue
+			}
+			entries = append(entries, &cacheEntry{category: category, key: key, content: content})
+		}
+	}
+	if dryRun {
+		for _, entry := range entries {
+			utils.Stderr.Fprintf("Migrate cache [%s, %s] (dry run)\n", entry.category, entry.key)
+		}
+		utils.Stderr.Fprintf("Migrate: %d entries, skipped: %d (dry run)\n", len(entries), skipCount)
+		return nil
+	}
+
+	if rotateKey {
+		if encryptionMode == utils.CacheEncryptionModeDefault {
+			return errors.New("rotate key requires cache encryption mode passphrase or signer")
+		}
+		if err := utils.RotateCacheKey(); err != nil {
+			return err
+		}
+		utils.Stderr.Fprintf("Cache key rotated\n")
+	}
+
+	migrateCount := 0
+	for _, entry := range entries {
+		err := utils.WriteCacheFileWithEncryption(entry.category, entry.key, entry.content)
+		if err != nil {
+			return errors.Wrapf(err, "migrate cache [%s, %s] failed", entry.category, entry.key)
+		}
+		migrateCount++
+		utils.Stderr.Fprintf("Migrate cache [%s, %s] success\n", entry.category, entry.key)
+	}
+	utils.Stderr.Fprintf("Migrate: %d entries, skipped: %d\n", migrateCount, skipCount)
+	return nil
+}
@@ -5,12 +5,12 @@ import (
 	"io"
 	"os"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_account"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/credential"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/openclaw"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idp"
-	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
 	"github.com/urfave/cli/v2"
 )
 
@@ -89,9 +89,8 @@ func fetchOpenClawSecret(configFilename, profile string, forceNew bool) error {
 		ForceNew: forceNew,
 		CacheKey: agentConfig.AccessTokenProvider.GetCacheKey(),
 	}
-	// MUST be Access Token for Cloud Account Token obtain
-	agentConfig.AccessTokenProvider.TokenType = oidc.TokenAccessToken
-	accessToken, err := idp.FetchOidcToken(profile, agentConfig.AccessTokenProvider, fetchOidcTokenOptions)
+	accessTokenProvider := cloud_account.GetAccessTokenProvider(agentConfig.AccessTokenProvider)
+	accessToken, err := idp.FetchOidcToken(profile, accessTokenProvider, fetchOidcTokenOptions)
 	if err != nil {
 		return fmt.Errorf("fetch access token error: %s", err)
 	}
@@ -0,0 +1,363 @@
+package rotate_signer_key
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/common"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"github.com/youmark/pkcs8"
+	"golang.org/x/term"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	stringFlagProfile = &cli.StringFlag{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Usage:   "IDaaS Profile",
+	}
+	stringFlagAlgorithm = &cli.StringFlag{
+		Name:  "algorithm",
+		Usage: "Algorithm of new key (default algorithm of current signer)",
+	}
+	stringFlagKeyFile = &cli.StringFlag{
+		Name:  "key-file",
+		Usage: "New private key file (default <profile>_signer_<time>.pem in config dir)",
+	}
+	stringFlagJwksFile = &cli.StringFlag{
+		Name:  "jwks-file",
+		Usage: "Write JWKS of current and new keys to file (default stdout)",
+	}
+	boolFlagYes = &cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Update profile to new key without confirmation",
+	}
+)
+
+type rotateSignerKeyOptions struct {
+	configFilename string
+	profile        string
+	algorithm      string
+	keyFile        string
+	jwksFile       string
+	yes            bool
+}
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		stringFlagProfile,
+		stringFlagAlgorithm,
+		stringFlagKeyFile,
+		stringFlagJwksFile,
+		boolFlagYes,
+	}
+	return &cli.Command{
+		Name:  "rotate-signer-key",
+		Usage: "Generate new key_file signer key, output JWKS of current and new keys, and update profile",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			return rotateSignerKey(&rotateSignerKeyOptions{
+				configFilename: context.String("config"),
+				profile:        context.String("profile"),
+				algorithm:      context.String("algorithm"),
+				keyFile:        context.String("key-file"),
+				jwksFile:       context.String("jwks-file"),
+				yes:            context.Bool("yes"),
+			})
+		},
+	}
+}
+
+func rotateSignerKey(options *rotateSignerKeyOptions) error {
+	configFilename := options.configFilename
+	if configFilename == "" {
+		var err error
+		if configFilename, err = config.GetDefaultCloudCredentialConfigFile(); err != nil {
+			return err
+		}
+	}
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		return err
+	}
+	profile, cloudStsConfig := cloudCredentialConfig.FindProfile(options.profile)
+	if cloudStsConfig == nil {
+		return errors.Errorf("profile: %s not found", profile)
+	}
+	clientAssertionSigner := common.FindClientAssertionSigner(cloudStsConfig)
+	if clientAssertionSigner == nil {
+		return errors.Errorf("client assertion signer not found in profile: %s", profile)
+	}
+	currentSignerConfig := clientAssertionSigner.Config
+	if currentSignerConfig.KeyFile == nil {
+		return errors.New("only key_file signer can be rotated, keys in PKCS#11, YubiKey or external signer MUST be rotated by its owner")
+	}
+
+	// signer from parent profile or shared token provider is not in the profile, it MUST be rotated where it is defined
+	if inherits := cloudCredentialConfig.ProfileInherits[profile]; len(inherits) > 0 {
+		return errors.Errorf("profile: %s uses %s, update signer key manually where signer is defined",
+			profile, strings.Join(inherits, ", "))
+	}
+
+	currentJwk, err := getSignerJwk(currentSignerConfig)
+	if err != nil {
+		return errors.Wrap(err, "get current signer public key failed")
+	}
+	algorithm := options.algorithm
+	if algorithm == "" {
+		algorithm = currentSignerConfig.Algorithm
+	}
+	alg, err := signer.ParseJwtSignAlgorithm(algorithm)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := generatePrivateKey(alg)
+	if err != nil {
+		return err
+	}
+	newJwk, err := jwt.NewJwk(privateKey.Public())
+	if err != nil {
+		return err
+	}
+	if newJwk.KeyId, err = newJwk.Thumbprint(); err != nil {
+		return err
+	}
+	newJwk.Algorithm = alg.ToString()
+	newJwk.Use = "sig"
+	if newJwk.KeyId == currentJwk.KeyId {
+		return errors.New("new key id conflicts with current key id")
+	}
+
+	keyFile := options.keyFile
+	if keyFile == "" {
+		keyFile = filepath.Join(filepath.Dir(configFilename),
+			fmt.Sprintf("%s_signer_%s.pem", profile, time.Now().Format("20060102150405")))
+	}
+	password, err := getKeyFilePassword(currentSignerConfig.KeyFile)
+	if err != nil {
+		return err
+	}
+	newKeyFileConfig := map[string]interface{}{
+		"file": keyFile,
+	}
+	if currentSignerConfig.KeyFile.Password != "" {
+		newKeyFileConfig["password"] = currentSignerConfig.KeyFile.Password
+	}
+	newSignerConfig := map[string]interface{}{
+		"key_id":    newJwk.KeyId,
+		"algorithm": newJwk.Algorithm,
+		"key_file":  newKeyFileConfig,
+	}
+
+	// profile may come from profile.d, only JSON file is updated, YAML and TOML comments cannot be kept
+	profileFilename := cloudCredentialConfig.ProfileSources[profile]
+	if profileFilename == "" {
+		profileFilename = configFilename
+	}
+	updateProfile := config.GetConfigFormat(profileFilename) == config.ConfigFormatJson
+	prompt := fmt.Sprintf("Write new key: %s to: %s and update profile: %s? [y/N]: ", newJwk.KeyId, keyFile, profile)
+	if !updateProfile {
+		prompt = fmt.Sprintf("Write new key: %s to: %s? [y/N]: ", newJwk.KeyId, keyFile)
+	}
+	// nothing is written before confirmed
+	if !options.yes && !confirm(prompt) {
+		utils.Stderr.Fprintf("Rotate signer key is cancelled, nothing is written\n")
+		return nil
+	}
+	if err = writePrivateKey(keyFile, privateKey, password); err != nil {
+		return err
+	}
+	utils.Stderr.Fprintf("New private key is written to: %s\n", keyFile)
+
+	// current key is kept in JWKS, tokens signed by current key are valid during overlap
+	jwksJson, err := json.MarshalIndent(&jwt.Jwks{Keys: []*jwt.Jwk{currentJwk, newJwk}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if options.jwksFile != "" {
+		if err = os.WriteFile(options.jwksFile, append(jwksJson, '\n'), 0644); err != nil {
+			return errors.Wrapf(err, "write JWKS file: %s failed", options.jwksFile)
+		}
+		utils.Stderr.Fprintf("JWKS is written to: %s\n", options.jwksFile)
+	} else {
+		utils.Stdout.Println(string(jwksJson))
+	}
+
+	utils.Stderr.Fprintf("Register JWKS to IDaaS application before use profile: %s with new key: %s\n", profile, newJwk.KeyId)
+	if !updateProfile {
+		utils.Stderr.Fprintf("Profile is in %s file: %s, update signer config manually when JWKS is registered:\n",
+			config.GetConfigFormat(profileFilename), profileFilename)
+		newSignerConfigJson, _ := json.MarshalIndent(newSignerConfig, "", "  ")
+		utils.Stderr.Println(string(newSignerConfigJson))
+		return nil
+	}
+	if err = updateSignerConfig(profileFilename, profile, clientAssertionSigner.Path, newSignerConfig); err != nil {
+		return err
+	}
+	utils.Stderr.Fprintf("%s\n", utils.Green(fmt.Sprintf(
+		"Profile: %s is updated, remove current key from IDaaS application when all tokens signed by it expired", profile), true))
+	return nil
+}
+
+func getSignerJwk(exSingerConfig *config.ExSingerConfig) (*jwt.Jwk, error) {
+	exJwtSigner, err := config.NewExJwtSignerFromConfig(exSingerConfig)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := exJwtSigner.GetExtSinger().Public()
+	if err != nil {
+		return nil, err
+	}
+	return common.NewSignerJwk(exSingerConfig, publicKey)
+}
+
+func generatePrivateKey(alg signer.JwtSignAlgorithm) (crypto.Signer, error) {
+	switch alg {
+	case signer.RS256, signer.PS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case signer.RS384, signer.PS384:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case signer.RS512, signer.PS512:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case signer.ES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case signer.ES384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case signer.ES512:
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case signer.EdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	}
+	return nil, errors.Errorf("unsupported algorithm: %s", alg.ToString())
+}
+
+// getKeyFilePassword new key is encrypted when current key is encrypted
+func getKeyFilePassword(keyFileConfig *config.ExSingerKeyFileConfig) (string, error) {
+	if keyFileConfig.Password != "" {
+		return utils.ResolveSecret(keyFileConfig.Password)
+	}
+	content := keyFileConfig.Key
+	if content == "" {
+		contentBytes, err := os.ReadFile(keyFileConfig.File)
+		if err != nil {
+			return "", errors.Wrapf(err, "read key file: %s failed", keyFileConfig.File)
+		}
+		content = string(contentBytes)
+	}
+	if !strings.Contains(content, "ENCRYPTED PRIVATE KEY") {
+		return "", nil
+	}
+	password := os.Getenv(constants.EnvPkcs8Password)
+	if password == "" {
+		return "", errors.New("encrypted private key requires password")
+	}
+	return password, nil
+}
+
+func writePrivateKey(keyFile string, privateKey crypto.Signer, password string) error {
+	var passwordBytes []byte
+	pemType := "PRIVATE KEY"
+	if password != "" {
+		passwordBytes = []byte(password)
+		pemType = "ENCRYPTED PRIVATE KEY"
+	}
+	der, err := pkcs8.MarshalPrivateKey(privateKey, passwordBytes, nil)
+	if err != nil {
+		return errors.Wrap(err, "marshal private key failed")
+	}
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der})
+	// O_EXCL, existing key MUST NOT be overwritten
+	file, err := os.OpenFile(keyFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "create key file: %s failed", keyFile)
+	}
+	defer file.Close()
+	if _, err = file.Write(keyPem); err != nil {
+		return errors.Wrapf(err, "write key file: %s failed", keyFile)
+	}
+	return nil
+}
+
+func confirm(prompt string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	utils.Stderr.Print(prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// updateSignerConfig updates signer in config file, unknown fields and order of fields are kept
+func updateSignerConfig(configFilename, profile string, path []string, newSignerConfig map[string]interface{}) error {
+	content, err := os.ReadFile(configFilename)
+	if err != nil {
+		return errors.Wrapf(err, "read config file: %s failed", configFilename)
+	}
+	configObject, err := utils.UnmarshalJsonObject(content)
+	if err != nil {
+		return errors.Wrapf(err, "unmarshal config file: %s failed", configFilename)
+	}
+	signerObject, err := getObject(configObject, append([]string{"profile", profile}, path...)...)
+	if err != nil {
+		return err
+	}
+	// other signer types are removed, only one signer type is allowed
+	for _, name := range []string{"pkcs11", "yubikey_piv", "external_command"} {
+		signerObject.Delete(name)
+	}
+	for _, name := range []string{"key_id", "algorithm", "key_file"} {
+		signerObject.Set(name, newSignerConfig[name])
+	}
+
+	newContent, err := json.MarshalIndent(configObject, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal config failed")
+	}
+	backupFilename, err := utils.BackupFile(configFilename)
+	if err != nil {
+		return err
+	}
+	if err = utils.WriteFileAtomic(configFilename, newContent, 0600); err != nil {
+		return err
+	}
+	utils.Stderr.Fprintf("Updated file: %s, backup: %s\n", configFilename, backupFilename)
+	return nil
+}
+
+func getObject(object *utils.JsonObject, names ...string) (*utils.JsonObject, error) {
+	current := object
+	for _, name := range names {
+		if current = current.GetObject(name); current == nil {
+			return nil, errors.Errorf("config object: %s not found", name)
+		}
+	}
+	return current, nil
+}
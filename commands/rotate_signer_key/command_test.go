@@ -0,0 +1,137 @@
+package rotate_signer_key
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/common"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+)
+
+func TestRotateSignerKey(t *testing.T) {
+	dir := t.TempDir()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentKeyFile := filepath.Join(dir, "current.pem")
+	if err = os.WriteFile(currentKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configFilename := filepath.Join(dir, "config.json")
+	configJson := `{"$schema": "kept", "version": "1", "profile": {"p1": {"aws_sts": {"role_arn": "arn:aws:iam::1:role/r1",
+"oidc_token_provider": {"client_credentials": {"client_id": "client1", "client_assertion_singer": {
+"key_id": "key1", "algorithm": "ES256", "key_file": {"file": "` + filepath.ToSlash(currentKeyFile) + `"}}}}}}}}`
+	if err = os.WriteFile(configFilename, []byte(configJson), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	jwksFile := filepath.Join(dir, "jwks.json")
+	// not confirmed(stdin is not terminal), nothing is written
+	newKeyFile := filepath.Join(dir, "new.pem")
+	err = rotateSignerKey(&rotateSignerKeyOptions{
+		configFilename: configFilename,
+		profile:        "p1",
+		keyFile:        newKeyFile,
+		jwksFile:       jwksFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(newKeyFile); !os.IsNotExist(err) {
+		t.Fatalf("key file should not be written before confirmed: %v", err)
+	}
+	if _, err = os.Stat(jwksFile); !os.IsNotExist(err) {
+		t.Fatalf("JWKS file should not be written before confirmed: %v", err)
+	}
+
+	err = rotateSignerKey(&rotateSignerKeyOptions{
+		configFilename: configFilename,
+		profile:        "p1",
+		algorithm:      "PS256",
+		jwksFile:       jwksFile,
+		yes:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, err := jwt.LoadJwksFile(jwksFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 2 || jwks.Keys[0].KeyId != "key1" || jwks.Keys[1].Algorithm != "PS256" {
+		t.Fatalf("unexpected JWKS: %+v", jwks.Keys)
+	}
+
+	configContent, err := os.ReadFile(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(configContent), "{\n  \"$schema\": \"kept\",\n  \"version\": \"1\",") {
+		t.Errorf("field or order of fields is not kept: %s", configContent)
+	}
+	if strings.Index(string(configContent), `"role_arn"`) > strings.Index(string(configContent), `"oidc_token_provider"`) {
+		t.Errorf("order of fields is not kept: %s", configContent)
+	}
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerConfig := common.FindClientAssertionSigner(cloudCredentialConfig.Profile["p1"]).Config
+	if signerConfig.KeyID != jwks.Keys[1].KeyId || signerConfig.Algorithm != "PS256" {
+		t.Fatalf("unexpected signer config: %+v", signerConfig)
+	}
+
+	// token signed by new key is verified by JWKS
+	exJwtSigner, err := config.NewExJwtSignerFromConfig(signerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := exJwtSigner.SignJwtWithOptions(nil, &signer.JwtSignerOptions{Subject: "client1", Validity: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = jwt.Verify(token, jwt.NewStaticKeySet(jwks), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// signer of extends or ref profile is not defined in the profile
+	inheritConfigFilename := filepath.Join(dir, "inherit.json")
+	inheritConfigJson := `{"version": "1", "token_providers": {"shared": {"client_credentials": {"client_id": "client1",
+"client_assertion_singer": {"key_id": "key1", "algorithm": "ES256", "key_file": {"file": "` + filepath.ToSlash(currentKeyFile) + `"}}}}},
+"profile": {"p1": {"aws_sts": {"role_arn": "arn:aws:iam::1:role/r1", "oidc_token_provider": {"ref": "shared"}}},
+"p2": {"extends": "p1", "aws_sts": {"role_arn": "arn:aws:iam::1:role/r2"}}}}`
+	if err = os.WriteFile(inheritConfigFilename, []byte(inheritConfigJson), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, profile := range []string{"p1", "p2"} {
+		inheritKeyFile := filepath.Join(dir, profile+"_inherit.pem")
+		err = rotateSignerKey(&rotateSignerKeyOptions{
+			configFilename: inheritConfigFilename,
+			profile:        profile,
+			keyFile:        inheritKeyFile,
+			yes:            true,
+		})
+		if err == nil || !strings.Contains(err.Error(), "ref: shared") {
+			t.Errorf("profile: %s should be rejected, got: %v", profile, err)
+		}
+		if _, err = os.Stat(inheritKeyFile); !os.IsNotExist(err) {
+			t.Errorf("key file should not be written for profile: %s", profile)
+		}
+	}
+}
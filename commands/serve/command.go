@@ -3,9 +3,15 @@ package serve
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
@@ -32,6 +38,34 @@ var (
 		Name:  "unsafe-disable-ssrf",
 		Usage: "Disable SSRF feature",
 	}
+	boolFlagImds = &cli.BoolFlag{
+		Name:  "imds",
+		Usage: "Enable IMDS(instance metadata service) emulation, paths: /latest/api/token, /latest/meta-data/ram/security-credentials/<profile>, /latest/meta-data/iam/security-credentials/<profile>",
+	}
+	stringSliceFlagImdsProfile = &cli.StringSliceFlag{
+		Name:  "imds-profile",
+		Usage: "Profiles exposed as IMDS role names (default current profile)",
+	}
+	boolFlagUnsafeImdsAllowV1 = &cli.BoolFlag{
+		Name:  "unsafe-imds-allow-v1",
+		Usage: "Allow IMDS request without metadata token(IMDSv1)",
+	}
+	stringFlagUnixSocket = &cli.StringFlag{
+		Name:  "unix-socket",
+		Usage: "Listen at unix socket instead of TCP, caller UID is checked via peer credential (Linux and macOS)",
+	}
+	intSliceFlagUnixSocketAllowUid = &cli.IntSliceFlag{
+		Name:  "unix-socket-allow-uid",
+		Usage: "UIDs allowed to connect unix socket besides owner and UIDs in serve policies, can be assigned multiple times",
+	}
+	stringFlagUnixSocketGroup = &cli.StringFlag{
+		Name:  "unix-socket-group",
+		Usage: "Group(name or GID) of unix socket, allowed UIDs must be members of it (default primary group of owner)",
+	}
+	boolFlagEnableProfilesEndpoint = &cli.BoolFlag{
+		Name:  "enable-profiles-endpoint",
+		Usage: "Enable /profiles endpoint, list profiles allowed by request",
+	}
 )
 
 func BuildCommand() *cli.Command {
@@ -40,6 +74,13 @@ func BuildCommand() *cli.Command {
 		stringFlagUnsafeListenHost,
 		stringFlagSsrfToken,
 		boolFlagUnsafeDisableSsrf,
+		boolFlagImds,
+		stringSliceFlagImdsProfile,
+		boolFlagUnsafeImdsAllowV1,
+		stringFlagUnixSocket,
+		intSliceFlagUnixSocketAllowUid,
+		stringFlagUnixSocketGroup,
+		boolFlagEnableProfilesEndpoint,
 	}
 	return &cli.Command{
 		Name:  "serve",
@@ -48,9 +89,36 @@ func BuildCommand() *cli.Command {
 		Action: func(context *cli.Context) error {
 			ssrfToken := context.String("ssrf-token")
 			unsafeDisableSsrf := context.Bool("unsafe-disable-ssrf")
-			if ssrfToken == "" {
+			unixSocket := context.String("unix-socket")
+			var servePolicy *ServePolicy
+			cloudCredentialConfig, err := config.LoadCloudCredentialConfig("")
+			if err != nil {
+				idaaslog.Warn.PrintfLn("Load config for serve policy failed: %v", err)
+			} else {
+				servePolicy, err = NewServePolicy(cloudCredentialConfig)
+				if err != nil {
+					return err
+				}
+			}
+			// unix socket is not reachable via SSRF, caller is checked via peer credential
+			if ssrfToken == "" && unixSocket == "" && !servePolicy.hasPolicies() {
 				if !unsafeDisableSsrf {
-					return errors.New("SSRF token is required, unless --unsafe-disable-ssrf is set")
+					return errors.New("SSRF token is required, unless --unsafe-disable-ssrf or --unix-socket is set")
+				}
+			}
+			var unixSocketOptions *UnixSocketOptions
+			if unixSocket != "" {
+				// UIDs in policies are allowed without --unix-socket-allow-uid
+				allowedUids := context.IntSlice("unix-socket-allow-uid")
+				for _, uid := range servePolicy.getUids() {
+					if !slices.Contains(allowedUids, uid) {
+						allowedUids = append(allowedUids, uid)
+					}
+				}
+				unixSocketOptions = &UnixSocketOptions{
+					Socket:      unixSocket,
+					AllowedUids: allowedUids,
+					Group:       context.String("unix-socket-group"),
 				}
 			}
 
@@ -63,8 +131,31 @@ func BuildCommand() *cli.Command {
 				return fmt.Errorf("invalid port %d", port)
 			}
 			listenHostAndPort := getListenHostAndPort(unsafeListenHost, port)
+			var imdsOptions *ImdsOptions
+			if context.Bool("imds") {
+				imdsProfiles := context.StringSlice("imds-profile")
+				if len(imdsProfiles) == 0 {
+					defaultProfile, _, err := config.FindProfile("", "", true)
+					if err != nil {
+						return errors.Wrap(err, "find default profile for IMDS failed")
+					}
+					imdsProfiles = []string{defaultProfile}
+				}
+				imdsOptions = NewImdsOptions(imdsProfiles, context.Bool("unsafe-imds-allow-v1"))
+			}
+
+			memoryCache := NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+				return cloud.FetchCloudStsFromDefaultConfig("", profile, options)
+			}, getConfigVersion)
+			memoryCache.StartBackgroundRefresh(make(chan struct{}))
 			return serve(listenHostAndPort, &HttpServeOptions{
-				SsrfToken: ssrfToken,
+				SsrfToken:   ssrfToken,
+				MemoryCache: memoryCache,
+				Imds:        imdsOptions,
+				UnixSocket:  unixSocketOptions,
+				Policy:      servePolicy,
+
+				EnableProfilesEndpoint: context.Bool("enable-profiles-endpoint"),
 			})
 		},
 	}
@@ -80,7 +171,23 @@ func serve(listenHostAndPort string, serveOptions *HttpServeOptions) error {
 	http.HandleFunc("/cloud_token", func(w http.ResponseWriter, r *http.Request) {
 		handleCloudToken(w, r, serveOptions)
 	})
+	if serveOptions.EnableProfilesEndpoint {
+		http.HandleFunc("/profiles", func(w http.ResponseWriter, r *http.Request) {
+			handleProfiles(w, r, serveOptions)
+		})
+	}
+	if serveOptions.Imds != nil {
+		// protected by SSRF token or policy, and metadata token(IMDSv2)
+		http.HandleFunc("/latest/", func(w http.ResponseWriter, r *http.Request) {
+			handleImds(w, r, serveOptions)
+		})
+		fmt.Printf("IMDS emulation enabled, roles: %s\n", strings.Join(serveOptions.Imds.Profiles, ", "))
+	}
 
+	if serveOptions.UnixSocket != nil {
+		fmt.Printf("Listen at unix socket %s...", serveOptions.UnixSocket.Socket)
+		return listenAndServeUnixSocket(serveOptions.UnixSocket, http.DefaultServeMux)
+	}
 	fmt.Printf("Listen at %s...", listenHostAndPort)
 	return http.ListenAndServe(listenHostAndPort, nil)
 }
@@ -106,6 +213,22 @@ func handleVersion(w http.ResponseWriter, r *http.Request, serveOptions *HttpSer
 	})
 }
 
+// getConfigVersion changes when config file or profile.d files are modified
+func getConfigVersion() string {
+	configFilename, err := config.GetDefaultCloudCredentialConfigFile()
+	if err != nil {
+		return ""
+	}
+	profileFiles, _ := config.ListProfileDirFiles(configFilename)
+	var version strings.Builder
+	for _, filename := range append([]string{configFilename}, profileFiles...) {
+		if fileInfo, err := os.Stat(filename); err == nil {
+			version.WriteString(fmt.Sprintf("%s:%d:%d;", filename, fileInfo.ModTime().UnixNano(), fileInfo.Size()))
+		}
+	}
+	return version.String()
+}
+
 func getListenHostAndPort(unsafeListenHost string, port int) string {
 	var listenHostAndPort string
 	if unsafeListenHost == "" {
@@ -6,11 +6,20 @@ import (
 )
 
 const (
-	SSRF_TOKEN_HEADER = "X-Aliyun-Parameters-Secrets-Token"
+	SSRF_TOKEN_HEADER    = "X-Aliyun-Parameters-Secrets-Token"
+	AUTHORIZATION_HEADER = "Authorization"
+	CACHE_HEADER         = "X-Idaas-Cache" // HIT or MISS, for memory cache
+	AGE_HEADER           = "Age"           // seconds since token cached in memory
 )
 
 type HttpServeOptions struct {
-	SsrfToken string
+	SsrfToken   string
+	MemoryCache *MemoryCache
+	Imds        *ImdsOptions       // IMDS emulation is disabled when nil
+	UnixSocket  *UnixSocketOptions // listen at TCP when nil
+	Policy      *ServePolicy       // per profile access policy, nil when config not found
+	// list profiles via /profiles
+	EnableProfilesEndpoint bool
 }
 
 type ErrorResponse struct {
@@ -25,8 +34,8 @@ type VersionResponse struct {
 }
 
 func printResponse(w http.ResponseWriter, code int, response any) {
-	w.WriteHeader(code)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	responseJson, err := json.Marshal(response)
 	if err == nil {
 		_, _ = w.Write(responseJson)
@@ -34,24 +43,8 @@ func printResponse(w http.ResponseWriter, code int, response any) {
 }
 
 func isRequestAllowed(w http.ResponseWriter, r *http.Request, serveOptions *HttpServeOptions) bool {
-	if serveOptions.SsrfToken != "" {
-		ssrfTokenFromRequest := getSsrfToken(r)
-		if ssrfTokenFromRequest == "" {
-			printResponse(w, http.StatusUnauthorized, ErrorResponse{
-				Error:   "request_denied",
-				Message: SSRF_TOKEN_HEADER + " header or __ssrf_token parameter is required",
-			})
-			return false
-		}
-		if ssrfTokenFromRequest != serveOptions.SsrfToken {
-			printResponse(w, http.StatusForbidden, ErrorResponse{
-				Error:   "request_denied",
-				Message: "Invalid SSRF token",
-			})
-			return false
-		}
-	}
-	return true
+	_, ok := getRequestGrant(w, r, serveOptions)
+	return ok
 }
 
 func getSsrfToken(r *http.Request) string {
@@ -59,6 +52,11 @@ func getSsrfToken(r *http.Request) string {
 	if ssrfTokenFromHeader != "" {
 		return ssrfTokenFromHeader
 	}
+	// AWS container credential provider sends AWS_CONTAINER_AUTHORIZATION_TOKEN in Authorization header
+	authorizationFromHeader := r.Header.Get(AUTHORIZATION_HEADER)
+	if authorizationFromHeader != "" {
+		return authorizationFromHeader
+	}
 	query := r.URL.Query()
 	return query.Get("__ssrf_token")
 }
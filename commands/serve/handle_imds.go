@@ -0,0 +1,250 @@
+package serve
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_account"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/pkg/errors"
+)
+
+// IMDS(Instance Metadata Service) emulation, role name in metadata path is the profile name
+// Alibaba Cloud: http://100.100.100.200/latest/meta-data/ram/security-credentials/<role-name>
+// AWS: http://169.254.169.254/latest/meta-data/iam/security-credentials/<role-name>
+const (
+	IMDS_ALIBABA_CLOUD_TOKEN_HEADER     = "X-aliyun-ecs-metadata-token"
+	IMDS_ALIBABA_CLOUD_TOKEN_TTL_HEADER = "X-aliyun-ecs-metadata-token-ttl-seconds"
+	IMDS_AWS_TOKEN_HEADER               = "X-aws-ec2-metadata-token"
+	IMDS_AWS_TOKEN_TTL_HEADER           = "X-aws-ec2-metadata-token-ttl-seconds"
+
+	imdsTokenPath                     = "/latest/api/token"
+	imdsAlibabaCloudCredentialsPrefix = "/latest/meta-data/ram/security-credentials"
+	imdsAwsCredentialsPrefix          = "/latest/meta-data/iam/security-credentials"
+
+	imdsTokenMaxTtlSeconds = 21600
+)
+
+type ImdsOptions struct {
+	Profiles []string
+	// allow request without metadata token(IMDSv1), which is vulnerable to SSRF
+	AllowV1 bool
+
+	tokens *imdsTokenStore
+}
+
+func NewImdsOptions(profiles []string, allowV1 bool) *ImdsOptions {
+	return &ImdsOptions{
+		Profiles: profiles,
+		AllowV1:  allowV1,
+		tokens: &imdsTokenStore{
+			tokens: map[string]time.Time{},
+		},
+	}
+}
+
+type imdsTokenStore struct {
+	mutex  sync.Mutex
+	tokens map[string]time.Time
+}
+
+func (s *imdsTokenStore) issue(ttl time.Duration) (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", errors.Wrap(err, "generate metadata token failed")
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	for t, expireAt := range s.tokens {
+		if now.After(expireAt) {
+			delete(s.tokens, t)
+		}
+	}
+	s.tokens[token] = now.Add(ttl)
+	return token, nil
+}
+
+func (s *imdsTokenStore) isValid(token string) bool {
+	if token == "" {
+		return false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	expireAt, ok := s.tokens[token]
+	return ok && time.Now().Before(expireAt)
+}
+
+func handleImds(w http.ResponseWriter, r *http.Request, serveOptions *HttpServeOptions) {
+	// same SSRF token and policy check as other endpoints, metadata token is checked additionally
+	grant, ok := getRequestGrant(w, r, serveOptions)
+	if !ok {
+		return
+	}
+	imdsOptions := serveOptions.Imds
+	path := r.URL.Path
+	if path == imdsTokenPath {
+		handleImdsToken(w, r, imdsOptions)
+		return
+	}
+	if r.Method != http.MethodGet {
+		printTextResponse(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	if !isImdsRequestAllowed(r, imdsOptions) {
+		printTextResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if role, ok := parseImdsRole(path, imdsAlibabaCloudCredentialsPrefix); ok {
+		if role == "" {
+			printTextResponse(w, http.StatusOK, strings.Join(getImdsAllowedProfiles(grant, imdsOptions), "\n"))
+			return
+		}
+		handleImdsCredentials(w, role, grant, serveOptions, convertToAlibabaCloudEcsMetadata)
+		return
+	}
+	if role, ok := parseImdsRole(path, imdsAwsCredentialsPrefix); ok {
+		if role == "" {
+			printTextResponse(w, http.StatusOK, strings.Join(getImdsAllowedProfiles(grant, imdsOptions), "\n"))
+			return
+		}
+		handleImdsCredentials(w, role, grant, serveOptions, convertToAwsImdsCredentials)
+		return
+	}
+	printTextResponse(w, http.StatusNotFound, "Not Found")
+}
+
+func handleImdsToken(w http.ResponseWriter, r *http.Request, imdsOptions *ImdsOptions) {
+	if r.Method != http.MethodPut {
+		printTextResponse(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	// request forwarded by proxy is rejected, same as AWS IMDSv2
+	if r.Header.Get("X-Forwarded-For") != "" {
+		printTextResponse(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	ttlHeader := IMDS_ALIBABA_CLOUD_TOKEN_TTL_HEADER
+	ttlSecondsStr := r.Header.Get(IMDS_ALIBABA_CLOUD_TOKEN_TTL_HEADER)
+	if ttlSecondsStr == "" {
+		ttlHeader = IMDS_AWS_TOKEN_TTL_HEADER
+		ttlSecondsStr = r.Header.Get(IMDS_AWS_TOKEN_TTL_HEADER)
+	}
+	ttlSeconds, err := strconv.Atoi(ttlSecondsStr)
+	if err != nil || ttlSeconds < 1 || ttlSeconds > imdsTokenMaxTtlSeconds {
+		printTextResponse(w, http.StatusBadRequest, "Bad Request")
+		return
+	}
+	token, err := imdsOptions.tokens.issue(time.Duration(ttlSeconds) * time.Second)
+	if err != nil {
+		idaaslog.Error.PrintfLn("Issue metadata token failed: %v", err)
+		printTextResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	w.Header().Set(ttlHeader, ttlSecondsStr)
+	printTextResponse(w, http.StatusOK, token)
+}
+
+func handleImdsCredentials(w http.ResponseWriter, role string, grant *RequestGrant, serveOptions *HttpServeOptions,
+	convert func(sts any, lastUpdated time.Time) any) {
+	if !slices.Contains(serveOptions.Imds.Profiles, role) {
+		printTextResponse(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if !grant.IsProfileAllowed(role) {
+		idaaslog.Warn.PrintfLn("IMDS request denied, grant: %s, profile: %s", grant.Name, role)
+		printTextResponse(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	options := &cloud.FetchCloudStsOptions{
+		IgnoreParseFromProfile: true,
+	}
+	cacheResult, err := serveOptions.MemoryCache.Get(role, options)
+	if err != nil {
+		idaaslog.Error.PrintfLn("Fetch cloud sts token of profile: %s failed: %v", role, err)
+		printTextResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	credentials := convert(cacheResult.Sts, cacheResult.CacheTime)
+	if credentials == nil {
+		idaaslog.Warn.PrintfLn("Cloud sts token of profile: %s is not supported by metadata path", role)
+		printTextResponse(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	printResponse(w, http.StatusOK, credentials)
+}
+
+func convertToAlibabaCloudEcsMetadata(sts any, lastUpdated time.Time) any {
+	if alibabaCloudSts, ok := sts.(*alibaba_cloud.StsToken); ok {
+		return alibabaCloudSts.ConvertToEcsMetadata(lastUpdated)
+	}
+	if cloudAccountToken, ok := sts.(*cloud_account.CloudAccountToken); ok && cloudAccountToken.IsAlibabaCloudToken() {
+		alibabaCloudSts := cloud.ConvertCloudAccountTokenAlibabaCloudStsTokenToAlibabaStsToken(
+			cloudAccountToken.CloudAccountRoleAccessCredential.AlibabaCloudStsToken)
+		return alibabaCloudSts.ConvertToEcsMetadata(lastUpdated)
+	}
+	return nil
+}
+
+func convertToAwsImdsCredentials(sts any, lastUpdated time.Time) any {
+	if awsStsToken, ok := sts.(*aws.AwsStsToken); ok {
+		return awsStsToken.ConvertToImdsCredentials(lastUpdated)
+	}
+	return nil
+}
+
+// getImdsAllowedProfiles roles not allowed by request grant are not listed
+func getImdsAllowedProfiles(grant *RequestGrant, imdsOptions *ImdsOptions) []string {
+	var profiles []string
+	for _, profile := range imdsOptions.Profiles {
+		if grant.IsProfileAllowed(profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+func isImdsRequestAllowed(r *http.Request, imdsOptions *ImdsOptions) bool {
+	token := r.Header.Get(IMDS_ALIBABA_CLOUD_TOKEN_HEADER)
+	if token == "" {
+		token = r.Header.Get(IMDS_AWS_TOKEN_HEADER)
+	}
+	if token == "" {
+		return imdsOptions.AllowV1
+	}
+	return imdsOptions.tokens.isValid(token)
+}
+
+// parseImdsRole returns role name, empty role name means list roles
+func parseImdsRole(path, prefix string) (string, bool) {
+	if path == prefix || path == prefix+"/" {
+		return "", true
+	}
+	if !strings.HasPrefix(path, prefix+"/") {
+		return "", false
+	}
+	role := strings.TrimSuffix(strings.TrimPrefix(path, prefix+"/"), "/")
+	if role == "" || strings.Contains(role, "/") {
+		return "", false
+	}
+	return role, true
+}
+
+func printTextResponse(w http.ResponseWriter, code int, text string) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(code)
+	_, _ = fmt.Fprint(w, text)
+}
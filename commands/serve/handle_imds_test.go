@@ -0,0 +1,165 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+)
+
+func newTestImdsServeOptions() *HttpServeOptions {
+	return &HttpServeOptions{
+		MemoryCache: NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+			if profile == "aws1" {
+				return &aws.AwsStsToken{
+					AccessKeyId:     "ASIA-TEST",
+					SecretAccessKey: "secret",
+					SessionToken:    "token",
+					Expiration:      time.Now().Add(time.Hour),
+				}, nil, nil
+			}
+			return &alibaba_cloud.StsToken{
+				AccessKeyId:     "STS.TEST",
+				AccessKeySecret: "secret",
+				StsToken:        "token",
+				Expiration:      time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			}, nil, nil
+		}, nil),
+		Imds: NewImdsOptions([]string{"aliyun1", "aws1"}, false),
+	}
+}
+
+func doImdsRequest(serveOptions *HttpServeOptions, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	recorder := httptest.NewRecorder()
+	handleImds(recorder, request, serveOptions)
+	return recorder
+}
+
+func TestImdsAws(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	serveOptions := newTestImdsServeOptions()
+
+	recorder := doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/iam/security-credentials/aws1", nil)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("IMDSv1 request should be rejected, got: %d", recorder.Code)
+	}
+
+	recorder = doImdsRequest(serveOptions, http.MethodPut, "/latest/api/token",
+		map[string]string{IMDS_AWS_TOKEN_TTL_HEADER: "60"})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("fetch token failed: %d", recorder.Code)
+	}
+	token := recorder.Body.String()
+
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/iam/security-credentials/",
+		map[string]string{IMDS_AWS_TOKEN_HEADER: token})
+	if recorder.Body.String() != "aliyun1\naws1" {
+		t.Errorf("unexpected roles: %s", recorder.Body.String())
+	}
+
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/iam/security-credentials/aws1",
+		map[string]string{IMDS_AWS_TOKEN_HEADER: token})
+	var credentials aws.AwsStsTokenImdsCredentials
+	if err := json.Unmarshal(recorder.Body.Bytes(), &credentials); err != nil {
+		t.Fatal(err)
+	}
+	if credentials.Code != "Success" || credentials.AccessKeyId != "ASIA-TEST" || credentials.Token != "token" {
+		t.Errorf("unexpected credentials: %+v", credentials)
+	}
+
+	// the second request is served from memory cache, and MUST be audited
+	doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/iam/security-credentials/aws1",
+		map[string]string{IMDS_AWS_TOKEN_HEADER: token})
+	records, err := audit.ReadRecords(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Source != audit.SourceMemoryCache || records[0].Profile != "aws1" {
+		t.Errorf("unexpected audit records: %+v", records)
+	}
+
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/iam/security-credentials/aws2",
+		map[string]string{IMDS_AWS_TOKEN_HEADER: token})
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("unknown role should be not found, got: %d", recorder.Code)
+	}
+}
+
+func TestImdsAlibabaCloud(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	serveOptions := newTestImdsServeOptions()
+
+	recorder := doImdsRequest(serveOptions, http.MethodPut, "/latest/api/token",
+		map[string]string{IMDS_ALIBABA_CLOUD_TOKEN_TTL_HEADER: "60", "X-Forwarded-For": "1.1.1.1"})
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("forwarded token request should be rejected, got: %d", recorder.Code)
+	}
+
+	recorder = doImdsRequest(serveOptions, http.MethodPut, "/latest/api/token",
+		map[string]string{IMDS_ALIBABA_CLOUD_TOKEN_TTL_HEADER: "60"})
+	token := recorder.Body.String()
+
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/ram/security-credentials/aliyun1",
+		map[string]string{IMDS_ALIBABA_CLOUD_TOKEN_HEADER: token})
+	var credentials alibaba_cloud.StsTokenEcsMetadata
+	if err := json.Unmarshal(recorder.Body.Bytes(), &credentials); err != nil {
+		t.Fatal(err)
+	}
+	if credentials.Code != "Success" || credentials.AccessKeyId != "STS.TEST" || credentials.StsToken != "token" {
+		t.Errorf("unexpected credentials: %+v", credentials)
+	}
+
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/ram/security-credentials/aws1",
+		map[string]string{IMDS_ALIBABA_CLOUD_TOKEN_HEADER: token})
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("AWS token should not be served as Alibaba Cloud credentials, got: %d", recorder.Code)
+	}
+}
+
+func TestImdsSsrfAndPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	serveOptions := newTestImdsServeOptions()
+	serveOptions.SsrfToken = "master"
+	serveOptions.Policy = &ServePolicy{
+		Policies: []*config.ServePolicyConfig{
+			{Name: "workload1", SsrfToken: "token1", Profiles: []string{"aliyun1"}},
+		},
+	}
+
+	recorder := doImdsRequest(serveOptions, http.MethodPut, "/latest/api/token",
+		map[string]string{IMDS_AWS_TOKEN_TTL_HEADER: "60"})
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("token request without SSRF token should be rejected, got: %d", recorder.Code)
+	}
+
+	recorder = doImdsRequest(serveOptions, http.MethodPut, "/latest/api/token",
+		map[string]string{IMDS_AWS_TOKEN_TTL_HEADER: "60", SSRF_TOKEN_HEADER: "token1"})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("fetch token failed: %d", recorder.Code)
+	}
+	headers := map[string]string{IMDS_AWS_TOKEN_HEADER: recorder.Body.String(), SSRF_TOKEN_HEADER: "token1"}
+
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/iam/security-credentials/", headers)
+	if recorder.Body.String() != "aliyun1" {
+		t.Errorf("only allowed roles should be listed, got: %s", recorder.Body.String())
+	}
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/iam/security-credentials/aws1", headers)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("profile not allowed by policy should be rejected, got: %d", recorder.Code)
+	}
+	recorder = doImdsRequest(serveOptions, http.MethodGet, "/latest/meta-data/ram/security-credentials/aliyun1", headers)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("profile allowed by policy should be served, got: %d", recorder.Code)
+	}
+}
@@ -2,21 +2,28 @@ package serve
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
 
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_account"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
 )
 
 func handleCloudToken(w http.ResponseWriter, r *http.Request, serveOptions *HttpServeOptions) {
-	if !isRequestAllowed(w, r, serveOptions) {
+	grant, ok := getRequestGrant(w, r, serveOptions)
+	if !ok {
 		return
 	}
 	query := r.URL.Query()
 
-	// TODO memory cache
 	profile := query.Get("profile")
+	if !isProfileAllowed(w, grant, serveOptions, profile) {
+		return
+	}
 	forceNew := query.Get("force-new")
 	forceNewCloudToken := query.Get("force-new-cloud-token")
 
@@ -25,14 +32,22 @@ func handleCloudToken(w http.ResponseWriter, r *http.Request, serveOptions *Http
 		ForceNewCloudToken:     forceNewCloudToken == "true",
 		IgnoreParseFromProfile: true,
 	}
-	sts, _, err := cloud.FetchCloudStsFromDefaultConfig("", profile, options)
+	cacheResult, err := serveOptions.MemoryCache.Get(profile, options)
 	if err != nil {
+		idaaslog.Error.PrintfLn("Fetch cloud sts token of profile: %s failed: %v", profile, err)
 		printResponse(w, http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_error",
 			Message: "Fetch cloud sts token failed.",
 		})
 		return
 	}
+	sts := cacheResult.Sts
+	if cacheResult.CacheHit {
+		w.Header().Set(CACHE_HEADER, "HIT")
+	} else {
+		w.Header().Set(CACHE_HEADER, "MISS")
+	}
+	w.Header().Set(AGE_HEADER, strconv.FormatInt(int64(cacheResult.Age().Seconds()), 10))
 
 	alibabaCloudSts, ok := sts.(*alibaba_cloud.StsToken)
 	if ok {
@@ -47,21 +62,83 @@ func handleCloudToken(w http.ResponseWriter, r *http.Request, serveOptions *Http
 			printResponse(w, http.StatusOK, alibabaCloudSts)
 			return
 		}
-		// TODO handle other token
-	}
-
-	_, ok = sts.(*aws.AwsStsToken)
-	if ok {
 		printResponse(w, http.StatusNotImplemented, ErrorResponse{
 			Error:   "not_implemented",
-			Message: "AWS sts token sts not implemented.",
+			Message: "Cloud account token of vendor type " + cloudAccountToken.CloudAccountVendorType + " not implemented.",
 		})
 		return
 	}
 
+	awsStsToken, ok := sts.(*aws.AwsStsToken)
+	if ok {
+		printResponse(w, http.StatusOK, awsStsToken.ConvertToContainerCredentials())
+		return
+	}
+
 	printResponse(w, http.StatusInternalServerError, ErrorResponse{
 		Error:   "bad_request",
 		Message: "Unknown cloud sts token.",
 	})
 	return
 }
+
+type ProfilesResponse struct {
+	Profiles []*ProfileResponse `json:"profiles"`
+}
+
+type ProfileResponse struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func handleProfiles(w http.ResponseWriter, r *http.Request, serveOptions *HttpServeOptions) {
+	grant, ok := getRequestGrant(w, r, serveOptions)
+	if !ok {
+		return
+	}
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig("")
+	if err != nil {
+		idaaslog.Error.PrintfLn("Load config failed: %v", err)
+		printResponse(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Load config failed.",
+		})
+		return
+	}
+	var profiles []string
+	for profile := range cloudCredentialConfig.Profile {
+		if grant.IsProfileAllowed(profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Strings(profiles)
+	profilesResponse := &ProfilesResponse{
+		Profiles: []*ProfileResponse{},
+	}
+	for _, profile := range profiles {
+		cloudStsConfig := cloudCredentialConfig.Profile[profile]
+		if cloudStsConfig == nil {
+			continue
+		}
+		profilesResponse.Profiles = append(profilesResponse.Profiles, &ProfileResponse{
+			Name:    profile,
+			Type:    getProfileType(cloudStsConfig),
+			Comment: cloudStsConfig.Comment,
+		})
+	}
+	printResponse(w, http.StatusOK, profilesResponse)
+}
+
+func getProfileType(cloudStsConfig *config.CloudStsConfig) string {
+	if cloudStsConfig.AlibabaCloud != nil {
+		return "AlibabaCloud"
+	} else if cloudStsConfig.Aws != nil {
+		return "Aws"
+	} else if cloudStsConfig.OidcToken != nil {
+		return "OidcToken"
+	} else if cloudStsConfig.CloudAccount != nil {
+		return "CloudAccount"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,59 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+)
+
+func TestCloudTokenAwsContainerCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	expiration := time.Date(2030, 1, 2, 3, 4, 5, 0, time.FixedZone("UTC+8", 8*3600))
+	serveOptions := &HttpServeOptions{
+		SsrfToken: "token1",
+		MemoryCache: NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+			return &aws.AwsStsToken{
+				AccessKeyId:     "ASIA-TEST",
+				SecretAccessKey: "secret",
+				SessionToken:    "token",
+				Expiration:      expiration,
+			}, nil, nil
+		}, nil),
+	}
+	doRequest := func(authorization string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/cloud_token?profile=aws1", nil)
+		if authorization != "" {
+			// AWS SDK sends AWS_CONTAINER_AUTHORIZATION_TOKEN as is
+			request.Header.Set(AUTHORIZATION_HEADER, authorization)
+		}
+		recorder := httptest.NewRecorder()
+		handleCloudToken(recorder, request, serveOptions)
+		return recorder
+	}
+
+	if recorder := doRequest(""); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("request without authorization should be rejected, got: %d", recorder.Code)
+	}
+	if recorder := doRequest("token2"); recorder.Code != http.StatusForbidden {
+		t.Errorf("request with invalid authorization should be rejected, got: %d", recorder.Code)
+	}
+
+	recorder := doRequest("token1")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d, body: %s", recorder.Code, recorder.Body.String())
+	}
+	var credentials aws.AwsStsTokenContainerCredentials
+	if err := json.Unmarshal(recorder.Body.Bytes(), &credentials); err != nil {
+		t.Fatal(err)
+	}
+	if credentials.AccessKeyId != "ASIA-TEST" || credentials.SecretAccessKey != "secret" ||
+		credentials.Token != "token" || credentials.Expiration != "2030-01-01T19:04:05Z" {
+		t.Errorf("unexpected container credentials: %+v", credentials)
+	}
+}
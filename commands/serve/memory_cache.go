@@ -0,0 +1,237 @@
+package serve
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+)
+
+const (
+	memoryCacheRefreshInterval = 1 * time.Minute
+)
+
+type FetchStsFunc func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error)
+
+// ConfigVersionFunc returns version of config, cached tokens are invalidated when version changed
+type ConfigVersionFunc func() string
+
+type MemoryCacheResult struct {
+	Sts       any
+	CacheHit  bool
+	CacheTime time.Time
+}
+
+func (r *MemoryCacheResult) Age() time.Duration {
+	return time.Since(r.CacheTime)
+}
+
+type memoryCacheEntry struct {
+	Sts            any
+	CloudStsConfig *config.CloudStsConfig
+	CacheTime      time.Time
+	ConfigVersion  string
+}
+
+type memoryCacheCall struct {
+	wg    sync.WaitGroup
+	entry *memoryCacheEntry
+	err   error
+}
+
+// MemoryCache caches cloud STS tokens per profile in memory, concurrent fetches of the same
+// profile are deduplicated(single-flight), so only one upstream fetch is made,
+// cached tokens are invalidated when config changed, expired tokens are removed by background refresh
+type MemoryCache struct {
+	mutex         sync.Mutex
+	entries       map[string]*memoryCacheEntry
+	calls         map[string]*memoryCacheCall
+	fetchSts      FetchStsFunc
+	configVersion ConfigVersionFunc
+}
+
+// NewMemoryCache configVersion is optional, cached tokens are never invalidated by config change when nil
+func NewMemoryCache(fetchSts FetchStsFunc, configVersion ConfigVersionFunc) *MemoryCache {
+	return &MemoryCache{
+		entries:       map[string]*memoryCacheEntry{},
+		calls:         map[string]*memoryCacheCall{},
+		fetchSts:      fetchSts,
+		configVersion: configVersion,
+	}
+}
+
+func (c *MemoryCache) Get(profile string, options *cloud.FetchCloudStsOptions) (*MemoryCacheResult, error) {
+	if !options.ForceNew && !options.ForceNewCloudToken {
+		entry := c.getValidEntry(profile)
+		if entry != nil {
+			expiringOrExpired, expired := cloud.IsStsExpiringOrExpired(entry.Sts)
+			if !expired {
+				if expiringOrExpired {
+					idaaslog.Debug.PrintfLn("Memory cache of profile: %s is expiring, refresh in background", profile)
+					go c.refresh(profile)
+				}
+				auditMemoryCacheHit(profile, entry)
+				return &MemoryCacheResult{
+					Sts:       entry.Sts,
+					CacheHit:  true,
+					CacheTime: entry.CacheTime,
+				}, nil
+			}
+		}
+	}
+
+	entry, err := c.fetch(profile, options)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryCacheResult{
+		Sts:       entry.Sts,
+		CacheHit:  false,
+		CacheTime: entry.CacheTime,
+	}, nil
+}
+
+// Invalidate removes cached token of profile, all profiles when profile is empty
+func (c *MemoryCache) Invalidate(profile string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if profile == "" {
+		c.entries = map[string]*memoryCacheEntry{}
+	} else {
+		delete(c.entries, profile)
+	}
+}
+
+// getValidEntry returns nil when token is not cached or config changed after token cached
+func (c *MemoryCache) getValidEntry(profile string) *memoryCacheEntry {
+	c.mutex.Lock()
+	entry := c.entries[profile]
+	c.mutex.Unlock()
+	if entry == nil || entry.ConfigVersion == c.getConfigVersion() {
+		return entry
+	}
+	idaaslog.Info.PrintfLn("Config changed, invalidate memory cache of profile: %s", profile)
+	c.mutex.Lock()
+	if c.entries[profile] == entry {
+		delete(c.entries, profile)
+	}
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *MemoryCache) getConfigVersion() string {
+	if c.configVersion == nil {
+		return ""
+	}
+	return c.configVersion()
+}
+
+// StartBackgroundRefresh refreshes expiring tokens proactively, until stop is closed
+func (c *MemoryCache) StartBackgroundRefresh(stop <-chan struct{}) {
+	ticker := time.NewTicker(memoryCacheRefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for _, profile := range c.expiringProfiles() {
+					c.refresh(profile)
+				}
+			}
+		}
+	}()
+}
+
+// expiringProfiles returns profiles to refresh, entries of old config version or expired are removed,
+// so removed profiles are not refreshed forever
+func (c *MemoryCache) expiringProfiles() []string {
+	configVersion := c.getConfigVersion()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var profiles []string
+	for profile, entry := range c.entries {
+		expiringOrExpired, expired := cloud.IsStsExpiringOrExpired(entry.Sts)
+		if entry.ConfigVersion != configVersion || expired {
+			delete(c.entries, profile)
+			continue
+		}
+		if expiringOrExpired {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+func (c *MemoryCache) refresh(profile string) {
+	_, err := c.fetch(profile, &cloud.FetchCloudStsOptions{
+		IgnoreParseFromProfile: true,
+	})
+	if err != nil {
+		idaaslog.Warn.PrintfLn("Refresh memory cache of profile: %s failed: %v", profile, err)
+	}
+}
+
+func (c *MemoryCache) fetch(profile string, options *cloud.FetchCloudStsOptions) (*memoryCacheEntry, error) {
+	callKey := fmt.Sprintf("%s|%t|%t", profile, options.ForceNew, options.ForceNewCloudToken)
+	c.mutex.Lock()
+	if call, ok := c.calls[callKey]; ok {
+		c.mutex.Unlock()
+		idaaslog.Debug.PrintfLn("Wait for in-flight fetch of profile: %s", profile)
+		call.wg.Wait()
+		return call.entry, call.err
+	}
+	call := &memoryCacheCall{}
+	call.wg.Add(1)
+	c.calls[callKey] = call
+	c.mutex.Unlock()
+
+	configVersion := c.getConfigVersion()
+	sts, cloudStsConfig, err := c.fetchSts(profile, options)
+	if err == nil {
+		call.entry = &memoryCacheEntry{
+			Sts:            sts,
+			CloudStsConfig: cloudStsConfig,
+			CacheTime:      time.Now(),
+			ConfigVersion:  configVersion,
+		}
+	}
+	call.err = err
+
+	c.mutex.Lock()
+	if call.entry != nil {
+		c.entries[profile] = call.entry
+	}
+	delete(c.calls, callKey)
+	c.mutex.Unlock()
+	call.wg.Done()
+
+	return call.entry, call.err
+}
+
+// auditMemoryCacheHit fetched token is audited by fetchers, token served from memory cache is audited here
+func auditMemoryCacheHit(profile string, entry *memoryCacheEntry) {
+	auditRecord := &audit.Record{
+		Profile:   profile,
+		CloudType: cloud.GetStsType(entry.Sts),
+		Source:    audit.SourceMemoryCache,
+	}
+	if cloudStsConfig := entry.CloudStsConfig; cloudStsConfig != nil {
+		if cloudStsConfig.AlibabaCloud != nil {
+			auditRecord.RoleArn = cloudStsConfig.AlibabaCloud.RoleArn
+		} else if cloudStsConfig.Aws != nil {
+			auditRecord.RoleArn = cloudStsConfig.Aws.RoleArn
+		} else if cloudStsConfig.CloudAccount != nil {
+			auditRecord.CloudAccountRoleExternalId = cloudStsConfig.CloudAccount.CloudAccountRoleExternalId
+		}
+	}
+	if expiration, ok := cloud.GetStsExpiration(entry.Sts); ok {
+		auditRecord.SetExpiration(expiration)
+	}
+	audit.Log(auditRecord)
+}
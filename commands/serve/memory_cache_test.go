@@ -0,0 +1,169 @@
+package serve
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+)
+
+func TestMemoryCacheSingleFlight(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var fetchCount int32
+	memoryCache := NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+		atomic.AddInt32(&fetchCount, 1)
+		time.Sleep(50 * time.Millisecond)
+		return &aws.AwsStsToken{Expiration: time.Now().Add(time.Hour)}, nil, nil
+	}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := memoryCache.Get("aws1", &cloud.FetchCloudStsOptions{})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if fetchCount != 1 {
+		t.Errorf("expected 1 fetch, got: %d", fetchCount)
+	}
+
+	result, err := memoryCache.Get("aws1", &cloud.FetchCloudStsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.CacheHit {
+		t.Error("expected cache hit")
+	}
+	if fetchCount != 1 {
+		t.Errorf("expected 1 fetch, got: %d", fetchCount)
+	}
+
+	result, err = memoryCache.Get("aws1", &cloud.FetchCloudStsOptions{ForceNew: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CacheHit || fetchCount != 2 {
+		t.Errorf("force new should not hit cache, fetch count: %d", fetchCount)
+	}
+}
+
+func TestMemoryCacheExpired(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var fetchCount int32
+	memoryCache := NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+		atomic.AddInt32(&fetchCount, 1)
+		return &aws.AwsStsToken{Expiration: time.Now().Add(time.Minute)}, nil, nil
+	}, nil)
+	for i := 0; i < 2; i++ {
+		result, err := memoryCache.Get("aws1", &cloud.FetchCloudStsOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.CacheHit {
+			t.Error("expired token should not hit cache")
+		}
+	}
+	if fetchCount != 2 {
+		t.Errorf("expected 2 fetches, got: %d", fetchCount)
+	}
+}
+
+func TestMemoryCacheInvalidate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var fetchCount int32
+	configVersion := "v1"
+	memoryCache := NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+		atomic.AddInt32(&fetchCount, 1)
+		return &aws.AwsStsToken{Expiration: time.Now().Add(time.Hour)}, nil, nil
+	}, func() string {
+		return configVersion
+	})
+	get := func() *MemoryCacheResult {
+		result, err := memoryCache.Get("aws1", &cloud.FetchCloudStsOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	get()
+	if !get().CacheHit {
+		t.Error("expected cache hit")
+	}
+	configVersion = "v2"
+	if get().CacheHit {
+		t.Error("config changed should not hit cache")
+	}
+	if !get().CacheHit {
+		t.Error("expected cache hit after config changed")
+	}
+	memoryCache.Invalidate("aws1")
+	if get().CacheHit {
+		t.Error("invalidated profile should not hit cache")
+	}
+	if fetchCount != 3 {
+		t.Errorf("expected 3 fetches, got: %d", fetchCount)
+	}
+
+	configVersion = "v3"
+	if profiles := memoryCache.expiringProfiles(); len(profiles) != 0 || len(memoryCache.entries) != 0 {
+		t.Errorf("entries of old config should be removed, profiles: %v, entries: %d", profiles, len(memoryCache.entries))
+	}
+}
+
+func TestMemoryCacheHitAudit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	memoryCache := NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+		return &aws.AwsStsToken{Expiration: time.Now().Add(time.Hour)},
+			&config.CloudStsConfig{Aws: &config.AwsCloudStsConfig{RoleArn: "arn:aws:iam::123456789012:role/role1"}}, nil
+	}, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := memoryCache.Get("aws1", &cloud.FetchCloudStsOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := audit.ReadRecords(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fetched token is audited by fetcher, only cache hit is audited by memory cache
+	if len(records) != 1 {
+		t.Fatalf("expected 1 audit record, got: %d", len(records))
+	}
+	record := records[0]
+	if record.Source != audit.SourceMemoryCache || record.Profile != "aws1" || record.CloudType != audit.CloudTypeAws ||
+		record.RoleArn != "arn:aws:iam::123456789012:role/role1" || record.Expiration == "" {
+		t.Errorf("unexpected audit record: %+v", record)
+	}
+}
+
+func TestMemoryCacheHitAuditCloudAccount(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	memoryCache := NewMemoryCache(func(profile string, options *cloud.FetchCloudStsOptions) (any, *config.CloudStsConfig, error) {
+		return &alibaba_cloud.StsToken{Expiration: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)},
+			&config.CloudStsConfig{CloudAccount: &config.CloudAccountTokenConfig{CloudAccountRoleExternalId: "external-id-1"}}, nil
+	}, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := memoryCache.Get("account1", &cloud.FetchCloudStsOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := audit.ReadRecords(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].CloudAccountRoleExternalId != "external-id-1" || records[0].RoleArn != "" {
+		t.Errorf("unexpected audit records: %+v", records)
+	}
+}
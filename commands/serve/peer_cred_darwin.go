@@ -0,0 +1,37 @@
+//go:build darwin
+// +build darwin
+
+package serve
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const peerCredSupported = true
+
+// getPeerUid returns UID of unix socket peer via LOCAL_PEERCRED
+func getPeerUid(conn net.Conn) (int, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return -1, errors.New("not unix socket connection")
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return -1, errors.Wrap(err, "get raw connection failed")
+	}
+	var xucred *unix.Xucred
+	var xucredErr error
+	err = rawConn.Control(func(fd uintptr) {
+		xucred, xucredErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	})
+	if err != nil {
+		return -1, errors.Wrap(err, "control raw connection failed")
+	}
+	if xucredErr != nil {
+		return -1, errors.Wrap(xucredErr, "get LOCAL_PEERCRED failed")
+	}
+	return int(xucred.Uid), nil
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package serve
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const peerCredSupported = true
+
+// getPeerUid returns UID of unix socket peer via SO_PEERCRED
+func getPeerUid(conn net.Conn) (int, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return -1, errors.New("not unix socket connection")
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return -1, errors.Wrap(err, "get raw connection failed")
+	}
+	var ucred *unix.Ucred
+	var ucredErr error
+	err = rawConn.Control(func(fd uintptr) {
+		ucred, ucredErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return -1, errors.Wrap(err, "control raw connection failed")
+	}
+	if ucredErr != nil {
+		return -1, errors.Wrap(ucredErr, "get SO_PEERCRED failed")
+	}
+	return int(ucred.Uid), nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package serve
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+const peerCredSupported = false
+
+func getPeerUid(conn net.Conn) (int, error) {
+	return -1, errors.New("unix socket peer credential is not supported on this platform")
+}
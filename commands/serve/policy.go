@@ -0,0 +1,154 @@
+package serve
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	allProfiles = "*"
+)
+
+type ServePolicy struct {
+	DefaultProfile string
+	Policies       []*config.ServePolicyConfig
+}
+
+// RequestGrant is the access granted to a request, by SSRF token from command line or a policy
+type RequestGrant struct {
+	Name     string
+	All      bool
+	Profiles []string
+}
+
+func NewServePolicy(cloudCredentialConfig *config.CloudCredentialConfig) (*ServePolicy, error) {
+	defaultProfile, _ := cloudCredentialConfig.FindProfile("")
+	servePolicy := &ServePolicy{
+		DefaultProfile: defaultProfile,
+	}
+	if cloudCredentialConfig.Serve == nil {
+		return servePolicy, nil
+	}
+	for i, policy := range cloudCredentialConfig.Serve.Policies {
+		if policy == nil {
+			continue
+		}
+		if policy.SsrfToken == "" && len(policy.Uids) == 0 {
+			return nil, errors.Errorf("serve policy #%d %s: ssrf_token or uids is required", i, policy.Name)
+		}
+		if len(policy.Profiles) == 0 {
+			return nil, errors.Errorf("serve policy #%d %s: profiles is required", i, policy.Name)
+		}
+		if policy.SsrfToken != "" {
+			ssrfToken, err := utils.ResolveSecret(policy.SsrfToken)
+			if err != nil {
+				return nil, errors.Wrapf(err, "serve policy #%d %s: resolve ssrf_token failed", i, policy.Name)
+			}
+			if ssrfToken == "" {
+				return nil, errors.Errorf("serve policy #%d %s: ssrf_token is empty", i, policy.Name)
+			}
+			// resolved SSRF token is kept in serve policy only, config is not changed
+			resolvedPolicy := *policy
+			resolvedPolicy.SsrfToken = ssrfToken
+			policy = &resolvedPolicy
+		}
+		servePolicy.Policies = append(servePolicy.Policies, policy)
+	}
+	return servePolicy, nil
+}
+
+func (p *ServePolicy) hasPolicies() bool {
+	return p != nil && len(p.Policies) > 0
+}
+
+// getUids returns UIDs in all policies, they are allowed to connect unix socket
+func (p *ServePolicy) getUids() []int {
+	if p == nil {
+		return nil
+	}
+	var uids []int
+	for _, policy := range p.Policies {
+		for _, uid := range policy.Uids {
+			if !slices.Contains(uids, uid) {
+				uids = append(uids, uid)
+			}
+		}
+	}
+	return uids
+}
+
+func (p *ServePolicy) match(r *http.Request, ssrfToken string) *config.ServePolicyConfig {
+	if p == nil {
+		return nil
+	}
+	if ssrfToken != "" {
+		for _, policy := range p.Policies {
+			if policy.SsrfToken != "" && isSsrfTokenEqual(policy.SsrfToken, ssrfToken) {
+				return policy
+			}
+		}
+	}
+	if uid, ok := r.Context().Value(peerUidContextKey{}).(int); ok {
+		for _, policy := range p.Policies {
+			if slices.Contains(policy.Uids, uid) {
+				return policy
+			}
+		}
+	}
+	return nil
+}
+
+func (g *RequestGrant) IsProfileAllowed(profile string) bool {
+	return g.All || slices.Contains(g.Profiles, allProfiles) || slices.Contains(g.Profiles, profile)
+}
+
+func getRequestGrant(w http.ResponseWriter, r *http.Request, serveOptions *HttpServeOptions) (*RequestGrant, bool) {
+	ssrfTokenFromRequest := getSsrfToken(r)
+	if serveOptions.SsrfToken != "" && isSsrfTokenEqual(serveOptions.SsrfToken, ssrfTokenFromRequest) {
+		return &RequestGrant{Name: "ssrf-token", All: true}, true
+	}
+	if policy := serveOptions.Policy.match(r, ssrfTokenFromRequest); policy != nil {
+		return &RequestGrant{Name: policy.Name, Profiles: policy.Profiles}, true
+	}
+	if serveOptions.SsrfToken == "" && !serveOptions.Policy.hasPolicies() {
+		return &RequestGrant{Name: "anonymous", All: true}, true
+	}
+	if ssrfTokenFromRequest == "" {
+		printResponse(w, http.StatusUnauthorized, ErrorResponse{
+			Error:   "request_denied",
+			Message: SSRF_TOKEN_HEADER + " header, " + AUTHORIZATION_HEADER + " header or __ssrf_token parameter is required",
+		})
+		return nil, false
+	}
+	printResponse(w, http.StatusForbidden, ErrorResponse{
+		Error:   "request_denied",
+		Message: "Invalid SSRF token",
+	})
+	return nil, false
+}
+
+// isSsrfTokenEqual compares in constant time, so SSRF token cannot be guessed by response time
+func isSsrfTokenEqual(expected, actual string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
+
+func isProfileAllowed(w http.ResponseWriter, grant *RequestGrant, serveOptions *HttpServeOptions, profile string) bool {
+	if profile == "" && serveOptions.Policy != nil {
+		profile = serveOptions.Policy.DefaultProfile
+	}
+	if grant.IsProfileAllowed(profile) {
+		return true
+	}
+	idaaslog.Warn.PrintfLn("Serve request denied, grant: %s, profile: %s", grant.Name, profile)
+	printResponse(w, http.StatusForbidden, ErrorResponse{
+		Error:   "access_denied",
+		Message: "Profile " + profile + " is not allowed.",
+	})
+	return false
+}
@@ -0,0 +1,106 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+)
+
+func TestServePolicy(t *testing.T) {
+	t.Setenv("TEST_SERVE_SSRF_TOKEN", "token3")
+	servePolicy, err := NewServePolicy(&config.CloudCredentialConfig{
+		CurrentProfile: "aliyun1",
+		Serve: &config.ServeConfig{
+			Policies: []*config.ServePolicyConfig{
+				{Name: "workload1", SsrfToken: "token1", Profiles: []string{"aliyun1"}},
+				{Name: "workload2", Uids: []int{1001}, Profiles: []string{"*"}},
+				{Name: "workload3", SsrfToken: "env:TEST_SERVE_SSRF_TOKEN", Profiles: []string{"aws1"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveOptions := &HttpServeOptions{
+		SsrfToken: "master",
+		Policy:    servePolicy,
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		uid      int
+		profile  string
+		expected int
+	}{
+		{name: "master token", token: "master", profile: "aws1", expected: http.StatusOK},
+		{name: "policy token", token: "token1", profile: "aliyun1", expected: http.StatusOK},
+		{name: "policy token default profile", token: "token1", profile: "", expected: http.StatusOK},
+		{name: "policy token profile denied", token: "token1", profile: "aws1", expected: http.StatusForbidden},
+		{name: "policy uid", uid: 1001, profile: "aws1", expected: http.StatusOK},
+		{name: "unknown uid", uid: 1002, profile: "aws1", expected: http.StatusUnauthorized},
+		{name: "invalid token", token: "token2", profile: "aliyun1", expected: http.StatusForbidden},
+		{name: "policy token from env", token: "token3", profile: "aws1", expected: http.StatusOK},
+		{name: "policy token reference", token: "env:TEST_SERVE_SSRF_TOKEN", profile: "aws1", expected: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/cloud_token", nil)
+			if tt.token != "" {
+				request.Header.Set(SSRF_TOKEN_HEADER, tt.token)
+			}
+			if tt.uid > 0 {
+				request = request.WithContext(context.WithValue(request.Context(), peerUidContextKey{}, tt.uid))
+			}
+			recorder := httptest.NewRecorder()
+			code := http.StatusOK
+			grant, ok := getRequestGrant(recorder, request, serveOptions)
+			if !ok || !isProfileAllowed(recorder, grant, serveOptions, tt.profile) {
+				code = recorder.Code
+			}
+			if code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestServePolicyUids(t *testing.T) {
+	servePolicy, err := NewServePolicy(&config.CloudCredentialConfig{
+		Serve: &config.ServeConfig{
+			Policies: []*config.ServePolicyConfig{
+				{Name: "workload1", Uids: []int{1001, 1002}, Profiles: []string{"aliyun1"}},
+				{Name: "workload2", SsrfToken: "token2", Profiles: []string{"aws1"}},
+				{Name: "workload3", Uids: []int{1002, 1003}, Profiles: []string{"aws1"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uids := servePolicy.getUids(); !slices.Equal(uids, []int{1001, 1002, 1003}) {
+		t.Errorf("unexpected policy UIDs: %v", uids)
+	}
+	var nilServePolicy *ServePolicy
+	if uids := nilServePolicy.getUids(); len(uids) != 0 {
+		t.Errorf("nil policy should have no UIDs: %v", uids)
+	}
+}
+
+func TestServePolicySsrfTokenRef(t *testing.T) {
+	ssrfTokenRef := "env:TEST_SERVE_SSRF_TOKEN_NOT_SET"
+	_, err := NewServePolicy(&config.CloudCredentialConfig{
+		Serve: &config.ServeConfig{
+			Policies: []*config.ServePolicyConfig{
+				{Name: "workload1", SsrfToken: ssrfTokenRef, Profiles: []string{"aliyun1"}},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("unresolved ssrf_token should fail")
+	}
+}
@@ -0,0 +1,122 @@
+package serve
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+type peerUidContextKey struct{}
+
+type UnixSocketOptions struct {
+	Socket string
+	// owner UID is always allowed
+	AllowedUids []int
+	// socket group, allowed UIDs other than owner must be members of it, default primary group of owner
+	Group string
+}
+
+func (o *UnixSocketOptions) isUidAllowed(uid int) bool {
+	return uid == os.Getuid() || slices.Contains(o.AllowedUids, uid)
+}
+
+func listenAndServeUnixSocket(unixSocketOptions *UnixSocketOptions, handler http.Handler) error {
+	if !peerCredSupported {
+		return errors.New("unix socket with peer credential check is not supported on this platform")
+	}
+	socket := unixSocketOptions.Socket
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return errors.Wrapf(err, "create socket dir failed")
+	}
+	if err := utils.RemoveStaleUnixSocket(socket); err != nil {
+		if errors.Is(err, utils.ErrUnixSocketInUse) {
+			return errors.Errorf("unix socket: %s is in use", socket)
+		}
+		return err
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return errors.Wrapf(err, "listen unix socket: %s failed", socket)
+	}
+	defer func() {
+		_ = os.Remove(socket)
+	}()
+	// group members can connect only when UIDs are allowed, peer UID is always checked
+	socketPerm := os.FileMode(0600)
+	if len(unixSocketOptions.AllowedUids) > 0 {
+		socketPerm = 0660
+	}
+	if err = os.Chmod(socket, socketPerm); err != nil {
+		_ = listener.Close()
+		return errors.Wrapf(err, "chmod unix socket: %s failed", socket)
+	}
+	if unixSocketOptions.Group != "" {
+		if err = chownSocketGroup(socket, unixSocketOptions.Group); err != nil {
+			_ = listener.Close()
+			return err
+		}
+	}
+
+	server := &http.Server{
+		Handler:           peerCredHandler(unixSocketOptions, handler),
+		ReadHeaderTimeout: 10 * time.Second,
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			uid, err := getPeerUid(conn)
+			if err != nil {
+				idaaslog.Warn.PrintfLn("Get unix socket peer UID failed: %v", err)
+				return ctx
+			}
+			return context.WithValue(ctx, peerUidContextKey{}, uid)
+		},
+	}
+	return server.Serve(listener)
+}
+
+func chownSocketGroup(socket, group string) error {
+	gid, err := strconv.Atoi(group)
+	if err != nil {
+		socketGroup, lookupErr := user.LookupGroup(group)
+		if lookupErr != nil {
+			return errors.Wrapf(lookupErr, "lookup unix socket group: %s failed", group)
+		}
+		if gid, err = strconv.Atoi(socketGroup.Gid); err != nil {
+			return errors.Wrapf(err, "invalid gid of group: %s", group)
+		}
+	}
+	if err = os.Chown(socket, -1, gid); err != nil {
+		return errors.Wrapf(err, "chown unix socket: %s to group: %s failed", socket, group)
+	}
+	return nil
+}
+
+func peerCredHandler(unixSocketOptions *UnixSocketOptions, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, ok := r.Context().Value(peerUidContextKey{}).(int)
+		if !ok {
+			printResponse(w, http.StatusForbidden, ErrorResponse{
+				Error:   "request_denied",
+				Message: "Unknown peer credential",
+			})
+			return
+		}
+		if !unixSocketOptions.isUidAllowed(uid) {
+			idaaslog.Warn.PrintfLn("Unix socket request denied, peer UID: %d", uid)
+			printResponse(w, http.StatusForbidden, ErrorResponse{
+				Error:   "request_denied",
+				Message: "Peer UID is not allowed",
+			})
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
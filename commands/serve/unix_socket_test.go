@@ -0,0 +1,53 @@
+package serve
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListenAndServeUnixSocket(t *testing.T) {
+	if !peerCredSupported {
+		t.Skip("peer credential is not supported")
+	}
+	socket := filepath.Join(t.TempDir(), "serve.sock")
+	unixSocketOptions := &UnixSocketOptions{Socket: socket, AllowedUids: []int{os.Getuid() + 1}}
+	go func() {
+		_ = listenAndServeUnixSocket(unixSocketOptions, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}()
+
+	var fileInfo os.FileInfo
+	var err error
+	for i := 0; i < 100; i++ {
+		if fileInfo, err = os.Stat(socket); err == nil && fileInfo.Mode().Perm() == 0660 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || fileInfo.Mode().Perm() != 0660 {
+		t.Fatalf("socket permission should be 0660 when UIDs are allowed, error: %v", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	response, err := client.Get("http://localhost/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		t.Errorf("owner should be allowed, got: %d", response.StatusCode)
+	}
+}
@@ -0,0 +1,143 @@
+package show_audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	stringSliceFlagProfile = &cli.StringSliceFlag{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Usage:   "IDaaS Profile, can be assigned multiple times (default all profiles)",
+	}
+	stringFlagSince = &cli.StringFlag{
+		Name:  "since",
+		Usage: "Show records since time, RFC3339 time or duration before now, e.g. 2025-01-01T00:00:00Z, 24h",
+	}
+	stringFlagUntil = &cli.StringFlag{
+		Name:  "until",
+		Usage: "Show records until time, RFC3339 time or duration before now",
+	}
+	intFlagLimit = &cli.IntFlag{
+		Name:    "limit",
+		Aliases: []string{"n"},
+		Usage:   "Show latest N records only",
+	}
+	boolFlagJson = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Output records in JSON lines format",
+	}
+	boolFlagNoColor = &cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Output without color",
+	}
+)
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringSliceFlagProfile,
+		stringFlagSince,
+		stringFlagUntil,
+		intFlagLimit,
+		boolFlagJson,
+		boolFlagNoColor,
+	}
+	return &cli.Command{
+		Name:  "show-audit",
+		Usage: "Show audit records of credential issuance",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			now := time.Now()
+			since, err := parseTime(context.String("since"), now)
+			if err != nil {
+				return errors.Wrap(err, "invalid --since")
+			}
+			until, err := parseTime(context.String("until"), now)
+			if err != nil {
+				return errors.Wrap(err, "invalid --until")
+			}
+			filter := &audit.Filter{
+				Profiles: context.StringSlice("profile"),
+				Since:    since,
+				Until:    until,
+			}
+			records, err := audit.ReadRecords(filter)
+			if err != nil {
+				return err
+			}
+			limit := context.Int("limit")
+			if limit > 0 && len(records) > limit {
+				records = records[len(records)-limit:]
+			}
+			if context.Bool("json") {
+				return printRecordsJson(records)
+			}
+			showRecords(records, !context.Bool("no-color"))
+			return nil
+		},
+	}
+}
+
+// parseTime parses RFC3339 time, or duration before now
+func parseTime(str string, now time.Time) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s is neither RFC3339 time nor duration", str)
+	}
+	return now.Add(-duration), nil
+}
+
+func printRecordsJson(records []*audit.Record) error {
+	for _, record := range records {
+		recordJson, err := json.Marshal(record)
+		if err != nil {
+			return errors.Wrap(err, "marshal audit record failed")
+		}
+		utils.Stdout.Println(string(recordJson))
+	}
+	return nil
+}
+
+func showRecords(records []*audit.Record, color bool) {
+	if len(records) == 0 {
+		utils.Stdout.Fprintf("No audit records found\n")
+		return
+	}
+	for _, record := range records {
+		var source string
+		if record.Source == audit.SourceFetch {
+			source = utils.Yellow(record.Source, color)
+		} else {
+			source = utils.Green(record.Source, color)
+		}
+		utils.Stdout.Fprintf("%s %s [%s] %s, command: %s, pid: %d\n",
+			record.Time, record.Profile, record.CloudType, source, record.Command, record.Pid)
+		if record.RoleArn != "" {
+			utils.Stdout.Fprintf("   Role        : %s\n", record.RoleArn)
+		}
+		if record.CloudAccountRoleExternalId != "" {
+			utils.Stdout.Fprintf("   Role ext ID : %s\n", record.CloudAccountRoleExternalId)
+		}
+		if record.RoleSessionName != "" {
+			utils.Stdout.Fprintf("   Session name: %s\n", record.RoleSessionName)
+		}
+		if record.OidcSubject != "" || record.OidcJti != "" {
+			utils.Stdout.Fprintf("   OIDC        : sub=%s, jti=%s\n", record.OidcSubject, record.OidcJti)
+		}
+		if record.Expiration != "" {
+			utils.Stdout.Fprintf("   Expiration  : %s\n", record.Expiration)
+		}
+	}
+}
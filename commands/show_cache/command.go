@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
 	"github.com/pkg/errors"
@@ -36,6 +37,7 @@ func BuildCommand() *cli.Command {
 		Action: func(context *cli.Context) error {
 			category := context.String("category")
 			name := context.String("name")
+			config.ApplyDefaultConfigCacheBackend()
 			return showCache(category, name)
 		},
 	}
@@ -56,6 +58,10 @@ func showCache(category, name string) error {
 	}
 
 	if name == "" {
+		if cacheBackend := utils.GetCacheBackend(); cacheBackend != utils.CacheBackendFile {
+			utils.Stderr.Fprintf("List cache is not supported by cache backend: %s\n", cacheBackend)
+			return nil
+		}
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return errors.Wrap(err, "failed to get user home dir")
@@ -68,7 +74,7 @@ func showCache(category, name string) error {
 		}
 		var files []string
 		for _, file := range logFiles {
-			if !file.IsDir() {
+			if !file.IsDir() && !strings.HasPrefix(file.Name(), ".") {
 				files = append(files, "- "+file.Name())
 			}
 		}
@@ -80,9 +86,9 @@ func showCache(category, name string) error {
 		return nil
 	}
 
-	data, err := utils.ReadCacheFileWithEncryption(category, name)
+	data, err := utils.ReadCache(category, name)
 	if err != nil {
-		utils.Stderr.Fprintf("Read cache file [%s, %s] with encryption failed: %v\n", category, name, err)
+		utils.Stderr.Fprintf("Read cache [%s, %s] failed: %v\n", category, name, err)
 		return nil
 	}
 	utils.Stdout.Fprintf("%s\n", data)
@@ -69,7 +69,14 @@ func showProfiles(configFilename, profileFilter string, color bool) error {
 		if profile.Comment != "" {
 			comment = fmt.Sprintf(" , with comment: %s", utils.Under(profile.Comment, color))
 		}
-		fmt.Printf("Profile: %s%s\n", utils.Bold(utils.Blue(utils.Under(name, color), color), color), comment)
+		extends := ""
+		if profile.Extends != "" {
+			extends = fmt.Sprintf(" , extends: %s", utils.Blue(profile.Extends, color))
+		}
+		fmt.Printf("Profile: %s%s%s\n", utils.Bold(utils.Blue(utils.Under(name, color), color), color), extends, comment)
+		if source := cloudCredentialConfig.ProfileSources[name]; source != "" {
+			fmt.Printf(" %s: %s\n", pad("Source"), utils.Green(source, color))
+		}
 
 		showAlibabaCloud(color, profile)
 		showAws(color, profile)
@@ -136,6 +143,9 @@ func showOidcTokenProvider(color bool, oidcTokenProvider *config.OidcTokenProvid
 		deviceCode := oidcTokenProvider.OidcTokenProviderDeviceCode
 		showDeviceCode(color, deviceCode)
 
+		authorizationCode := oidcTokenProvider.OidcTokenProviderAuthorizationCode
+		showAuthorizationCode(color, authorizationCode)
+
 		clientCredentials := oidcTokenProvider.OidcTokenProviderClientCredentials
 		showClientCredentials(color, clientCredentials)
 
@@ -184,7 +194,7 @@ func showClientCredentials(color bool, clientCredentials *config.OidcTokenProvid
 		fmt.Printf(" - %s: %s\n", pad2("TokenEndpoint"), utils.Green(clientCredentials.TokenEndpoint, color))
 		fmt.Printf(" - %s: %s\n", pad2("ClientId"), utils.Green(clientCredentials.ClientId, color))
 		if clientCredentials.ClientSecret != "" {
-			fmt.Printf(" - %s: %s\n", pad2("ClientSecret"), utils.Green("******", color))
+			fmt.Printf(" - %s: %s\n", pad2("ClientSecret"), utils.Green(maskSecret(clientCredentials.ClientSecret), color))
 		}
 		fmt.Printf(" - %s: %s\n", pad2("Scope"), utils.Green(clientCredentials.Scope, color))
 		clientAssertionSinger := clientCredentials.ClientAssertionSinger
@@ -217,7 +227,7 @@ func showOpenApi(color bool, openCpiConfig *config.OpenApiConfig) {
 			fmt.Printf(" - %s: %s\n", pad2("AccessKeyId"), utils.Green(openCpiConfig.AccessKeyId, color))
 		}
 		if openCpiConfig.AccessKeySecret != "" {
-			fmt.Printf(" - %s: %s\n", pad2("AccessKeySecret"), utils.Green("******", color))
+			fmt.Printf(" - %s: %s\n", pad2("AccessKeySecret"), utils.Green(maskSecret(openCpiConfig.AccessKeySecret), color))
 		}
 		if openCpiConfig.SecurityToken != "" {
 			fmt.Printf(" - %s: %s\n", pad2("SecurityToken"), utils.Green("******", color))
@@ -318,7 +328,7 @@ func showKeyFile(color bool, clientAssertionSinger *config.ExSingerConfig, prefi
 			}
 		}
 		if keyFile.Password != "" {
-			fmt.Printf("%s   - %s: %s\n", prefix, pad3("Password"), utils.Green("******", color))
+			fmt.Printf("%s   - %s: %s\n", prefix, pad3("Password"), utils.Green(maskSecret(keyFile.Password), color))
 		}
 	}
 }
@@ -329,6 +339,9 @@ func showExternalCommand(color bool, clientAssertionSinger *config.ExSingerConfi
 		fmt.Printf("%s - %s: %s\n", prefix, pad2("Singer"), utils.Green("External Command", color))
 		fmt.Printf("%s   - %s: %s\n", prefix, pad3("Command"), utils.Green(externalCommand.Command, color))
 		fmt.Printf("%s   - %s: %s\n", prefix, pad3("Parameter"), utils.Green(externalCommand.Parameter, color))
+		if externalCommand.Protocol != "" {
+			fmt.Printf("%s   - %s: %s\n", prefix, pad3("Protocol"), utils.Green(externalCommand.Protocol, color))
+		}
 	}
 }
 
@@ -338,7 +351,7 @@ func showYubiKeyPiv(color bool, clientAssertionSinger *config.ExSingerConfig, pr
 		fmt.Printf("%s - %s: %s\n", prefix, pad2("Singer"), utils.Green("YubiKey PIV", color))
 		fmt.Printf("%s   - %s: %s\n", prefix, pad3("Slot"), utils.Green(yubikeyPiv.Slot, color))
 		if yubikeyPiv.Pin != "" {
-			fmt.Printf("%s   - %s: %s\n", prefix, pad3("Pin"), utils.Green("******", color))
+			fmt.Printf("%s   - %s: %s\n", prefix, pad3("Pin"), utils.Green(maskSecret(yubikeyPiv.Pin), color))
 		}
 		fmt.Printf("%s   - %s: %s\n", prefix, pad3("PinPolicy"), utils.Green(yubikeyPiv.PinPolicy, color))
 	}
@@ -352,7 +365,7 @@ func showPkcs11(color bool, clientAssertionSinger *config.ExSingerConfig, prefix
 		fmt.Printf("%s   - %s: %s\n", prefix, pad3("TokenLabel"), utils.Green(pkcs11.TokenLabel, color))
 		fmt.Printf("%s   - %s: %s\n", prefix, pad3("KeyLabel"), utils.Green(pkcs11.KeyLabel, color))
 		if pkcs11.Pin != "" {
-			fmt.Printf("%s   - %s: %s\n", prefix, pad3("Pin"), utils.Green("******", color))
+			fmt.Printf("%s   - %s: %s\n", prefix, pad3("Pin"), utils.Green(maskSecret(pkcs11.Pin), color))
 		}
 	}
 }
@@ -363,7 +376,7 @@ func showDeviceCode(color bool, deviceCode *config.OidcTokenProviderDeviceCodeCo
 		fmt.Printf(" - %s: %s\n", pad2("Issuer"), utils.Green(deviceCode.Issuer, color))
 		fmt.Printf(" - %s: %s\n", pad2("ClientId"), utils.Green(deviceCode.ClientId, color))
 		if deviceCode.ClientSecret != "" {
-			fmt.Printf(" - %s: %s\n", pad2("ClientSecret"), utils.Green("******", color))
+			fmt.Printf(" - %s: %s\n", pad2("ClientSecret"), utils.Green(maskSecret(deviceCode.ClientSecret), color))
 		}
 		fmt.Printf(" - %s: %s\n", pad2("Scope"), utils.Green(deviceCode.Scope, color))
 		fmt.Printf(" - %s: %s\n", pad2("AutoOpenUrl"),
@@ -375,6 +388,27 @@ func showDeviceCode(color bool, deviceCode *config.OidcTokenProviderDeviceCodeCo
 	}
 }
 
+func showAuthorizationCode(color bool, authorizationCode *config.OidcTokenProviderAuthorizationCodeConfig) {
+	if authorizationCode != nil {
+		fmt.Printf(" %s: %s\n", pad("OIDC Token Provider"), utils.Green("Authorization Code", color))
+		fmt.Printf(" - %s: %s\n", pad2("Issuer"), utils.Green(authorizationCode.Issuer, color))
+		fmt.Printf(" - %s: %s\n", pad2("ClientId"), utils.Green(authorizationCode.ClientId, color))
+		if authorizationCode.ClientSecret != "" {
+			fmt.Printf(" - %s: %s\n", pad2("ClientSecret"), utils.Green(maskSecret(authorizationCode.ClientSecret), color))
+		}
+		fmt.Printf(" - %s: %s\n", pad2("Scope"), utils.Green(authorizationCode.Scope, color))
+		if authorizationCode.RedirectPort > 0 {
+			fmt.Printf(" - %s: %s\n", pad2("RedirectPort"),
+				utils.Green(fmt.Sprintf("%d", authorizationCode.RedirectPort), color))
+		}
+		if authorizationCode.RedirectPath != "" {
+			fmt.Printf(" - %s: %s\n", pad2("RedirectPath"), utils.Green(authorizationCode.RedirectPath, color))
+		}
+		fmt.Printf(" - %s: %s\n", pad2("AutoOpenUrl"),
+			utils.Green(fmt.Sprintf("%v", authorizationCode.AutoOpenUrl), color))
+	}
+}
+
 func pad(str string) string {
 	return padWith(str, 24)
 }
@@ -393,3 +427,11 @@ func padWith(str string, width int) string {
 	}
 	return str + strings.Repeat(" ", width-len(str))
 }
+
+// maskSecret secret reference is shown, it is not secret itself
+func maskSecret(secret string) string {
+	if utils.IsSecretRef(secret) {
+		return secret
+	}
+	return "******"
+}
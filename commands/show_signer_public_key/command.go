@@ -1,11 +1,15 @@
 package show_signer_public_key
 
 import (
+	"crypto"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/common"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
 	"github.com/urfave/cli/v2"
 )
 
@@ -20,12 +24,18 @@ var (
 		Aliases: []string{"p"},
 		Usage:   "IDaaS Profile",
 	}
+	stringFlagFormat = &cli.StringFlag{
+		Name:  "format",
+		Value: "pem",
+		Usage: "Output format, pem, jwk or jwks",
+	}
 )
 
 func BuildCommand() *cli.Command {
 	flags := []cli.Flag{
 		stringFlagConfig,
 		stringFlagProfile,
+		stringFlagFormat,
 	}
 	return &cli.Command{
 		Name:  "show-signer-public-key",
@@ -34,12 +44,16 @@ func BuildCommand() *cli.Command {
 		Action: func(context *cli.Context) error {
 			configFilename := context.String("config")
 			profile := context.String("profile")
-			return showPublicKey(configFilename, profile)
+			format := context.String("format")
+			if format != "pem" && format != "jwk" && format != "jwks" {
+				return fmt.Errorf("invalid format: %s, must be pem, jwk or jwks", format)
+			}
+			return showPublicKey(configFilename, profile, format)
 		},
 	}
 }
 
-func showPublicKey(configFilename, profile string) error {
+func showPublicKey(configFilename, profile, format string) error {
 	profile, cloudStsConfig, err := config.FindProfile(configFilename, profile, false)
 	if err != nil {
 		return fmt.Errorf("find profile %s error %s", profile, err)
@@ -48,44 +62,18 @@ func showPublicKey(configFilename, profile string) error {
 		return fmt.Errorf("profile %s does not exist", profile)
 	}
 
-	return printClientAssertionSignerPublicKey(cloudStsConfig)
+	return printClientAssertionSignerPublicKey(cloudStsConfig, format)
 }
 
-func printClientAssertionSignerPublicKey(cloudStsConfig *config.CloudStsConfig) error {
-	var oidcTokenProvider *config.OidcTokenProviderConfig
-	if cloudStsConfig.AlibabaCloud != nil {
-		if cloudStsConfig.AlibabaCloud.OidcTokenProvider != nil {
-			oidcTokenProvider = cloudStsConfig.AlibabaCloud.OidcTokenProvider
-		}
-	}
-	if cloudStsConfig.Aws != nil {
-		if cloudStsConfig.Aws.OidcTokenProvider != nil {
-			oidcTokenProvider = cloudStsConfig.Aws.OidcTokenProvider
-		}
+func printClientAssertionSignerPublicKey(cloudStsConfig *config.CloudStsConfig, format string) error {
+	clientAssertionSigner := common.FindClientAssertionSigner(cloudStsConfig)
+	if clientAssertionSigner == nil {
+		return fmt.Errorf("ext signer not found")
 	}
-	if cloudStsConfig.CloudAccount != nil {
-		if cloudStsConfig.CloudAccount.AccessTokenProvider != nil {
-			oidcTokenProvider = cloudStsConfig.CloudAccount.AccessTokenProvider
-		}
-	}
-	if oidcTokenProvider != nil {
-		oidcTokenProviderClientCredentials := oidcTokenProvider.OidcTokenProviderClientCredentials
-		if oidcTokenProviderClientCredentials != nil {
-			// client assertion signer
-			if oidcTokenProviderClientCredentials.ClientAssertionSinger != nil {
-				return printExSingerPublicKey(oidcTokenProviderClientCredentials.ClientAssertionSinger)
-			}
-			// client assertion private CA signer
-			clientAssertionPrivateCaConfig := oidcTokenProviderClientCredentials.ClientAssertionPrivateCaConfig
-			if clientAssertionPrivateCaConfig != nil && clientAssertionPrivateCaConfig.CertificateKeySigner != nil {
-				return printExSingerPublicKey(clientAssertionPrivateCaConfig.CertificateKeySigner)
-			}
-		}
-	}
-	return fmt.Errorf("ext signer not found")
+	return printExSingerPublicKey(clientAssertionSigner.Config, format)
 }
 
-func printExSingerPublicKey(exSingerConfig *config.ExSingerConfig) error {
+func printExSingerPublicKey(exSingerConfig *config.ExSingerConfig, format string) error {
 	extJwtSigner, err := config.NewExJwtSignerFromConfig(exSingerConfig)
 	if err != nil {
 		return err
@@ -95,6 +83,9 @@ func printExSingerPublicKey(exSingerConfig *config.ExSingerConfig) error {
 	if err != nil {
 		return err
 	}
+	if format == "jwk" || format == "jwks" {
+		return printJwk(exSingerConfig, publicKey, format == "jwks")
+	}
 	publicKeyDer, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return err
@@ -107,3 +98,21 @@ func printExSingerPublicKey(exSingerConfig *config.ExSingerConfig) error {
 	fmt.Printf("%s", publicKeyPem)
 	return nil
 }
+
+// printJwk JWK or JWKS of signer, can be registered to IDaaS application directly
+func printJwk(exSingerConfig *config.ExSingerConfig, publicKey crypto.PublicKey, jwks bool) error {
+	jwk, err := common.NewSignerJwk(exSingerConfig, publicKey)
+	if err != nil {
+		return err
+	}
+	var v interface{} = jwk
+	if jwks {
+		v = &jwt.Jwks{Keys: []*jwt.Jwk{jwk}}
+	}
+	jwkJson, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jwkJson))
+	return nil
+}
@@ -0,0 +1,337 @@
+package sync_credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/cloud_account"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	TargetAliyun  = "aliyun"
+	TargetOssutil = "ossutil"
+	TargetAws     = "aws"
+
+	EnvAwsSharedCredentialsFile = "AWS_SHARED_CREDENTIALS_FILE"
+
+	defaultAliyunRegion = "cn-hangzhou"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	stringSliceFlagProfile = &cli.StringSliceFlag{
+		Name:    "profile",
+		Aliases: []string{"p"},
+		Usage:   "IDaaS Profile, can be assigned multiple times (default all Alibaba Cloud and AWS profiles)",
+	}
+	stringSliceFlagTarget = &cli.StringSliceFlag{
+		Name:    "target",
+		Aliases: []string{"t"},
+		Usage:   "Sync targets, values aliyun, ossutil, aws (default all)",
+	}
+	stringFlagAliyunConfig = &cli.StringFlag{
+		Name:  "aliyun-config",
+		Usage: "aliyun-cli config file (default ~/.aliyun/config.json)",
+	}
+	stringFlagOssutilConfig = &cli.StringFlag{
+		Name:  "ossutil-config",
+		Usage: "ossutil config file (default ~/.ossutilconfig)",
+	}
+	stringFlagAwsCredentials = &cli.StringFlag{
+		Name:  "aws-credentials",
+		Usage: "AWS shared credentials file (default $AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials)",
+	}
+	boolFlagForceNew = &cli.BoolFlag{
+		Name:    "force-new",
+		Aliases: []string{"N"},
+		Usage:   "Force fetch cloud token, ignore all cache",
+	}
+	boolFlagForceNewCloudToken = &cli.BoolFlag{
+		Name:  "force-new-cloud-token",
+		Usage: "Force fetch cloud token (lower cache enabled)",
+	}
+)
+
+type SyncCredentialsOptions struct {
+	ConfigFilename         string
+	Profiles               []string
+	Targets                []string
+	AliyunConfigFilename   string
+	OssutilConfigFilename  string
+	AwsCredentialsFilename string
+	ForceNew               bool
+	ForceNewCloudToken     bool
+}
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		stringSliceFlagProfile,
+		stringSliceFlagTarget,
+		stringFlagAliyunConfig,
+		stringFlagOssutilConfig,
+		stringFlagAwsCredentials,
+		boolFlagForceNew,
+		boolFlagForceNewCloudToken,
+	}
+	return &cli.Command{
+		Name:  "sync-credentials",
+		Usage: "Sync cloud STS tokens to aliyun-cli, ossutil and AWS credentials files",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			return syncCredentials(&SyncCredentialsOptions{
+				ConfigFilename:         context.String("config"),
+				Profiles:               context.StringSlice("profile"),
+				Targets:                context.StringSlice("target"),
+				AliyunConfigFilename:   context.String("aliyun-config"),
+				OssutilConfigFilename:  context.String("ossutil-config"),
+				AwsCredentialsFilename: context.String("aws-credentials"),
+				ForceNew:               context.Bool("force-new"),
+				ForceNewCloudToken:     context.Bool("force-new-cloud-token"),
+			})
+		},
+	}
+}
+
+func syncCredentials(options *SyncCredentialsOptions) error {
+	targets := options.Targets
+	if len(targets) == 0 {
+		targets = []string{TargetAliyun, TargetOssutil, TargetAws}
+	}
+	for _, target := range targets {
+		if target != TargetAliyun && target != TargetOssutil && target != TargetAws {
+			return errors.Errorf("unknown target: %s", target)
+		}
+	}
+
+	cloudCredentialConfig, err := config.LoadCloudCredentialConfig(options.ConfigFilename)
+	if err != nil {
+		return err
+	}
+	profiles := options.Profiles
+	if len(profiles) == 0 {
+		for profile, cloudStsConfig := range cloudCredentialConfig.Profile {
+			if cloudStsConfig != nil &&
+				(cloudStsConfig.AlibabaCloud != nil || cloudStsConfig.CloudAccount != nil || cloudStsConfig.Aws != nil) {
+				profiles = append(profiles, profile)
+			}
+		}
+		sort.Strings(profiles)
+	}
+
+	alibabaCloudStsTokens := map[string]*alibaba_cloud.StsToken{}
+	awsStsTokens := map[string]*aws.AwsStsToken{}
+	var failedProfiles []string
+	for _, profile := range profiles {
+		cloudStsConfig := cloudCredentialConfig.Profile[profile]
+		if cloudStsConfig == nil {
+			return errors.Errorf("profile: %s not found", profile)
+		}
+		fetchOptions := &cloud.FetchCloudStsOptions{
+			ForceNew:           options.ForceNew,
+			ForceNewCloudToken: options.ForceNewCloudToken,
+		}
+		sts, err := cloud.FetchCloudSts(profile, cloudStsConfig, fetchOptions)
+		if err != nil {
+			utils.Stderr.Fprintf("%s\n", utils.Red(fmt.Sprintf("Fetch token for profile: %s failed: %v", profile, err), true))
+			failedProfiles = append(failedProfiles, profile)
+			continue
+		}
+		if alibabaCloudSts, ok := sts.(*alibaba_cloud.StsToken); ok {
+			alibabaCloudStsTokens[profile] = alibabaCloudSts
+		} else if awsStsToken, ok := sts.(*aws.AwsStsToken); ok {
+			awsStsTokens[profile] = awsStsToken
+		} else if cloudAccountToken, ok := sts.(*cloud_account.CloudAccountToken); ok && cloudAccountToken.IsAlibabaCloudToken() {
+			alibabaCloudStsTokens[profile] = cloud.ConvertCloudAccountTokenAlibabaCloudStsTokenToAlibabaStsToken(
+				cloudAccountToken.CloudAccountRoleAccessCredential.AlibabaCloudStsToken)
+		} else {
+			utils.Stderr.Fprintf("%s\n", utils.Yellow(fmt.Sprintf("Profile: %s token type is not supported, skip", profile), true))
+		}
+	}
+
+	if slices.Contains(targets, TargetAliyun) && len(alibabaCloudStsTokens) > 0 {
+		filename, err := getFilenameInHome(options.AliyunConfigFilename, "", ".aliyun", "config.json")
+		if err != nil {
+			return err
+		}
+		if err = updateFile(filename, func(content []byte) ([]byte, error) {
+			return mergeAliyunConfig(content, alibabaCloudStsTokens)
+		}); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(targets, TargetOssutil) && len(alibabaCloudStsTokens) > 0 {
+		filename, err := getFilenameInHome(options.OssutilConfigFilename, "", ".ossutilconfig")
+		if err != nil {
+			return err
+		}
+		if err = updateFile(filename, func(content []byte) ([]byte, error) {
+			return mergeOssutilConfig(content, alibabaCloudStsTokens), nil
+		}); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(targets, TargetAws) && len(awsStsTokens) > 0 {
+		filename, err := getFilenameInHome(options.AwsCredentialsFilename, EnvAwsSharedCredentialsFile, ".aws", "credentials")
+		if err != nil {
+			return err
+		}
+		if err = updateFile(filename, func(content []byte) ([]byte, error) {
+			return mergeAwsCredentials(content, awsStsTokens), nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(failedProfiles) > 0 {
+		return errors.Errorf("sync credentials failed for profiles: %s", strings.Join(failedProfiles, ", "))
+	}
+	return nil
+}
+
+func updateFile(filename string, merge func(content []byte) ([]byte, error)) error {
+	content, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "read file: %s failed", filename)
+	}
+	newContent, err := merge(content)
+	if err != nil {
+		return errors.Wrapf(err, "merge file: %s failed", filename)
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return errors.Wrapf(err, "create dir for file: %s failed", filename)
+	}
+	backupFilename, err := utils.BackupFile(filename)
+	if err != nil {
+		return err
+	}
+	if err = utils.WriteFileAtomic(filename, newContent, 0600); err != nil {
+		return err
+	}
+	if backupFilename != "" {
+		utils.Stderr.Fprintf("Updated file: %s, backup: %s\n", filename, backupFilename)
+	} else {
+		utils.Stderr.Fprintf("Created file: %s\n", filename)
+	}
+	return nil
+}
+
+// mergeAliyunConfig updates profiles in aliyun-cli config.json with StsToken mode, unknown fields are kept
+// https://github.com/aliyun/aliyun-cli/blob/master/config/profile.go
+func mergeAliyunConfig(content []byte, stsTokens map[string]*alibaba_cloud.StsToken) ([]byte, error) {
+	aliyunConfig := map[string]any{}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &aliyunConfig); err != nil {
+			return nil, errors.Wrap(err, "parse aliyun-cli config failed")
+		}
+	}
+	var aliyunProfiles []any
+	if profiles, ok := aliyunConfig["profiles"].([]any); ok {
+		aliyunProfiles = profiles
+	}
+	for _, profile := range sortedKeys(stsTokens) {
+		stsToken := stsTokens[profile]
+		var aliyunProfile map[string]any
+		for _, p := range aliyunProfiles {
+			if m, ok := p.(map[string]any); ok && m["name"] == profile {
+				aliyunProfile = m
+				break
+			}
+		}
+		if aliyunProfile == nil {
+			aliyunProfile = map[string]any{
+				"name":          profile,
+				"region_id":     defaultAliyunRegion,
+				"output_format": "json",
+				"language":      "en",
+			}
+			aliyunProfiles = append(aliyunProfiles, aliyunProfile)
+		}
+		aliyunProfile["mode"] = "StsToken"
+		aliyunProfile["access_key_id"] = stsToken.AccessKeyId
+		aliyunProfile["access_key_secret"] = stsToken.AccessKeySecret
+		aliyunProfile["sts_token"] = stsToken.StsToken
+	}
+	aliyunConfig["profiles"] = aliyunProfiles
+	if current, _ := aliyunConfig["current"].(string); current == "" && len(aliyunProfiles) > 0 {
+		aliyunConfig["current"] = sortedKeys(stsTokens)[0]
+	}
+	return json.MarshalIndent(aliyunConfig, "", "\t")
+}
+
+// mergeOssutilConfig updates ossutil 2.0 config
+// https://help.aliyun.com/zh/oss/developer-reference/configure-ossutil2
+func mergeOssutilConfig(content []byte, stsTokens map[string]*alibaba_cloud.StsToken) []byte {
+	ossutilConfig := string(content)
+	for _, profile := range sortedKeys(stsTokens) {
+		stsToken := stsTokens[profile]
+		ossutilConfig = utils.UpdateIniSection(ossutilConfig, getIniProfileSection(profile), []utils.IniKeyValue{
+			{Key: "mode", Value: "StsToken"},
+			{Key: "accessKeyID", Value: stsToken.AccessKeyId},
+			{Key: "accessKeySecret", Value: stsToken.AccessKeySecret},
+			{Key: "stsToken", Value: stsToken.StsToken},
+		})
+	}
+	return []byte(ossutilConfig)
+}
+
+// mergeAwsCredentials updates AWS shared credentials file, section name is profile name without `profile` prefix
+// https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-files.html
+func mergeAwsCredentials(content []byte, stsTokens map[string]*aws.AwsStsToken) []byte {
+	awsCredentials := string(content)
+	for _, profile := range sortedKeys(stsTokens) {
+		stsToken := stsTokens[profile]
+		awsCredentials = utils.UpdateIniSection(awsCredentials, profile, []utils.IniKeyValue{
+			{Key: "aws_access_key_id", Value: stsToken.AccessKeyId},
+			{Key: "aws_secret_access_key", Value: stsToken.SecretAccessKey},
+			{Key: "aws_session_token", Value: stsToken.SessionToken},
+		})
+	}
+	return []byte(awsCredentials)
+}
+
+func getIniProfileSection(profile string) string {
+	if profile == "default" {
+		return "default"
+	}
+	return "profile " + profile
+}
+
+func getFilenameInHome(filename, env string, elem ...string) (string, error) {
+	if filename != "" {
+		return filename, nil
+	}
+	if env != "" && os.Getenv(env) != "" {
+		return os.Getenv(env), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home dir")
+	}
+	return filepath.Join(append([]string{homeDir}, elem...)...), nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
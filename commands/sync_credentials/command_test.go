@@ -0,0 +1,74 @@
+package sync_credentials
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/alibaba_cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud/aws"
+)
+
+func TestMergeAliyunConfig(t *testing.T) {
+	content := `{"current":"p1","profiles":[{"name":"p1","mode":"AK","region_id":"cn-beijing","x":1},{"name":"other","mode":"AK"}],"meta_path":""}`
+	stsTokens := map[string]*alibaba_cloud.StsToken{
+		"p1": {AccessKeyId: "STS.1", AccessKeySecret: "s1", StsToken: "t1"},
+		"p2": {AccessKeyId: "STS.2", AccessKeySecret: "s2", StsToken: "t2"},
+	}
+	merged, err := mergeAliyunConfig([]byte(content), stsTokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var aliyunConfig struct {
+		Current  string           `json:"current"`
+		MetaPath *string          `json:"meta_path"`
+		Profiles []map[string]any `json:"profiles"`
+	}
+	if err = json.Unmarshal(merged, &aliyunConfig); err != nil {
+		t.Fatal(err)
+	}
+	if aliyunConfig.Current != "p1" || aliyunConfig.MetaPath == nil || len(aliyunConfig.Profiles) != 3 {
+		t.Fatalf("unexpected config: %s", merged)
+	}
+	p1 := aliyunConfig.Profiles[0]
+	if p1["mode"] != "StsToken" || p1["sts_token"] != "t1" || p1["region_id"] != "cn-beijing" || p1["x"] != float64(1) {
+		t.Errorf("unexpected profile p1: %v", p1)
+	}
+	if aliyunConfig.Profiles[1]["mode"] != "AK" {
+		t.Errorf("unrelated profile changed: %v", aliyunConfig.Profiles[1])
+	}
+	if aliyunConfig.Profiles[2]["name"] != "p2" || aliyunConfig.Profiles[2]["access_key_id"] != "STS.2" {
+		t.Errorf("unexpected profile p2: %v", aliyunConfig.Profiles[2])
+	}
+}
+
+func TestMergeOssutilConfig(t *testing.T) {
+	content := "[default]\nregion = cn-hangzhou\n\n[profile p1]\n# comment\nmode = AK\nregion = cn-beijing\n"
+	stsTokens := map[string]*alibaba_cloud.StsToken{
+		"p1": {AccessKeyId: "STS.1", AccessKeySecret: "s1", StsToken: "t1"},
+		"p2": {AccessKeyId: "STS.2", AccessKeySecret: "s2", StsToken: "t2"},
+	}
+	merged := string(mergeOssutilConfig([]byte(content), stsTokens))
+	expected := "[default]\nregion = cn-hangzhou\n\n" +
+		"[profile p1]\n# comment\nmode = StsToken\nregion = cn-beijing\n" +
+		"accessKeyID = STS.1\naccessKeySecret = s1\nstsToken = t1\n\n" +
+		"[profile p2]\nmode = StsToken\naccessKeyID = STS.2\naccessKeySecret = s2\nstsToken = t2\n"
+	if merged != expected {
+		t.Errorf("unexpected ossutil config:\n%s", merged)
+	}
+}
+
+func TestMergeAwsCredentials(t *testing.T) {
+	content := "[default]\naws_access_key_id = AKIA-OLD\naws_secret_access_key = old\n\n[other]\naws_access_key_id = AKIA-OTHER\n"
+	stsTokens := map[string]*aws.AwsStsToken{
+		"default": {AccessKeyId: "ASIA-1", SecretAccessKey: "s1", SessionToken: "t1"},
+		"aws2":    {AccessKeyId: "ASIA-2", SecretAccessKey: "s2", SessionToken: "t2"},
+	}
+	merged := string(mergeAwsCredentials([]byte(content), stsTokens))
+	// section name is profile name without `profile` prefix in credentials file
+	expected := "[default]\naws_access_key_id = ASIA-1\naws_secret_access_key = s1\naws_session_token = t1\n\n" +
+		"[other]\naws_access_key_id = AKIA-OTHER\n\n" +
+		"[aws2]\naws_access_key_id = ASIA-2\naws_secret_access_key = s2\naws_session_token = t2\n"
+	if merged != expected {
+		t.Errorf("unexpected AWS credentials:\n%s", merged)
+	}
+}
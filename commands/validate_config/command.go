@@ -0,0 +1,110 @@
+package validate_config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	stringFlagConfig = &cli.StringFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "IDaaS Config",
+	}
+	boolFlagJson = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Output problems in JSON",
+	}
+	boolFlagSchema = &cli.BoolFlag{
+		Name:  "schema",
+		Usage: "Output JSON Schema of config",
+	}
+	boolFlagNoColor = &cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Output without color",
+	}
+)
+
+func BuildCommand() *cli.Command {
+	flags := []cli.Flag{
+		stringFlagConfig,
+		boolFlagJson,
+		boolFlagSchema,
+		boolFlagNoColor,
+	}
+	return &cli.Command{
+		Name:  "validate-config",
+		Usage: "Validate config, report unknown fields, missing required fields, bad algorithms, missing files and insecure permissions",
+		Flags: flags,
+		Action: func(context *cli.Context) error {
+			if context.Bool("schema") {
+				return printJson(config.GenerateJsonSchema())
+			}
+			return validateConfig(context.String("config"), context.Bool("json"), !context.Bool("no-color"))
+		},
+	}
+}
+
+func validateConfig(configFilename string, outputJson, color bool) error {
+	if configFilename == "" {
+		var err error
+		if configFilename, err = config.GetDefaultCloudCredentialConfigFile(); err != nil {
+			return err
+		}
+	}
+	problems, err := config.ValidateConfigFile(configFilename)
+	if err != nil {
+		return err
+	}
+	errorCount := 0
+	for _, problem := range problems {
+		if problem.Level == config.ProblemLevelError {
+			errorCount++
+		}
+	}
+
+	if outputJson {
+		if problems == nil {
+			problems = []*config.Problem{}
+		}
+		if err = printJson(problems); err != nil {
+			return err
+		}
+	} else {
+		for _, problem := range problems {
+			level := utils.Yellow("WARN ", color)
+			if problem.Level == config.ProblemLevelError {
+				level = utils.Red("ERROR", color)
+			}
+			file := ""
+			if problem.File != "" {
+				file = fmt.Sprintf(" (%s)", problem.File)
+			}
+			utils.Stdout.Fprintf("%s %s%s: %s\n", level, utils.Blue(problem.Path, color), file, problem.Message)
+		}
+		if len(problems) == 0 {
+			utils.Stdout.Fprintf("%s config: %s is valid\n", utils.Green("OK", color), configFilename)
+		} else {
+			utils.Stdout.Fprintf("\nFound %d error(s), %d warning(s) in config: %s\n",
+				errorCount, len(problems)-errorCount, configFilename)
+		}
+	}
+	if errorCount > 0 {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+func printJson(value interface{}) error {
+	valueJson, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal JSON failed")
+	}
+	utils.Stdout.Fprintf("%s\n", valueJson)
+	return nil
+}
@@ -1,269 +1,296 @@
 package validate_jwt
 
 import (
-	"crypto"
-	"crypto/rsa"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"math/big"
+	"io"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
+const (
+	// errorCodeKeySetUnavailable JWKS cannot be loaded, JWT is not verified
+	errorCodeKeySetUnavailable = "key_set_unavailable"
+	// errorCodeKeySetNotPinned neither issuer nor JWKS is assigned, JWKS from iss in JWT is not trusted
+	errorCodeKeySetNotPinned = "key_set_not_pinned"
+)
+
 var (
-	stringFlagProfile = &cli.StringFlag{
+	stringFlagToken = &cli.StringFlag{
 		Name:     "token",
 		Aliases:  []string{"t"},
 		Required: true,
-		Usage:    "JWT Token",
+		Usage:    "JWT Token, read from stdin when is -",
+	}
+	stringFlagIssuer = &cli.StringFlag{
+		Name:  "issuer",
+		Usage: "Expected issuer, JWKS is discovered from this issuer when JWKS is not assigned, --issuer, --jwks-uri or --jwks-file is required",
+	}
+	stringSliceFlagAudience = &cli.StringSliceFlag{
+		Name:  "audience",
+		Usage: "Expected audience, can be assigned multiple times, JWT aud MUST contain one of them",
+	}
+	durationFlagClockSkew = &cli.DurationFlag{
+		Name:  "clock-skew",
+		Value: jwt.DefaultClockSkew,
+		Usage: "Clock skew tolerance of exp, nbf and iat",
+	}
+	stringSliceFlagAlgorithm = &cli.StringSliceFlag{
+		Name:  "algorithm",
+		Usage: "Allowed algorithm, can be assigned multiple times (default all supported: " + strings.Join(jwt.SupportedAlgorithms, ", ") + ")",
+	}
+	stringFlagJwksFile = &cli.StringFlag{
+		Name:  "jwks-file",
+		Usage: "Local JWKS file, offline verification",
+	}
+	stringFlagJwksUri = &cli.StringFlag{
+		Name:  "jwks-uri",
+		Usage: "JWKS URI",
+	}
+	boolFlagJson = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Output result in JSON format",
+	}
+	boolFlagNoColor = &cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Output without color",
 	}
 )
 
+type validateJwtOptions struct {
+	issuer     string
+	audiences  []string
+	clockSkew  time.Duration
+	algorithms []string
+	jwksFile   string
+	jwksUri    string
+	json       bool
+	color      bool
+}
+
+// ValidateJwtResult machine-readable result, see --json
+type ValidateJwtResult struct {
+	Valid     bool                   `json:"valid"`
+	Error     *jwt.ValidationError   `json:"error,omitempty"`
+	KeySource string                 `json:"key_source,omitempty"`
+	Header    map[string]interface{} `json:"header,omitempty"`
+	Claims    jwt.Claims             `json:"claims,omitempty"`
+}
+
 func BuildCommand() *cli.Command {
 	flags := []cli.Flag{
-		stringFlagProfile,
+		stringFlagToken,
+		stringFlagIssuer,
+		stringSliceFlagAudience,
+		durationFlagClockSkew,
+		stringSliceFlagAlgorithm,
+		stringFlagJwksFile,
+		stringFlagJwksUri,
+		boolFlagJson,
+		boolFlagNoColor,
 	}
 	return &cli.Command{
 		Name:    "validate-jwt",
 		Aliases: []string{"jwt"},
-		Usage:   "Dead simple JWT token validation tool (IMPORTANT: only supports RS256)",
+		Usage:   "Validate JWT signature and claims (supports RS*, PS*, ES* and EdDSA)",
 		Flags:   flags,
 		Action: func(context *cli.Context) error {
-			token := context.String("token")
-			return validateJwt(token)
+			token, err := readToken(context.String("token"))
+			if err != nil {
+				return err
+			}
+			options := &validateJwtOptions{
+				issuer:     context.String("issuer"),
+				audiences:  context.StringSlice("audience"),
+				clockSkew:  context.Duration("clock-skew"),
+				algorithms: context.StringSlice("algorithm"),
+				jwksFile:   context.String("jwks-file"),
+				jwksUri:    context.String("jwks-uri"),
+				json:       context.Bool("json"),
+				color:      !context.Bool("no-color"),
+			}
+			if options.jwksFile != "" && options.jwksUri != "" {
+				return errors.New("--jwks-file and --jwks-uri cannot be used together")
+			}
+			return validateJwt(token, options)
 		},
 	}
 }
 
-func validateJwt(token string, ) error {
-	jwtParts := strings.Split(token, ".")
-	if len(jwtParts) != 3 {
-		return errors.New("invalid JWT token")
+func readToken(token string) (string, error) {
+	if token != "-" {
+		return token, nil
 	}
-
-	headerBytes, err := base64.RawURLEncoding.DecodeString(jwtParts[0])
+	tokenBytes, err := io.ReadAll(os.Stdin)
 	if err != nil {
-		return errors.New("invalid JWT header")
-	}
-	var header map[string]any
-	if err = json.Unmarshal(headerBytes, &header); err != nil {
-		return errors.New("invalid JWT header, error: " + err.Error())
+		return "", errors.Wrap(err, "read JWT from stdin failed")
 	}
+	return strings.TrimSpace(string(tokenBytes)), nil
+}
 
-	payloadBytes, err := base64.RawURLEncoding.DecodeString(jwtParts[1])
+func validateJwt(token string, options *validateJwtOptions) error {
+	result := &ValidateJwtResult{}
+	err := verifyJwt(token, options, result)
 	if err != nil {
-		return errors.New("invalid JWT payload")
-	}
-	var payload map[string]any
-	if err = json.Unmarshal(payloadBytes, &payload); err != nil {
-		return errors.New("invalid JWT payload, error: " + err.Error())
+		var validationError *jwt.ValidationError
+		if !errors.As(err, &validationError) {
+			validationError = &jwt.ValidationError{Code: errorCodeKeySetUnavailable, Message: err.Error()}
+		}
+		result.Error = validationError
 	}
+	result.Valid = err == nil
 
-	headerJsonBytes, err := json.MarshalIndent(&header, "", "  ")
-	if err == nil {
-		fmt.Println(string(headerJsonBytes))
+	if options.json {
+		resultJson, marshalErr := json.MarshalIndent(result, "", "  ")
+		if marshalErr != nil {
+			return marshalErr
+		}
+		utils.Stdout.Println(string(resultJson))
+	} else {
+		printJwtValidation(result, options.color)
 	}
-	payloadJsonBytes, err := json.MarshalIndent(&payload, "", "  ")
-	if err == nil {
-		fmt.Println(string(payloadJsonBytes))
+	if err != nil {
+		// result is already printed, exit with non-zero status without dumping error
+		return cli.Exit("", 1)
 	}
+	return nil
+}
 
-	issuer := payload["iss"].(string)
-	fmt.Printf("Issuer: %s\n", issuer)
-
-	oidcDiscoveryUrl := issuer + "/.well-known/openid-configuration"
-	fmt.Printf("Fetch OIDC discovery: %s\n", oidcDiscoveryUrl)
-	ret, oidcDiscoveryJson, err := utils.GetHttp(oidcDiscoveryUrl)
+func verifyJwt(token string, options *validateJwtOptions, result *ValidateJwtResult) error {
+	parsedJwt, err := jwt.Parse(token)
 	if err != nil {
-		return errors.New("cannot fetch OIDC discovery, error: " + err.Error())
-	}
-	if ret != 200 {
-		return errors.New(fmt.Sprintf("cannot fetch OIDC discovery, status: %d", ret))
+		return err
 	}
-	var oidcDiscovery map[string]any
-	if err = json.Unmarshal([]byte(oidcDiscoveryJson), &oidcDiscovery); err != nil {
-		return errors.New("invalid OIDC discovery, error: " + err.Error())
+	result.Header = parsedJwt.RawHeader
+	result.Claims = parsedJwt.Claims
+	if !options.json {
+		printJwtHeaderAndClaims(parsedJwt)
 	}
-	jwksUri := oidcDiscovery["jwks_uri"].(string)
-	fmt.Printf("Fetch JWKs: %s\n", jwksUri)
-	ret, jwksJson, err := utils.GetHttp(jwksUri)
+
+	keySet, keySource, err := resolveKeySet(parsedJwt, options)
+	result.KeySource = keySource
 	if err != nil {
-		return errors.New("cannot fetch JWKS, error: " + err.Error())
+		return err
 	}
-	if ret != 200 {
-		return errors.New(fmt.Sprintf("cannot fetch JWKS, status: %d", ret))
+	if err = parsedJwt.VerifySignature(keySet, options.algorithms); err != nil {
+		return err
 	}
-	//fmt.Printf("JWKS json: %v\n", jwksJson)
-	var jwks Jwks
-	if err = json.Unmarshal([]byte(jwksJson), &jwks); err != nil {
-		return errors.New("invalid JWKS, error: " + err.Error())
-	}
-
-	alg := header["alg"].(string)
-	if alg != "RS256" {
-		return errors.New("unsupported JWT algorithm: " + alg)
+	if !options.json {
+		utils.Stdout.Fprintf("%s\n", utils.Green("[OK] Verify JWT signature success", options.color))
+		printJwt(parsedJwt.Claims, options.color)
 	}
+	return parsedJwt.VerifyClaims(&jwt.VerifyOptions{
+		Issuer:    options.issuer,
+		Audiences: options.audiences,
+		ClockSkew: options.clockSkew,
+	})
+}
 
-	signKeyId := header["kid"].(string)
-	signPublicKey, err := findRsaJwk(&jwks, signKeyId)
-	if err != nil {
-		return errors.New("cannot find JWK, key ID: " + signKeyId + ", error: " + err.Error())
+// resolveKeySet key source precedence: --jwks-file, --jwks-uri, --issuer,
+// iss in JWT is NOT trusted, anyone can sign a JWT with own issuer and JWKS
+func resolveKeySet(parsedJwt *jwt.Jwt, options *validateJwtOptions) (jwt.KeySet, string, error) {
+	if options.jwksFile != "" {
+		jwks, err := jwt.LoadJwksFile(options.jwksFile)
+		if err != nil {
+			return nil, "file:" + options.jwksFile, err
+		}
+		return jwt.NewStaticKeySet(jwks), "file:" + options.jwksFile, nil
 	}
-	if signPublicKey == nil {
-		return errors.New("cannot find JWK, key ID: " + signKeyId)
+	if options.jwksUri != "" {
+		return jwt.NewRemoteKeySet(options.jwksUri), options.jwksUri, nil
 	}
-
-	tobeVerified := jwtParts[0] + "." + jwtParts[1]
-	tobeVerifiedSha256 := sha256.Sum256([]byte(tobeVerified))
-
-	signature, err := base64.RawURLEncoding.DecodeString(jwtParts[2])
-	if err != nil {
-		return errors.New("invalid JWT signature, error: " + err.Error())
+	if options.issuer == "" {
+		return nil, "", &jwt.ValidationError{
+			Code: errorCodeKeySetNotPinned,
+			Message: fmt.Sprintf("JWKS of iss: %s in JWT is not trusted, --issuer, --jwks-uri or --jwks-file is required",
+				parsedJwt.Claims.GetString("iss")),
+		}
 	}
-	err = rsa.VerifyPKCS1v15(signPublicKey, crypto.SHA256, tobeVerifiedSha256[:], signature)
+	keySet, err := jwt.NewRemoteKeySetFromIssuer(options.issuer)
 	if err != nil {
-		return errors.New("invalid JWT signature, error: " + err.Error())
+		return nil, options.issuer, err
 	}
-
-	fmt.Printf(utils.Green("[OK] Verify JWT signature success\n", true))
-
-	printJwt(payload)
-	return nil
+	return keySet, keySet.GetJwksUri(), nil
 }
 
-func printJwt(payload map[string]any) {
-	nowUnixTimestamp := time.Now().Unix()
-
-	isJwtValid := true
-	jwtFrom := int64(0)
-	jwtEnd := int64(0)
-
-	if payload["iat"] != nil {
-		issueAt := payload["iat"].(float64)
-		jwtFrom = int64(issueAt)
-		issueAtTime := time.Unix(int64(issueAt), 0)
-		issuedSecs := nowUnixTimestamp - int64(issueAt)
-		fmt.Printf("Issue at        : %s %s\n", issueAtTime.Format(time.RFC3339), utils.WBlue(formatHumanTime(issuedSecs)+" ago"))
-	} else {
-		fmt.Printf("[WARN] iat not found in JWT payload\n")
+func printJwtHeaderAndClaims(parsedJwt *jwt.Jwt) {
+	headerJsonBytes, err := json.MarshalIndent(parsedJwt.RawHeader, "", "  ")
+	if err == nil {
+		utils.Stdout.Println(string(headerJsonBytes))
 	}
-
-	jwtNbfFrom, isJwtNbfValid := printJwtNbf(payload, nowUnixTimestamp)
-	if !isJwtNbfValid {
-		isJwtValid = false
+	claimsJsonBytes, err := json.MarshalIndent(parsedJwt.Claims, "", "  ")
+	if err == nil {
+		utils.Stdout.Println(string(claimsJsonBytes))
 	}
-	if jwtNbfFrom > 0 {
-		jwtFrom = jwtNbfFrom
+	if issuer := parsedJwt.Claims.GetString("iss"); issuer != "" {
+		utils.Stdout.Fprintf("Issuer          : %s\n", issuer)
 	}
+}
 
-	jwtEnd, isJwtExpValid := printJwtExp(payload, nowUnixTimestamp)
-	if !isJwtExpValid {
-		isJwtValid = false
-	}
+func printJwt(claims jwt.Claims, color bool) {
+	now := time.Now()
 
-	if jwtFrom > 0 && jwtEnd > 0 {
-		fmt.Printf("Validity period : %s\n", formatHumanTime(jwtEnd-jwtFrom))
+	var jwtFrom, jwtEnd time.Time
+	if issueAt, ok, _ := claims.GetNumericDate("iat"); ok {
+		jwtFrom = issueAt
+		utils.Stdout.Fprintf("Issue at        : %s %s\n", issueAt.Format(time.RFC3339),
+			utils.Blue(formatHumanTime(int64(now.Sub(issueAt).Seconds()))+" ago", color))
+	} else {
+		utils.Stdout.Fprintf("[WARN] iat not found in JWT claims\n")
 	}
-
-	printJwtValidation(isJwtValid)
-}
-
-func printJwtNbf(payload map[string]any, nowUnixTimestamp int64) (int64, bool) {
-	isJwtNbfValid := true
-	if payload["nbf"] != nil {
-		notBefore := payload["nbf"].(float64)
-		jwtNbfFrom := int64(notBefore)
-		notBeforeTime := time.Unix(int64(notBefore), 0)
-		nbfMessage := utils.WGreen("[ok]")
-		if int64(notBefore) > nowUnixTimestamp {
-			isJwtNbfValid = false
-			nbfMessage = utils.WRed("[not yet valid]")
+	if notBefore, ok, _ := claims.GetNumericDate("nbf"); ok {
+		jwtFrom = notBefore
+		nbfMessage := utils.Green("[ok]", color)
+		if notBefore.After(now) {
+			nbfMessage = utils.Red("[not yet valid]", color)
 		}
-		fmt.Printf("Not before      : %s %s\n", notBeforeTime.Format(time.RFC3339), nbfMessage)
-		return jwtNbfFrom, isJwtNbfValid
+		utils.Stdout.Fprintf("Not before      : %s %s\n", notBefore.Format(time.RFC3339), nbfMessage)
+	}
+	if expireAt, ok, _ := claims.GetNumericDate("exp"); ok {
+		jwtEnd = expireAt
+		utils.Stdout.Fprintf("Expire at       : %s %s\n", expireAt.Format(time.RFC3339), formatExpiration(expireAt, now, color))
+	} else {
+		utils.Stdout.Fprintf("[ERROR] exp not found in JWT claims\n")
+	}
+	if !jwtFrom.IsZero() && !jwtEnd.IsZero() {
+		utils.Stdout.Fprintf("Validity period : %s\n", formatHumanTime(int64(jwtEnd.Sub(jwtFrom).Seconds())))
 	}
-	return 0, isJwtNbfValid
 }
 
-func printJwtExp(payload map[string]any, nowUnixTimestamp int64) (int64, bool) {
-	if payload["exp"] != nil {
-		expireAt := payload["exp"].(float64)
-		jwtEnd := int64(expireAt)
-		isJwtExpValid := true
-		expireAtTime := time.Unix(int64(expireAt), 0)
-		expMessage := utils.WGreen("[ok]")
-		if int64(expireAt) <= nowUnixTimestamp {
-			isJwtExpValid = false
-			expiredSecs := nowUnixTimestamp - int64(expireAt)
-			if expiredSecs <= 1 {
-				expMessage = utils.WRed("[expired] " + utils.WBold("just now"))
-			} else {
-				expMessage = utils.WRed("[expired]" + fmt.Sprintf(" %s ago", formatHumanTime(expiredSecs)))
-			}
-		} else {
-			expiringSecs := int64(expireAt) - nowUnixTimestamp
-			leftHumanTime := fmt.Sprintf(" left %s", formatHumanTime(expiringSecs))
-			if expiringSecs < 60 {
-				expMessage = utils.WYellow("[expiring]" + leftHumanTime)
-			} else if expiringSecs < 300 {
-				expMessage = utils.WBlue("[expiring]" + leftHumanTime)
-			} else {
-				expMessage = utils.WGreen("[ok]" + leftHumanTime)
-			}
+func formatExpiration(expireAt, now time.Time, color bool) string {
+	if !expireAt.After(now) {
+		expiredSecs := int64(now.Sub(expireAt).Seconds())
+		if expiredSecs <= 1 {
+			return utils.Red("[expired] ", color) + utils.Bold("just now", color)
 		}
-		fmt.Printf("Expire at       : %s %s\n", expireAtTime.Format(time.RFC3339), expMessage)
-		return jwtEnd, isJwtExpValid
+		return utils.Red(fmt.Sprintf("[expired] %s ago", formatHumanTime(expiredSecs)), color)
 	}
-	fmt.Printf("[ERROR] exp not found in JWT payload\n")
-	return 0, false
-}
-
-func printJwtValidation(isJwtValid bool) {
-	if isJwtValid {
-		fmt.Printf(utils.WBold(utils.WGreen("[OK] JWT is VALID\n")))
-	} else {
-		fmt.Printf(utils.WBold(utils.WRed("[ERROR] JWT is INVALID\n")))
+	expiringSecs := int64(expireAt.Sub(now).Seconds())
+	leftHumanTime := fmt.Sprintf(" left %s", formatHumanTime(expiringSecs))
+	if expiringSecs < 60 {
+		return utils.Yellow("[expiring]"+leftHumanTime, color)
+	} else if expiringSecs < 300 {
+		return utils.Blue("[expiring]"+leftHumanTime, color)
 	}
+	return utils.Green("[ok]"+leftHumanTime, color)
 }
 
-type Jwks struct {
-	Keys []*RsaJwk `json:"keys"`
-}
-type RsaJwk struct {
-	KeyId     string `json:"kid"`
-	KeyType   string `json:"kty"`
-	Algorithm string `json:"alg"`
-	Use       string `json:"use"`
-	Exponent  string `json:"e"`
-	Modulus   string `json:"n"`
-}
-
-func findRsaJwk(jwks *Jwks, keyId string) (*rsa.PublicKey, error) {
-	for _, key := range jwks.Keys {
-		if key.KeyId == keyId {
-			nBytes, err := base64.RawURLEncoding.DecodeString(key.Modulus)
-			if err != nil {
-				return nil, err
-			}
-			eBytes, err := base64.RawURLEncoding.DecodeString(key.Exponent)
-			if err != nil {
-				return nil, err
-			}
-			n := new(big.Int).SetBytes(nBytes)
-			e := new(big.Int).SetBytes(eBytes).Int64()
-			return &rsa.PublicKey{
-				N: n,
-				E: int(e),
-			}, nil
-		}
+func printJwtValidation(result *ValidateJwtResult, color bool) {
+	if result.KeySource != "" {
+		utils.Stdout.Fprintf("Key source      : %s\n", result.KeySource)
+	}
+	if result.Valid {
+		utils.Stdout.Fprintf("%s\n", utils.Bold(utils.Green("[OK] JWT is VALID", color), color))
+	} else {
+		utils.Stdout.Fprintf("%s\n", utils.Bold(utils.Red(
+			fmt.Sprintf("[ERROR] JWT is INVALID, %s", result.Error.Error()), color), color))
 	}
-	return nil, nil
 }
 
 func formatHumanTime(timeInSeconds int64) string {
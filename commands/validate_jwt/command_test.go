@@ -0,0 +1,25 @@
+package validate_jwt
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
+	"github.com/pkg/errors"
+)
+
+func TestVerifyJwtNotPinned(t *testing.T) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"k1"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"https://attacker.example.com","exp":4102444800}`))
+	token := header + "." + claims + "." + base64.RawURLEncoding.EncodeToString([]byte("signature"))
+
+	result := &ValidateJwtResult{}
+	err := verifyJwt(token, &validateJwtOptions{json: true}, result)
+	var validationError *jwt.ValidationError
+	if !errors.As(err, &validationError) || validationError.Code != errorCodeKeySetNotPinned {
+		t.Fatalf("JWT without pinned issuer or JWKS should not be verified, got: %v", err)
+	}
+	if err = validateJwt(token, &validateJwtOptions{json: true}); err == nil {
+		t.Errorf("validate JWT without pinned issuer or JWKS should exit with non-zero status")
+	}
+}
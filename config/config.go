@@ -14,10 +14,44 @@ const (
 	Version1 = "1"
 )
 
+// CloudCredentialConfig fields marked `secret ref` accept inline secret or secret reference:
+// env:NAME, file:/path, exec:command or keyring:service/account, references are resolved when token is fetched
 type CloudCredentialConfig struct {
+	Schema         string                     `json:"$schema"` // optional, JSON schema for editors, see `validate-config --schema`
 	Version        string                     `json:"version"` // current version always ("1" - Version1)
 	CurrentProfile string                     `json:"current_profile"`
 	Profile        map[string]*CloudStsConfig `json:"profile"` // required
+	Serve          *ServeConfig               `json:"serve"`   // optional
+	// TokenProviders optional, shared token providers, referenced by token provider `{"ref": "<name>"}` in profiles
+	TokenProviders map[string]*OidcTokenProviderConfig `json:"token_providers"`
+	// CacheBackend optional, file(default), keyring or pass, environment ALIBABA_CLOUD_IDAAS_CACHE_BACKEND has higher priority
+	CacheBackend    string                 `json:"cache_backend"`
+	CacheEncryption *CacheEncryptionConfig `json:"cache_encryption"` // optional, only for file cache backend
+
+	// ProfileSources profile name to file it came from, main config file or file in profile.d
+	ProfileSources map[string]string `json:"-"`
+	// ProfileInherits profile name to fields not defined in the profile itself, e.g. `extends: base`, `ref: corp`
+	ProfileInherits map[string][]string `json:"-"`
+}
+
+// CacheEncryptionConfig wraps the cache encryption key, so cache cannot be decrypted with files in home dir only
+type CacheEncryptionConfig struct {
+	Mode   string          `json:"mode"`   // optional, default, passphrase or signer
+	Kdf    string          `json:"kdf"`    // optional, only for passphrase, argon2id(default) or scrypt
+	Signer *ExSingerConfig `json:"signer"` // optional, only for signer, MUST be RSA key(RS256, RS384, RS512)
+}
+
+// ServeConfig is used by `serve` command, when policies are configured, requests must use
+// SSRF token from command line, or match a policy
+type ServeConfig struct {
+	Policies []*ServePolicyConfig `json:"policies"` // optional
+}
+
+type ServePolicyConfig struct {
+	Name      string   `json:"name"`       // optional, for log and audit
+	SsrfToken string   `json:"ssrf_token"` // optional, SsrfToken or Uids one required, secret ref
+	Uids      []int    `json:"uids"`       // optional, unix socket peer UIDs
+	Profiles  []string `json:"profiles"`   // required, allowed profiles, `*` for all profiles
 }
 
 func FindProfile(configFilename, profile string, ignoreParseFromProfile bool) (string, *CloudStsConfig, error) {
@@ -88,6 +122,7 @@ type CloudStsConfig struct {
 	Agent        *AgentConfig             `json:"agent"`               // optional, see AlibabaCloud
 	Environments []string                 `json:"environments"`        // optional, environments for execute
 	Comment      string                   `json:"comment"`             // optional
+	Extends      string                   `json:"extends"`             // optional, parent profile, merged recursively
 }
 
 type CloudAccountTokenConfig struct {
@@ -177,9 +212,11 @@ type AwsCloudStsConfig struct {
 }
 
 type OidcTokenProviderConfig struct {
-	TokenType                          string                                    `json:"token_type"`         // for device_code, id_token[default], access_token
+	Ref                                string                                    `json:"ref"`                // optional, name in token_providers, other fields must be empty
+	TokenType                          string                                    `json:"token_type"`         // for device_code and authorization_code, id_token[default], access_token
 	OidcTokenProviderClientCredentials *OidcTokenProviderClientCredentialsConfig `json:"client_credentials"` // optional *
 	OidcTokenProviderDeviceCode        *OidcTokenProviderDeviceCodeConfig        `json:"device_code"`        // optional *
+	OidcTokenProviderAuthorizationCode *OidcTokenProviderAuthorizationCodeConfig `json:"authorization_code"` // optional *
 	OpenApi                            *OpenApiConfig                            `json:"open_api"`           // optional *
 	// * only requires one
 }
@@ -195,6 +232,9 @@ func (c *OidcTokenProviderConfig) GetId() string {
 	if c.OidcTokenProviderDeviceCode != nil {
 		return c.OidcTokenProviderDeviceCode.ClientId
 	}
+	if c.OidcTokenProviderAuthorizationCode != nil {
+		return c.OidcTokenProviderAuthorizationCode.ClientId
+	}
 	return "unknown_oidc"
 }
 
@@ -214,7 +254,7 @@ type OidcTokenProviderClientCredentialsConfig struct {
 	ClientId                           string           `json:"client_id"`                             // required
 	Scope                              string           `json:"scope"`                                 // optional
 	ApplicationFederatedCredentialName string           `json:"application_federated_credential_name"` // optional
-	ClientSecret                       string           `json:"client_secret"`                         // optional *
+	ClientSecret                       string           `json:"client_secret"`                         // optional *, secret ref
 	ClientAssertionSinger              *ExSingerConfig  `json:"client_assertion_singer"`               // optional *
 	ClientAssertionPkcs7Config         *Pkcs7Config     `json:"client_assertion_pkcs7"`                // optional *
 	ClientAssertionPrivateCaConfig     *PrivateCaConfig `json:"client_assertion_private_ca"`           // optional *
@@ -226,12 +266,28 @@ type OidcTokenProviderDeviceCodeConfig struct {
 	Issuer       string `json:"issuer"`        // required
 	ClientId     string `json:"client_id"`     // required
 	Scope        string `json:"scope"`         // optional, default openid
-	ClientSecret string `json:"client_secret"` // optional, when public client
+	ClientSecret string `json:"client_secret"` // optional, when public client, secret ref
 	AutoOpenUrl  bool   `json:"auto_open_url"` // optional, auto open in browser, use in local device
 	ShowQrCode   bool   `json:"show_qr_code"`  // optional, show QR code, use in server
 	SmallQrCode  bool   `json:"small_qr_code"` // optional, show small QR code, may cause compatible issue
 }
 
+// OidcTokenProviderAuthorizationCodeConfig
+// Authorization Code flow with PKCE, redirect to loopback 127.0.0.1
+// specification:
+// - RFC6749: The OAuth 2.0 Authorization Framework
+// - RFC7636: Proof Key for Code Exchange by OAuth Public Clients
+// - RFC8252: OAuth 2.0 for Native Apps
+type OidcTokenProviderAuthorizationCodeConfig struct {
+	Issuer       string `json:"issuer"`        // required
+	ClientId     string `json:"client_id"`     // required
+	Scope        string `json:"scope"`         // optional, default openid
+	ClientSecret string `json:"client_secret"` // optional, when public client, secret ref
+	RedirectPort int    `json:"redirect_port"` // optional, loopback redirect port, random port when absent
+	RedirectPath string `json:"redirect_path"` // optional, loopback redirect path, default /callback
+	AutoOpenUrl  bool   `json:"auto_open_url"` // optional, auto open in browser
+}
+
 // OpenApiConfig
 // reference:
 // - https://github.com/aliyun/credentials-go
@@ -246,7 +302,7 @@ type OpenApiConfig struct {
 	// Credential type, including access_key, sts, bearer, ecs_ram_role, ram_role_arn, rsa_key_pair, oidc_role_arn, credentials_uri
 	Type            string `json:"type"`
 	AccessKeyId     string `json:"access_key_id"`
-	AccessKeySecret string `json:"access_key_secret"`
+	AccessKeySecret string `json:"access_key_secret"` // secret ref
 	SecurityToken   string `json:"security_token"`
 
 	// Used when the type is ram_role_arn or oidc_role_arn
@@ -298,35 +354,57 @@ type OidcTokenConfig struct {
 
 type ExSingerConfig struct {
 	KeyID           string                         `json:"key_id"`           // optional, PCA do not requires key_id
-	Algorithm       string                         `json:"algorithm"`        // required, RS256, RS384, RS512, ES256, ES384, ES512
+	Algorithm       string                         `json:"algorithm"`        // required, RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512, EdDSA
 	Pkcs11          *ExSignerPkcs11Config          `json:"pkcs11"`           // optional *
 	YubikeyPiv      *ExSignerYubikeyPivConfig      `json:"yubikey_piv"`      // optional *
 	ExternalCommand *ExSignerExternalCommandConfig `json:"external_command"` // optional *
 	KeyFile         *ExSingerKeyFileConfig         `json:"key_file"`         // optional *
-	// * pkcs11, yubikey_piv, external_command, key_file requires one
+	Kms             *ExSignerKmsConfig             `json:"kms"`              // optional *
+	// * pkcs11, yubikey_piv, external_command, key_file, kms requires one
 }
 
 type ExSignerPkcs11Config struct {
 	LibraryPath string `json:"library_path"` // required
 	TokenLabel  string `json:"token_label"`  // required
 	KeyLabel    string `json:"key_label"`    // required
-	Pin         string `json:"pin"`          // optional, or set env PKS11_PIN
+	Pin         string `json:"pin"`          // optional, or set env PKS11_PIN, secret ref
 }
 
 type ExSignerYubikeyPivConfig struct {
 	Slot      string `json:"slot"`       // required, auth,sign or rN
-	Pin       string `json:"pin"`        // optional, or set env YUBIKEY_PIN
+	Pin       string `json:"pin"`        // optional, or set env YUBIKEY_PIN, secret ref
 	PinPolicy string `json:"pin_policy"` // required, none, once or always
 }
 
 type ExSignerExternalCommandConfig struct {
 	Command   string `json:"command"`   // required
 	Parameter string `json:"parameter"` // required
+	Protocol  string `json:"protocol"`  // optional, v1(default, process per call) or v2(persistent helper process)
+}
+
+// ExSignerKmsConfig
+// Alibaba Cloud KMS, AWS KMS
+// reference:
+// - https://www.alibabacloud.com/help/en/kms/key-management-service/developer-reference/api-asymmetricsign
+// - https://docs.aws.amazon.com/kms/latest/APIReference/API_Sign.html
+type ExSignerKmsConfig struct {
+	Provider     string         `json:"provider"`       // required, alibaba_cloud or aws
+	KeyId        string         `json:"key_id"`         // required, KMS key ID, AWS supports alias and ARN
+	KeyVersionId string         `json:"key_version_id"` // optional, required for alibaba_cloud
+	Region       string         `json:"region"`         // optional *, required for aws
+	Endpoint     string         `json:"endpoint"`       // optional *, e.g. https://kms.cn-hangzhou.aliyuncs.com
+	Profile      string         `json:"profile"`        // optional **, profile in the same config file which issues KMS credentials
+	OpenApi      *OpenApiConfig `json:"open_api"`       // optional **, Alibaba Cloud credential type, alibaba_cloud only
+	// * region, endpoint requires one
+	// ** profile, open_api requires one
+
+	// configFilename config file of this signer, profile is found in it, see resolveKmsProfiles
+	configFilename string
 }
 
 type ExSingerKeyFileConfig struct {
 	Key      string `json:"key"`      // optional *
 	File     string `json:"file"`     // optional *
-	Password string `json:"password"` // optional, for PKCS#8 encrypted private key
+	Password string `json:"password"` // optional, for PKCS#8 encrypted private key, secret ref
 	// * key, file requires one
 }
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"github.com/aliyun/credentials-go/credentials"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+// NewCredentialsConfig converts open_api credential type to Alibaba Cloud credentials config,
+// access_key_secret reference is resolved here
+func NewCredentialsConfig(openApiConfig *OpenApiConfig) (*credentials.Config, error) {
+	if openApiConfig.Type == "" {
+		return nil, nil
+	}
+
+	credentialsConfig := new(credentials.Config)
+
+	if openApiConfig.Type != "" {
+		credentialsConfig.SetType(openApiConfig.Type)
+	}
+	if openApiConfig.AccessKeyId != "" {
+		credentialsConfig.SetAccessKeyId(openApiConfig.AccessKeyId)
+	}
+	if openApiConfig.AccessKeySecret != "" {
+		accessKeySecret, err := utils.ResolveSecret(openApiConfig.AccessKeySecret)
+		if err != nil {
+			return nil, errors.Wrap(err, "resolve access key secret failed")
+		}
+		credentialsConfig.SetAccessKeySecret(accessKeySecret)
+	}
+	if openApiConfig.SecurityToken != "" {
+		credentialsConfig.SetSecurityToken(openApiConfig.SecurityToken)
+	}
+
+	if openApiConfig.OIDCProviderArn != "" {
+		credentialsConfig.SetOIDCProviderArn(openApiConfig.OIDCProviderArn)
+	}
+	if openApiConfig.OIDCTokenFilePath != "" {
+		credentialsConfig.SetOIDCTokenFilePath(openApiConfig.OIDCTokenFilePath)
+	}
+	if openApiConfig.RoleArn != "" {
+		credentialsConfig.SetRoleArn(openApiConfig.RoleArn)
+	}
+
+	if openApiConfig.RoleArn != "" {
+		credentialsConfig.SetRoleArn(openApiConfig.RoleArn)
+	}
+	if openApiConfig.RoleSessionName != "" {
+		credentialsConfig.SetRoleSessionName(openApiConfig.RoleSessionName)
+	}
+	if openApiConfig.RoleSessionExpiration != 0 {
+		credentialsConfig.SetRoleSessionExpiration(openApiConfig.RoleSessionExpiration)
+	}
+	if openApiConfig.Policy != "" {
+		credentialsConfig.SetPolicy(openApiConfig.Policy)
+	}
+	if openApiConfig.ExternalId != "" {
+		credentialsConfig.SetExternalId(openApiConfig.ExternalId)
+	}
+	if openApiConfig.STSEndpoint != "" {
+		credentialsConfig.SetSTSEndpoint(openApiConfig.STSEndpoint)
+	}
+
+	if openApiConfig.RoleName != "" {
+		credentialsConfig.SetRoleName(openApiConfig.RoleName)
+	}
+
+	if openApiConfig.Url != "" {
+		credentialsConfig.SetURLCredential(openApiConfig.Url)
+	}
+
+	return credentialsConfig, nil
+}
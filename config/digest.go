@@ -52,7 +52,7 @@ func (c *OidcTokenProviderConfig) Digest() string {
 		return ""
 	}
 	return digest(c.TokenType, c.OidcTokenProviderClientCredentials.Digest(),
-		c.OidcTokenProviderDeviceCode.Digest(), c.OpenApi.Digest())
+		c.OidcTokenProviderDeviceCode.Digest(), c.OidcTokenProviderAuthorizationCode.Digest(), c.OpenApi.Digest())
 }
 
 func (c *OidcTokenProviderClientCredentialsConfig) Digest() string {
@@ -75,12 +75,21 @@ func (c *OidcTokenProviderDeviceCodeConfig) Digest() string {
 	return digest(c.Issuer, c.ClientId, c.Scope)
 }
 
+func (c *OidcTokenProviderAuthorizationCodeConfig) Digest() string {
+	if c == nil {
+		return ""
+	}
+	// ClientSecret, RedirectPort, RedirectPath, AutoOpenUrl do not effect digest(cache)
+	return digest(c.Issuer, c.ClientId, c.Scope)
+}
+
 func (c *OpenApiConfig) Digest() string {
 	if c == nil {
 		return ""
 	}
+	// AccessKeySecret do not effect digest(cache), rotating secret keeps cache
 	return digest(c.InstanceId, c.ApplicationId, digest(c.ScopeValues...), c.Audience,
-		c.OpenApiEndpoint, c.Type, c.AccessKeyId, c.AccessKeySecret, c.SecurityToken,
+		c.OpenApiEndpoint, c.Type, c.AccessKeyId, c.SecurityToken,
 		c.OIDCProviderArn, c.OIDCTokenFilePath, c.RoleArn, c.RoleSessionName,
 		intToString(c.RoleSessionExpiration), c.Policy, c.ExternalId, c.STSEndpoint,
 		c.RoleName, c.Url)
@@ -109,13 +118,20 @@ func (c *OidcTokenConfig) Digest() string {
 	return digest(c.Provider, c.OidcToken, fileModTime(c.OidcTokenFile))
 }
 
+func (c *CacheEncryptionConfig) Digest() string {
+	if c == nil {
+		return ""
+	}
+	return digest(c.Mode, c.Kdf, c.Signer.Digest())
+}
+
 func (c *ExSingerConfig) Digest() string {
 	if c == nil {
 		return ""
 	}
 	return digest(c.KeyID, c.Algorithm,
 		c.Pkcs11.Digest(), c.YubikeyPiv.Digest(),
-		c.ExternalCommand.Digest(), c.KeyFile.Digest())
+		c.ExternalCommand.Digest(), c.KeyFile.Digest(), c.Kms.Digest())
 }
 
 func (c *ExSignerPkcs11Config) Digest() string {
@@ -148,6 +164,14 @@ func (c *ExSingerKeyFileConfig) Digest() string {
 	return digest(c.Key, c.File, fileModTime(c.File))
 }
 
+func (c *ExSignerKmsConfig) Digest() string {
+	if c == nil {
+		return ""
+	}
+	// KMS credentials do not effect digest(cache)
+	return digest(c.Provider, c.KeyId, c.KeyVersionId, c.Region, c.Endpoint)
+}
+
 func digest(args ...string) string {
 	h := sha256.New()
 	for _, a := range args {
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ConfigFormatJson = "json"
+	ConfigFormatYaml = "yaml"
+	ConfigFormatToml = "toml"
+
+	// ProfileDir profile fragments directory next to main config file
+	ProfileDir = "profile.d"
+)
+
+// GetConfigFormat config format is chosen by file extension, JSON by default
+func GetConfigFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYaml
+	case ".toml":
+		return ConfigFormatToml
+	}
+	return ConfigFormatJson
+}
+
+// configSource config content merged from main config file and profile.d files, in JSON
+type configSource struct {
+	content        []byte
+	profileSources map[string]string
+	profileFiles   []string
+}
+
+// readConfigSource reads main config file and merges profile and token_providers in profile.d files,
+// duplicate profile or token provider names are errors
+func readConfigSource(configFilename string) (*configSource, error) {
+	content, err := readConfigFileAsJson(configFilename)
+	if err != nil {
+		return nil, err
+	}
+	var mainConfig map[string]interface{}
+	if err = json.Unmarshal(content, &mainConfig); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal config file: %s", configFilename)
+	}
+	// empty YAML file or JSON null, fragments in profile.d are merged into empty config
+	if mainConfig == nil {
+		mainConfig = map[string]interface{}{}
+	}
+	source := &configSource{content: content, profileSources: map[string]string{}}
+	if profiles, ok := mainConfig["profile"].(map[string]interface{}); ok {
+		for name := range profiles {
+			source.profileSources[name] = configFilename
+		}
+	}
+
+	if source.profileFiles, err = ListProfileDirFiles(configFilename); err != nil {
+		return nil, err
+	}
+	if len(source.profileFiles) == 0 {
+		return source, nil
+	}
+	tokenProviderSources := map[string]string{}
+	if tokenProviders, ok := mainConfig["token_providers"].(map[string]interface{}); ok {
+		for name := range tokenProviders {
+			tokenProviderSources[name] = configFilename
+		}
+	}
+	for _, profileFile := range source.profileFiles {
+		fragmentContent, err := readConfigFileAsJson(profileFile)
+		if err != nil {
+			return nil, err
+		}
+		var fragment map[string]interface{}
+		if err = json.Unmarshal(fragmentContent, &fragment); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal profile file: %s", profileFile)
+		}
+		for key := range fragment {
+			if key != "profile" && key != "token_providers" {
+				return nil, errors.Errorf("field: %s is not allowed in profile file: %s, only profile and token_providers", key, profileFile)
+			}
+		}
+		if err = mergeFragment(mainConfig, fragment, "profile", profileFile, source.profileSources); err != nil {
+			return nil, err
+		}
+		if err = mergeFragment(mainConfig, fragment, "token_providers", profileFile, tokenProviderSources); err != nil {
+			return nil, err
+		}
+	}
+	if source.content, err = json.Marshal(mainConfig); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal merged config")
+	}
+	return source, nil
+}
+
+func mergeFragment(mainConfig, fragment map[string]interface{}, key, profileFile string, sources map[string]string) error {
+	if fragment[key] == nil {
+		return nil
+	}
+	fragmentObjects, ok := fragment[key].(map[string]interface{})
+	if !ok {
+		return errors.Errorf("field: %s in profile file: %s must be object", key, profileFile)
+	}
+	mainObjects, ok := mainConfig[key].(map[string]interface{})
+	if !ok {
+		mainObjects = map[string]interface{}{}
+		mainConfig[key] = mainObjects
+	}
+	for name, value := range fragmentObjects {
+		if source, exists := sources[name]; exists {
+			return errors.Errorf("duplicate %s: %s in file: %s and %s", key, name, source, profileFile)
+		}
+		sources[name] = profileFile
+		mainObjects[name] = value
+	}
+	return nil
+}
+
+// ListProfileDirFiles lists JSON, YAML and TOML files in profile.d, sorted by name, hidden files are ignored
+func ListProfileDirFiles(configFilename string) ([]string, error) {
+	profileDir := filepath.Join(filepath.Dir(configFilename), ProfileDir)
+	entries, err := os.ReadDir(profileDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read profile dir: %s", profileDir)
+	}
+	var profileFiles []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".json", ".yaml", ".yml", ".toml":
+			profileFiles = append(profileFiles, filepath.Join(profileDir, name))
+		}
+	}
+	sort.Strings(profileFiles)
+	return profileFiles, nil
+}
+
+// readConfigFileAsJson reads config file and converts YAML or TOML to JSON,
+// so strict decoding, extends and validation work the same for all formats
+func readConfigFileAsJson(filename string) ([]byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read config file: %s", filename)
+	}
+	var value interface{}
+	switch GetConfigFormat(filename) {
+	case ConfigFormatYaml:
+		if err = yaml.Unmarshal(content, &value); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal YAML file: %s", filename)
+		}
+	case ConfigFormatToml:
+		var tomlValue map[string]interface{}
+		if err = toml.Unmarshal(content, &tomlValue); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal TOML file: %s", filename)
+		}
+		value = tomlValue
+	default:
+		return content, nil
+	}
+	jsonContent, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert file: %s to JSON", filename)
+	}
+	return jsonContent, nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigFormatsAndProfileDir(t *testing.T) {
+	configDir := t.TempDir()
+	writeFile := func(name, content string) string {
+		filename := filepath.Join(configDir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	configFilename := writeFile("config.yaml", `version: "1"
+token_providers:
+  corp:
+    device_code:
+      issuer: https://example.com
+      client_id: client1
+profile:
+  base:
+    aws_sts:
+      region: us-east-1
+      role_arn: arn:aws:iam::1:role/base
+      oidc_token_provider:
+        ref: corp
+`)
+	tomlFilename := writeFile("profile.d/10-dev.toml", `[profile.dev]
+extends = "base"
+
+[profile.dev.aws_sts]
+role_arn = "arn:aws:iam::1:role/dev"
+duration_seconds = 900
+`)
+	jsonFilename := writeFile("profile.d/20-prod.json", `{"profile": {"prod": {"extends": "base"}}}`)
+	writeFile("profile.d/README.md", "ignored")
+
+	cloudCredentialConfig, err := LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := cloudCredentialConfig.Profile["dev"]
+	if dev == nil || dev.Aws.Region != "us-east-1" || dev.Aws.RoleArn != "arn:aws:iam::1:role/dev" ||
+		dev.Aws.DurationSeconds != 900 || dev.Aws.OidcTokenProvider.OidcTokenProviderDeviceCode == nil {
+		t.Fatalf("unexpected profile dev: %+v", dev)
+	}
+	expectedSources := map[string]string{"base": configFilename, "dev": tomlFilename, "prod": jsonFilename}
+	for name, expected := range expectedSources {
+		if source := cloudCredentialConfig.ProfileSources[name]; source != expected {
+			t.Errorf("profile %s source: %s, expected: %s", name, source, expected)
+		}
+	}
+
+	duplicateFilename := writeFile("profile.d/30-dup.yml", "profile:\n  dev:\n    comment: duplicate\n")
+	_, err = LoadCloudCredentialConfig(configFilename)
+	if err == nil || !strings.Contains(err.Error(), "duplicate profile: dev in file: "+tomlFilename+" and "+duplicateFilename) {
+		t.Errorf("duplicate profile should fail, error: %v", err)
+	}
+	problems, err := ValidateConfigFile(configFilename)
+	if err != nil || len(problems) != 1 || !strings.Contains(problems[0].Message, "duplicate profile: dev") {
+		t.Errorf("validate duplicate profile: %v, %v", problems, err)
+	}
+}
+
+func TestLoadEmptyMainConfigWithProfileDir(t *testing.T) {
+	for name, content := range map[string]string{"config.yaml": "", "config.json": "null"} {
+		configDir := t.TempDir()
+		configFilename := filepath.Join(configDir, name)
+		if err := os.WriteFile(configFilename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(configDir, ProfileDir), 0700); err != nil {
+			t.Fatal(err)
+		}
+		profileFilename := filepath.Join(configDir, ProfileDir, "a.yaml")
+		if err := os.WriteFile(profileFilename, []byte("profile:\n  a:\n    comment: a\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		// version is required in main config
+		if _, err := LoadCloudCredentialConfig(configFilename); err == nil || !strings.Contains(err.Error(), "version") {
+			t.Errorf("load %s should fail by version, error: %v", name, err)
+		}
+	}
+}
@@ -1,17 +1,19 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
 	"github.com/pkg/errors"
 )
 
+// CreateCloudCredentialConfig creates empty config file with permission 0600, config dir is created when absent
 func CreateCloudCredentialConfig(configFilename string) error {
 	if configFilename == "" {
 		return errors.New("configFilename is empty")
@@ -19,21 +21,19 @@ func CreateCloudCredentialConfig(configFilename string) error {
 	if _, err := os.Stat(configFilename); err == nil {
 		return errors.New("config file already exists")
 	}
-	config := &CloudCredentialConfig{
-		Version: Version1,
-		Profile: map[string]*CloudStsConfig{},
+	// only required fields, empty optional fields are not written
+	config := map[string]interface{}{
+		"version": Version1,
+		"profile": map[string]interface{}{},
 	}
-	configBytes, marshalErr := json.Marshal(config)
+	configBytes, marshalErr := json.MarshalIndent(config, "", "  ")
 	if marshalErr != nil {
 		return errors.Wrap(marshalErr, "failed to marshal config")
 	}
-	configFile, createErr := os.Create(configFilename)
-	if createErr != nil {
-		return errors.Wrap(createErr, "failed to create config file")
+	if mkdirErr := os.MkdirAll(filepath.Dir(configFilename), 0700); mkdirErr != nil {
+		return errors.Wrap(mkdirErr, "failed to create config dir")
 	}
-	defer configFile.Close()
-	_, writeErr := configFile.Write(configBytes)
-	if writeErr != nil {
+	if writeErr := utils.WriteFileAtomic(configFilename, configBytes, 0600); writeErr != nil {
 		return errors.Wrap(writeErr, "failed to write config file")
 	}
 	return nil
@@ -54,9 +54,16 @@ func LoadCloudCredentialConfig(configFilename string) (*CloudCredentialConfig, e
 	if config == nil {
 		return nil, fmt.Errorf("config file: %s not found", configFilename)
 	}
+	config.ApplyCacheEncryption()
 	return config, nil
 }
 
+// ApplyCacheEncryption applies cache encryption of the config loaded by current command,
+// config files only read(e.g. validate-config) do not change cache key options
+func (c *CloudCredentialConfig) ApplyCacheEncryption() {
+	utils.SetCacheKeyOptions(NewCacheKeyOptionsFromConfig(c.CacheEncryption))
+}
+
 func ReadCloudCredentialConfig(configFilename string) (*CloudCredentialConfig, error) {
 	if configFilename == "" {
 		return nil, errors.New("configFilename is empty")
@@ -66,21 +73,17 @@ func ReadCloudCredentialConfig(configFilename string) (*CloudCredentialConfig, e
 		idaaslog.Debug.PrintfLn("Config file does not exist: %s, error: %s", configFilename, err.Error())
 		return nil, nil
 	}
-	configFile, openErr := os.Open(configFilename)
-	if openErr != nil {
-		return nil, errors.Wrapf(openErr, "failed to open config file: %s", configFilename)
-	}
-	defer configFile.Close()
-
-	configContent, readAllErr := io.ReadAll(configFile)
-	if readAllErr != nil {
-		return nil, errors.Wrapf(readAllErr, "failed to read config file: %s", configFilename)
+	source, readErr := readConfigSource(configFilename)
+	if readErr != nil {
+		return nil, readErr
 	}
+	configContent := source.content
 
 	var config CloudCredentialConfig
-	configUnmarshalErr := json.Unmarshal(configContent, &config)
+	configUnmarshalErr := unmarshalConfig(configContent, &config)
 	if configUnmarshalErr != nil {
-		return nil, errors.Wrapf(configUnmarshalErr, "failed to unmarshal config file: %s", configFilename)
+		return nil, errors.Wrapf(configUnmarshalErr,
+			"failed to unmarshal config file: %s, run `alibaba-cloud-idaas validate-config` for details", configFilename)
 	}
 
 	// current we only know version1
@@ -89,10 +92,44 @@ func ReadCloudCredentialConfig(configFilename string) (*CloudCredentialConfig, e
 			"please consider upgrade alibaba-cloud-idaas, get latest version from: %s",
 			config.Version, constants.UrlIdaasProduct)
 	}
+	if err := config.resolveProfiles(configContent); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve profiles in config file: %s", configFilename)
+	}
+	if err := config.resolveKmsProfiles(configFilename); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve kms profiles in config file: %s", configFilename)
+	}
+	config.ProfileSources = source.profileSources
+	if config.CacheBackend != "" {
+		utils.SetCacheBackendFromConfig(config.CacheBackend)
+	}
 
 	return &config, nil
 }
 
+// unmarshalConfig typo like client_assertion_signer is rejected, instead of ignored silently
+func unmarshalConfig(configContent []byte, config *CloudCredentialConfig) error {
+	decoder := json.NewDecoder(bytes.NewReader(configContent))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(config)
+}
+
+// ApplyDefaultConfigCacheBackend applies cache backend from default config file, for commands without profile
+func ApplyDefaultConfigCacheBackend() {
+	configFilename, err := GetDefaultCloudCredentialConfigFile()
+	if err != nil {
+		idaaslog.Debug.PrintfLn("Get default config file failed: %v", err)
+		return
+	}
+	cloudCredentialConfig, err := ReadCloudCredentialConfig(configFilename)
+	if err != nil {
+		idaaslog.Debug.PrintfLn("Read default config file: %s failed: %v", configFilename, err)
+		return
+	}
+	if cloudCredentialConfig != nil {
+		cloudCredentialConfig.ApplyCacheEncryption()
+	}
+}
+
 func GetDefaultCloudCredentialConfigFile() (string, error) {
 	defaultConfigFileFromEnv := os.Getenv(constants.EnvConfigFile)
 	if defaultConfigFileFromEnv != "" {
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// resolveProfiles merges `extends` parent profiles, and replaces token provider `ref` with shared `token_providers`,
+// profiles reference the same token provider have the same cache key, so one login serves all of them
+func (c *CloudCredentialConfig) resolveProfiles(configContent []byte) error {
+	var rawConfig struct {
+		Profile map[string]map[string]interface{} `json:"profile"`
+	}
+	if err := json.Unmarshal(configContent, &rawConfig); err != nil {
+		return errors.Wrap(err, "failed to unmarshal profiles")
+	}
+	for name, tokenProvider := range c.TokenProviders {
+		if tokenProvider != nil && tokenProvider.Ref != "" {
+			return errors.Errorf("token provider: %s cannot reference another token provider: %s", name, tokenProvider.Ref)
+		}
+	}
+	c.ProfileInherits = map[string][]string{}
+	for name, cloudStsConfig := range c.Profile {
+		if cloudStsConfig == nil {
+			continue
+		}
+		if cloudStsConfig.Extends != "" {
+			c.ProfileInherits[name] = append(c.ProfileInherits[name], "extends: "+cloudStsConfig.Extends)
+			mergedProfile, err := mergeProfile(rawConfig.Profile, name, nil)
+			if err != nil {
+				return err
+			}
+			mergedProfileJson, err := json.Marshal(mergedProfile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal profile: %s", name)
+			}
+			var mergedCloudStsConfig CloudStsConfig
+			if err = json.Unmarshal(mergedProfileJson, &mergedCloudStsConfig); err != nil {
+				return errors.Wrapf(err, "failed to unmarshal profile: %s", name)
+			}
+			cloudStsConfig = &mergedCloudStsConfig
+			c.Profile[name] = cloudStsConfig
+		}
+		if err := c.resolveTokenProviderRefs(name, cloudStsConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeProfile child profile overrides parent, JSON objects are merged recursively, `null` removes parent value
+func mergeProfile(rawProfiles map[string]map[string]interface{}, name string, chain []string) (map[string]interface{}, error) {
+	if slices.Contains(chain, name) {
+		return nil, errors.Errorf("profile extends cycle: %s", strings.Join(slices.Concat(chain, []string{name}), " -> "))
+	}
+	rawProfile, ok := rawProfiles[name]
+	if !ok || rawProfile == nil {
+		return nil, errors.Errorf("profile: %s extends profile: %s not found", chain[len(chain)-1], name)
+	}
+	parent, _ := rawProfile["extends"].(string)
+	if parent == "" {
+		return rawProfile, nil
+	}
+	parentProfile, err := mergeProfile(rawProfiles, parent, slices.Concat(chain, []string{name}))
+	if err != nil {
+		return nil, err
+	}
+	return mergeJsonObject(parentProfile, rawProfile), nil
+}
+
+func mergeJsonObject(parent, child map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(parent)+len(child))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range child {
+		childObject, isChildObject := value.(map[string]interface{})
+		parentObject, isParentObject := merged[key].(map[string]interface{})
+		if isChildObject && isParentObject {
+			merged[key] = mergeJsonObject(parentObject, childObject)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+func (c *CloudCredentialConfig) resolveTokenProviderRefs(profile string, cloudStsConfig *CloudStsConfig) error {
+	var tokenProviders []**OidcTokenProviderConfig
+	if cloudStsConfig.AlibabaCloud != nil {
+		tokenProviders = append(tokenProviders, &cloudStsConfig.AlibabaCloud.OidcTokenProvider)
+	}
+	if cloudStsConfig.Aws != nil {
+		tokenProviders = append(tokenProviders, &cloudStsConfig.Aws.OidcTokenProvider)
+	}
+	if cloudStsConfig.CloudAccount != nil {
+		tokenProviders = append(tokenProviders, &cloudStsConfig.CloudAccount.AccessTokenProvider)
+	}
+	if cloudStsConfig.Agent != nil {
+		tokenProviders = append(tokenProviders, &cloudStsConfig.Agent.AccessTokenProvider)
+	}
+	tokenProviders = append(tokenProviders, &cloudStsConfig.OidcToken)
+
+	for _, tokenProvider := range tokenProviders {
+		if *tokenProvider == nil || (*tokenProvider).Ref == "" {
+			continue
+		}
+		ref := (*tokenProvider).Ref
+		if **tokenProvider != (OidcTokenProviderConfig{Ref: ref}) {
+			return errors.Errorf("profile: %s token provider ref: %s cannot be used with other fields", profile, ref)
+		}
+		sharedTokenProvider := c.TokenProviders[ref]
+		if sharedTokenProvider == nil {
+			return errors.Errorf("profile: %s token provider: %s not found", profile, ref)
+		}
+		c.ProfileInherits[profile] = append(c.ProfileInherits[profile], "ref: "+ref)
+		// every profile has its own copy, so changes of one profile never leak into other profiles
+		copiedTokenProvider, err := deepCopyTokenProvider(sharedTokenProvider)
+		if err != nil {
+			return errors.Wrapf(err, "profile: %s token provider: %s copy failed", profile, ref)
+		}
+		*tokenProvider = copiedTokenProvider
+	}
+	return nil
+}
+
+// deepCopyTokenProvider MUST be called before resolveKmsProfiles, unexported fields are not copied
+func deepCopyTokenProvider(tokenProvider *OidcTokenProviderConfig) (*OidcTokenProviderConfig, error) {
+	tokenProviderJson, err := json.Marshal(tokenProvider)
+	if err != nil {
+		return nil, err
+	}
+	var copiedTokenProvider OidcTokenProviderConfig
+	if err = json.Unmarshal(tokenProviderJson, &copiedTokenProvider); err != nil {
+		return nil, err
+	}
+	return &copiedTokenProvider, nil
+}
+
+// resolveKmsProfiles KMS profile is found in the config file of the signer, profiles MUST NOT
+// fetch KMS credentials from themselves, e.g. p1 -> kms profile p2 -> kms profile p1
+func (c *CloudCredentialConfig) resolveKmsProfiles(configFilename string) error {
+	walkKmsConfigs(reflect.ValueOf(c), func(kmsConfig *ExSignerKmsConfig) {
+		kmsConfig.configFilename = configFilename
+	})
+	profiles := make([]string, 0, len(c.Profile))
+	for profile := range c.Profile {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		if err := c.checkKmsProfileCycle(profile, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CloudCredentialConfig) checkKmsProfileCycle(profile string, visited []string) error {
+	visited = append(visited, profile)
+	if slices.Contains(visited[:len(visited)-1], profile) {
+		return errors.Errorf("kms profile cycle: %s", strings.Join(visited, " -> "))
+	}
+	cloudStsConfig := c.Profile[profile]
+	if cloudStsConfig == nil {
+		return nil
+	}
+	var err error
+	walkKmsConfigs(reflect.ValueOf(cloudStsConfig), func(kmsConfig *ExSignerKmsConfig) {
+		if err == nil && kmsConfig.Profile != "" {
+			err = c.checkKmsProfileCycle(kmsConfig.Profile, visited)
+		}
+	})
+	return err
+}
+
+// walkKmsConfigs calls fn with every KMS signer config in value
+func walkKmsConfigs(value reflect.Value, fn func(kmsConfig *ExSignerKmsConfig)) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return
+		}
+		if kmsConfig, ok := value.Interface().(*ExSignerKmsConfig); ok {
+			fn(kmsConfig)
+			return
+		}
+		walkKmsConfigs(value.Elem(), fn)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				walkKmsConfigs(value.Field(i), fn)
+			}
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			walkKmsConfigs(value.MapIndex(key), fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			walkKmsConfigs(value.Index(i), fn)
+		}
+	}
+}
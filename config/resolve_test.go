@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, configJson string) string {
+	configFilename := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFilename, []byte(configJson), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return configFilename
+}
+
+func TestResolveProfiles(t *testing.T) {
+	configFilename := writeConfig(t, `{"version": "1",
+"token_providers": {"corp": {"device_code": {"issuer": "https://example.com", "client_id": "client1"}}},
+"profile": {
+  "base": {"comment": "base", "aws_sts": {"region": "us-east-1", "role_arn": "arn:aws:iam::1:role/base", "oidc_token_provider": {"ref": "corp"}}},
+  "dev": {"extends": "base", "aws_sts": {"role_arn": "arn:aws:iam::1:role/dev"}},
+  "dev2": {"extends": "dev", "comment": null, "aws_sts": {"duration_seconds": 900}},
+  "inline": {"oidc_token": {"device_code": {"issuer": "https://example.com", "client_id": "client1"}}}
+}}`)
+	cloudCredentialConfig, err := LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev2 := cloudCredentialConfig.Profile["dev2"]
+	if dev2.Aws.Region != "us-east-1" || dev2.Aws.RoleArn != "arn:aws:iam::1:role/dev" ||
+		dev2.Aws.DurationSeconds != 900 || dev2.Comment != "" || dev2.Extends != "dev" {
+		t.Fatalf("unexpected merged profile: %+v, aws: %+v", dev2, dev2.Aws)
+	}
+	cacheKey := cloudCredentialConfig.Profile["base"].Aws.OidcTokenProvider.GetCacheKey()
+	for _, name := range []string{"dev", "dev2"} {
+		if key := cloudCredentialConfig.Profile[name].Aws.OidcTokenProvider.GetCacheKey(); key != cacheKey {
+			t.Errorf("profile %s cache key: %s, expected: %s", name, key, cacheKey)
+		}
+	}
+	if key := cloudCredentialConfig.Profile["inline"].OidcToken.GetCacheKey(); key != cacheKey {
+		t.Errorf("inline cache key: %s, expected: %s", key, cacheKey)
+	}
+	// profiles reference the same token provider do not share one pointer
+	cloudCredentialConfig.Profile["dev"].Aws.OidcTokenProvider.TokenType = "access_token"
+	if tokenType := cloudCredentialConfig.Profile["base"].Aws.OidcTokenProvider.TokenType; tokenType != "" {
+		t.Errorf("token provider change of profile dev leaks into profile base: %s", tokenType)
+	}
+	if inherits := strings.Join(cloudCredentialConfig.ProfileInherits["dev2"], ", "); inherits != "extends: dev, ref: corp" {
+		t.Errorf("unexpected profile dev2 inherits: %s", inherits)
+	}
+	if inherits := cloudCredentialConfig.ProfileInherits["inline"]; len(inherits) != 0 {
+		t.Errorf("unexpected profile inline inherits: %v", inherits)
+	}
+
+	tests := []struct {
+		name       string
+		configJson string
+		errorPart  string
+	}{
+		{"cycle", `{"version": "1", "profile": {"a": {"extends": "b"}, "b": {"extends": "c"}, "c": {"extends": "a"}}}`,
+			"profile extends cycle"},
+		{"parent_not_found", `{"version": "1", "profile": {"a": {"extends": "b"}}}`,
+			"profile: a extends profile: b not found"},
+		{"ref_not_found", `{"version": "1", "profile": {"a": {"oidc_token": {"ref": "corp"}}}}`,
+			"token provider: corp not found"},
+		{"ref_with_fields", `{"version": "1", "token_providers": {"corp": {}}, "profile": {"a": {"oidc_token": {"ref": "corp", "token_type": "access_token"}}}}`,
+			"cannot be used with other fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCloudCredentialConfig(writeConfig(t, tt.configJson))
+			if err == nil || !strings.Contains(err.Error(), tt.errorPart) {
+				t.Fatalf("error: %v, expected: %s", err, tt.errorPart)
+			}
+		})
+	}
+}
+
+func TestResolveKmsProfiles(t *testing.T) {
+	kmsProfile := func(name, kmsProfile string) string {
+		return `"` + name + `": {"aws_sts": {"region": "us-east-1", "role_arn": "arn:aws:iam::1:role/r1", "oidc_token_provider":
+{"client_credentials": {"token_endpoint": "https://example.com/token", "client_id": "client1", "client_assertion_singer":
+{"algorithm": "RS256", "kms": {"provider": "aws", "key_id": "key1", "region": "us-east-1", "profile": "` + kmsProfile + `"}}}}}}`
+	}
+	configFilename := writeConfig(t, `{"version": "1", "profile": {`+kmsProfile("p1", "p2")+`,
+"p2": {"aws_sts": {"region": "us-east-1", "role_arn": "arn:aws:iam::1:role/r2", "oidc_token_provider": {"device_code": {"issuer": "https://example.com", "client_id": "client1"}}}}}}`)
+	cloudCredentialConfig, err := LoadCloudCredentialConfig(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kmsConfig := cloudCredentialConfig.Profile["p1"].Aws.OidcTokenProvider.OidcTokenProviderClientCredentials.ClientAssertionSinger.Kms
+	if kmsConfig.configFilename != configFilename {
+		t.Errorf("kms config file: %s, expected: %s", kmsConfig.configFilename, configFilename)
+	}
+
+	configFilename = writeConfig(t, `{"version": "1", "profile": {`+kmsProfile("p1", "p2")+`,`+kmsProfile("p2", "p1")+`}}`)
+	if _, err = LoadCloudCredentialConfig(configFilename); err == nil || !strings.Contains(err.Error(), "kms profile cycle: p1 -> p2 -> p1") {
+		t.Errorf("kms profile cycle should be rejected, got: %v", err)
+	}
+}
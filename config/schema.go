@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const SchemaId = "https://github.com/aliyunidaas/alibaba-cloud-idaas/config.schema.json"
+
+// GenerateJsonSchema generates JSON Schema(draft 2020-12) from config structs, unknown fields are not allowed
+func GenerateJsonSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+	schema := typeSchema(reflect.TypeOf(CloudCredentialConfig{}), defs)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaId
+	schema["title"] = "alibaba-cloud-idaas config"
+	schema["$defs"] = defs
+	return schema
+}
+
+func typeSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": nullableSchema(typeSchema(t.Elem(), defs))}
+	case reflect.Struct:
+		if t == reflect.TypeOf(CloudCredentialConfig{}) {
+			return structSchema(t, defs)
+		}
+		if _, ok := defs[t.Name()]; !ok {
+			// placeholder first, struct may reference itself
+			defs[t.Name()] = map[string]interface{}{}
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for name, field := range jsonFields(t) {
+		properties[name] = nullableSchema(typeSchema(field.Type, defs))
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// nullableSchema field may be `null`, which is accepted by decoding and removes parent value in `extends` profile
+func nullableSchema(schema map[string]interface{}) map[string]interface{} {
+	if schemaType, ok := schema["type"].(string); ok {
+		schema["type"] = []string{schemaType, "null"}
+		return schema
+	}
+	if _, ok := schema["$ref"]; ok {
+		return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+	return schema
+}
+
+// jsonFields JSON name to struct field, fields without json tag are ignored
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+var simpleJsonPathName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// JsonPath appends name to JSON path, e.g. $.profile.default, $.profile["my.profile"]
+func JsonPath(path, name string) string {
+	if simpleJsonPathName.MatchString(name) {
+		return path + "." + name
+	}
+	return fmt.Sprintf("%s[%q]", path, name)
+}
@@ -1,11 +1,20 @@
 package config
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/aliyun/credentials-go/credentials"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/external"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/key_file"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/kms"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/pkcs11"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/yubikey_piv"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
 	"github.com/pkg/errors"
 )
 
@@ -38,8 +47,11 @@ func NewExJwtSignerFromConfig(conf *ExSingerConfig) (*signer.ExJwtSigner, error)
 	if conf.KeyFile != nil {
 		confs = append(confs, "key_file")
 	}
+	if conf.Kms != nil {
+		confs = append(confs, "kms")
+	}
 	if len(confs) == 0 {
-		return nil, errors.New("requires at least one; pkcs11, yubikey_piv or external_command")
+		return nil, errors.New("requires at least one; pkcs11, yubikey_piv, external_command, key_file or kms")
 	}
 	if len(confs) > 1 {
 		return nil, errors.Errorf("only one config may be specified, found: %v", confs)
@@ -55,6 +67,8 @@ func NewExJwtSignerFromConfig(conf *ExSingerConfig) (*signer.ExJwtSigner, error)
 		exSigner, exSignerErr = NewExCommandSignerFromConfig(conf.ExternalCommand)
 	} else if conf.KeyFile != nil {
 		exSigner, exSignerErr = NewKeyFileFromConfig(conf.KeyFile)
+	} else if conf.Kms != nil {
+		exSigner, exSignerErr = NewKmsSignerFromConfig(conf.Kms)
 	}
 	if exSignerErr != nil {
 		return nil, errors.Errorf("exsigner initialization error: %s, type: %s", exSignerErr, confs[0])
@@ -65,17 +79,138 @@ func NewExJwtSignerFromConfig(conf *ExSingerConfig) (*signer.ExJwtSigner, error)
 }
 
 func NewKeyFileFromConfig(conf *ExSingerKeyFileConfig) (*key_file.KeyFileSigner, error) {
-	return key_file.NewKeyFileSigner(conf.Key, conf.File, conf.Password)
+	password, err := utils.ResolveSecret(conf.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve key file password failed")
+	}
+	return key_file.NewKeyFileSigner(conf.Key, conf.File, password)
 }
 
 func NewExCommandSignerFromConfig(conf *ExSignerExternalCommandConfig) (*external.ExCommandSigner, error) {
-	return external.NewExCommandSigner(conf.Command, conf.Parameter)
+	return external.NewExCommandSigner(conf.Command, conf.Parameter, conf.Protocol)
 }
 
 func NewPkcs11SignerFromConfig(conf *ExSignerPkcs11Config) (*pkcs11.Pkcs11Signer, error) {
-	return pkcs11.NewPkcs11Signer(conf.LibraryPath, conf.TokenLabel, conf.Pin, conf.KeyLabel)
+	pin, err := utils.ResolveSecret(conf.Pin)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve PKCS#11 PIN failed")
+	}
+	return pkcs11.NewPkcs11Signer(conf.LibraryPath, conf.TokenLabel, pin, conf.KeyLabel)
 }
 
 func NewYubiKeyPivSignerFromConfig(conf *ExSignerYubikeyPivConfig) (*yubikey_piv.YubiKeyPivSigner, error) {
-	return yubikey_piv.NewYubiKeyPivSigner(conf.Slot, conf.Pin, conf.PinPolicy)
+	pin, err := utils.ResolveSecret(conf.Pin)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve YubiKey PIV PIN failed")
+	}
+	return yubikey_piv.NewYubiKeyPivSigner(conf.Slot, pin, conf.PinPolicy)
+}
+
+// ProfileKmsCredentialFetcher fetches KMS credentials from cloud STS token of profile in config file,
+// registered by main, config cannot import cloud
+var ProfileKmsCredentialFetcher func(configFilename, profile string) (*kms.Credential, error)
+
+func NewKmsSignerFromConfig(conf *ExSignerKmsConfig) (*kms.KmsSigner, error) {
+	credentialProvider, err := newKmsCredentialProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+	return kms.NewKmsSigner(&kms.KmsSignerOptions{
+		Provider:           conf.Provider,
+		KeyId:              conf.KeyId,
+		KeyVersionId:       conf.KeyVersionId,
+		Region:             conf.Region,
+		Endpoint:           conf.Endpoint,
+		CredentialProvider: credentialProvider,
+	})
+}
+
+func newKmsCredentialProvider(conf *ExSignerKmsConfig) (kms.CredentialProvider, error) {
+	if conf.Profile != "" && conf.OpenApi != nil {
+		return nil, errors.New("only one of kms profile and open_api may be specified")
+	}
+	if conf.Profile != "" {
+		if ProfileKmsCredentialFetcher == nil {
+			return nil, errors.New("kms profile credential fetcher is not registered")
+		}
+		return func() (*kms.Credential, error) {
+			return ProfileKmsCredentialFetcher(conf.configFilename, conf.Profile)
+		}, nil
+	}
+	if conf.OpenApi != nil {
+		if conf.Provider != kms.ProviderAlibabaCloud {
+			return nil, errors.Errorf("kms open_api credential requires provider %s", kms.ProviderAlibabaCloud)
+		}
+		credentialsConfig, err := NewCredentialsConfig(conf.OpenApi)
+		if err != nil {
+			return nil, err
+		}
+		if credentialsConfig == nil {
+			return nil, errors.New("kms open_api credential type is empty")
+		}
+		credential, err := credentials.NewCredential(credentialsConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating kms credential")
+		}
+		return func() (*kms.Credential, error) {
+			credentialModel, err := credential.GetCredential()
+			if err != nil {
+				return nil, err
+			}
+			return &kms.Credential{
+				AccessKeyId:     tea.StringValue(credentialModel.AccessKeyId),
+				AccessKeySecret: tea.StringValue(credentialModel.AccessKeySecret),
+				SecurityToken:   tea.StringValue(credentialModel.SecurityToken),
+			}, nil
+		}, nil
+	}
+	return nil, errors.New("requires at least one; kms profile or open_api")
+}
+
+// NewCacheKeyOptionsFromConfig signer is created when cache key is wrapped or unwrapped only
+func NewCacheKeyOptionsFromConfig(conf *CacheEncryptionConfig) *utils.CacheKeyOptions {
+	if conf == nil || conf.Mode == "" || conf.Mode == utils.CacheEncryptionModeDefault {
+		return nil
+	}
+	cacheKeyOptions := &utils.CacheKeyOptions{
+		Id:   conf.Digest(),
+		Mode: conf.Mode,
+		Kdf:  conf.Kdf,
+	}
+	if conf.Signer != nil {
+		cacheKeyOptions.Sign = func(challenge []byte) ([]byte, string, error) {
+			return signCacheKeyChallenge(conf.Signer, challenge)
+		}
+	}
+	return cacheKeyOptions
+}
+
+func signCacheKeyChallenge(conf *ExSingerConfig, challenge []byte) ([]byte, string, error) {
+	exJwtSigner, err := NewExJwtSignerFromConfig(conf)
+	if err != nil {
+		return nil, "", err
+	}
+	alg, err := signer.ParseJwtSignAlgorithm(conf.Algorithm)
+	if err != nil {
+		return nil, "", err
+	}
+	// PKCS#1 v1.5 signature is deterministic, RSASSA-PSS and ECDSA signatures are not
+	if !alg.IsRsa() {
+		return nil, "", errors.Errorf("cache encryption signer requires RSA algorithm, actual: %s", conf.Algorithm)
+	}
+	exSigner := exJwtSigner.GetExtSinger()
+	publicKey, err := exSigner.Public()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "get signer public key failed")
+	}
+	publicKeyDer, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "marshal signer public key failed")
+	}
+	publicKeyDigest := sha256.Sum256(publicKeyDer)
+	signature, err := exSigner.Sign(rand.Reader, alg, challenge)
+	if err != nil {
+		return nil, "", err
+	}
+	return signature, hex.EncodeToString(publicKeyDigest[:]), nil
 }
@@ -0,0 +1,508 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/external"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/kms"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	ProblemLevelError   = "error"
+	ProblemLevelWarning = "warning"
+)
+
+// Problem config problem, Path is JSON path, e.g. $.profile.default.aws_sts.region,
+// File is set when problem is in profile from profile.d
+type Problem struct {
+	Level   string `json:"level"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+}
+
+// ValidateConfigFile reports every problem of config file, returns error only when config file cannot be read
+func ValidateConfigFile(configFilename string) ([]*Problem, error) {
+	v := &validator{}
+	fileInfo, err := os.Stat(configFilename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat config file: %s failed", configFilename)
+	}
+	v.checkPermission("$", configFilename, fileInfo)
+	profileFiles, err := ListProfileDirFiles(configFilename)
+	if err != nil {
+		return nil, err
+	}
+	for _, profileFile := range profileFiles {
+		if profileFileInfo, err := os.Stat(profileFile); err == nil {
+			v.checkPermission("$", profileFile, profileFileInfo)
+		}
+	}
+	source, err := readConfigSource(configFilename)
+	if err != nil {
+		v.errorf("$", "%s", err)
+		return v.sortedProblems(), nil
+	}
+	configContent := source.content
+
+	var rawConfig interface{}
+	if err = json.Unmarshal(configContent, &rawConfig); err != nil {
+		v.errorf("$", "invalid JSON: %s", err)
+		return v.sortedProblems(), nil
+	}
+	// every unknown field is reported with its path, loading config stops at the first one
+	var unknownFields []string
+	findUnknownFields("$", rawConfig, reflect.TypeOf(CloudCredentialConfig{}), &unknownFields)
+	for _, unknownField := range unknownFields {
+		v.errorf(unknownField, "unknown field")
+	}
+	var config CloudCredentialConfig
+	if err = json.Unmarshal(configContent, &config); err != nil {
+		var unmarshalTypeError *json.UnmarshalTypeError
+		if errors.As(err, &unmarshalTypeError) {
+			v.errorf("$."+unmarshalTypeError.Field, "invalid type: %s, expected: %s",
+				unmarshalTypeError.Value, unmarshalTypeError.Type)
+		} else {
+			v.errorf("$", "invalid config: %s", err)
+		}
+		return v.sortedProblems(), nil
+	}
+	if err = config.resolveProfiles(configContent); err != nil {
+		v.errorf("$.profile", "%s", err)
+	}
+	v.validateConfig(&config)
+	v.setProblemFiles(configFilename, source.profileSources)
+	return v.sortedProblems(), nil
+}
+
+// findUnknownFields appends JSON paths of fields which are not defined in config structs
+func findUnknownFields(path string, value interface{}, t reflect.Type, unknownFields *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for name, fieldValue := range object {
+			field, ok := fields[name]
+			if !ok {
+				*unknownFields = append(*unknownFields, JsonPath(path, name))
+				continue
+			}
+			findUnknownFields(JsonPath(path, name), fieldValue, field.Type, unknownFields)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for name, fieldValue := range object {
+			findUnknownFields(JsonPath(path, name), fieldValue, t.Elem(), unknownFields)
+		}
+	case reflect.Slice, reflect.Array:
+		array, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range array {
+			findUnknownFields(fmt.Sprintf("%s[%d]", path, i), item, t.Elem(), unknownFields)
+		}
+	}
+}
+
+func (v *validator) setProblemFiles(configFilename string, profileSources map[string]string) {
+	for name, profileSource := range profileSources {
+		if profileSource == configFilename {
+			continue
+		}
+		profilePath := JsonPath("$.profile", name)
+		for _, problem := range v.problems {
+			if problem.Path == profilePath || strings.HasPrefix(problem.Path, profilePath+".") ||
+				strings.HasPrefix(problem.Path, profilePath+"[") {
+				problem.File = profileSource
+			}
+		}
+	}
+}
+
+type validator struct {
+	config   *CloudCredentialConfig
+	problems []*Problem
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, &Problem{Level: ProblemLevelError, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, &Problem{Level: ProblemLevelWarning, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) sortedProblems() []*Problem {
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		return v.problems[i].Path < v.problems[j].Path
+	})
+	return v.problems
+}
+
+func (v *validator) required(path, name, value string) {
+	if value == "" {
+		v.errorf(JsonPath(path, name), "required field is missing")
+	}
+}
+
+func (v *validator) requiredObject(path, name string, isSet bool) {
+	if !isSet {
+		v.errorf(JsonPath(path, name), "required field is missing")
+	}
+}
+
+func (v *validator) enum(path, name, value string, values ...string) {
+	if value != "" && !slices.Contains(values, value) {
+		v.errorf(JsonPath(path, name), "invalid value: %s, must be one of: %s", value, strings.Join(values, ", "))
+	}
+}
+
+// oneOf checks exactly one of names is set
+func (v *validator) oneOf(path string, names []string, isSets ...bool) {
+	var setNames []string
+	for i, isSet := range isSets {
+		if isSet {
+			setNames = append(setNames, names[i])
+		}
+	}
+	if len(setNames) > 1 {
+		v.errorf(path, "only one of %s may be set, found: %s", strings.Join(names, ", "), strings.Join(setNames, ", "))
+	} else if len(setNames) == 0 {
+		v.errorf(path, "requires one of %s", strings.Join(names, ", "))
+	}
+}
+
+// file checks file exists, secret file(private key, token) should not be accessible by group or others
+func (v *validator) file(path, name, filename string, secret bool) {
+	if filename == "" {
+		return
+	}
+	fileInfo, err := os.Stat(filename)
+	if err != nil {
+		v.errorf(JsonPath(path, name), "file: %s is not accessible: %s", filename, err)
+		return
+	}
+	if fileInfo.IsDir() {
+		v.errorf(JsonPath(path, name), "file: %s is a directory", filename)
+		return
+	}
+	if secret {
+		v.checkPermission(JsonPath(path, name), filename, fileInfo)
+	}
+}
+
+// secret checks secret reference, secret itself is not resolved, env may be set when token is fetched
+func (v *validator) secret(path, name, value string) {
+	secretPath := JsonPath(path, name)
+	switch {
+	case strings.HasPrefix(value, utils.SecretRefEnv):
+		if envName := strings.TrimPrefix(value, utils.SecretRefEnv); envName == "" {
+			v.errorf(secretPath, "secret env name is empty")
+		} else if _, ok := os.LookupEnv(envName); !ok {
+			v.warnf(secretPath, "secret env: %s not set", envName)
+		}
+	case strings.HasPrefix(value, utils.SecretRefFile):
+		v.file(path, name, strings.TrimPrefix(value, utils.SecretRefFile), true)
+	case strings.HasPrefix(value, utils.SecretRefExec):
+		if err := utils.CheckSecretCommand(strings.TrimPrefix(value, utils.SecretRefExec)); err != nil {
+			v.errorf(secretPath, "%s", err)
+		}
+	case strings.HasPrefix(value, utils.SecretRefKeyring):
+		if _, _, err := utils.ParseKeyringSecretRef(value); err != nil {
+			v.errorf(secretPath, "%s", err)
+		}
+	}
+}
+
+func (v *validator) checkPermission(path, filename string, fileInfo os.FileInfo) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if perm := fileInfo.Mode().Perm(); perm&0077 != 0 {
+		v.warnf(path, "file: %s permission %04o is insecure, should be 0600", filename, perm)
+	}
+}
+
+func (v *validator) validateConfig(c *CloudCredentialConfig) {
+	v.config = c
+	if c.Version != Version1 {
+		v.errorf("$.version", "unsupported version: %s, must be %s", c.Version, Version1)
+	}
+	if c.CurrentProfile != "" && c.Profile[c.CurrentProfile] == nil {
+		v.errorf("$.current_profile", "profile: %s not found", c.CurrentProfile)
+	}
+	v.enum("$", "cache_backend", c.CacheBackend, utils.CacheBackendFile, utils.CacheBackendKeyring, utils.CacheBackendPass)
+	if c.CacheEncryption != nil {
+		v.validateCacheEncryption("$.cache_encryption", c.CacheEncryption)
+	}
+	if c.Serve != nil {
+		v.validateServe("$.serve", c.Serve)
+	}
+	for name, tokenProvider := range c.TokenProviders {
+		v.validateOidcTokenProvider(JsonPath("$.token_providers", name), tokenProvider)
+	}
+	for name, cloudStsConfig := range c.Profile {
+		path := JsonPath("$.profile", name)
+		if cloudStsConfig == nil {
+			v.errorf(path, "profile is null")
+			continue
+		}
+		v.validateProfile(path, cloudStsConfig)
+	}
+}
+
+func (v *validator) validateCacheEncryption(path string, c *CacheEncryptionConfig) {
+	v.enum(path, "mode", c.Mode, utils.CacheEncryptionModeDefault, utils.CacheEncryptionModePassphrase, utils.CacheEncryptionModeSigner)
+	v.enum(path, "kdf", c.Kdf, utils.CacheKdfArgon2id, utils.CacheKdfScrypt)
+	if c.Mode != utils.CacheEncryptionModeSigner {
+		return
+	}
+	v.requiredObject(path, "signer", c.Signer != nil)
+	if c.Signer != nil {
+		v.validateSigner(JsonPath(path, "signer"), c.Signer)
+		if alg, err := signer.ParseJwtSignAlgorithm(c.Signer.Algorithm); err == nil && !alg.IsRsa() {
+			v.errorf(JsonPath(path, "signer.algorithm"), "cache encryption signer requires RS256, RS384 or RS512")
+		}
+	}
+}
+
+func (v *validator) validateServe(path string, c *ServeConfig) {
+	for i, policy := range c.Policies {
+		policyPath := fmt.Sprintf("%s.policies[%d]", path, i)
+		if policy == nil {
+			v.errorf(policyPath, "policy is null")
+			continue
+		}
+		if policy.SsrfToken == "" && len(policy.Uids) == 0 {
+			v.errorf(policyPath, "requires one of ssrf_token, uids")
+		}
+		v.secret(policyPath, "ssrf_token", policy.SsrfToken)
+		if len(policy.Profiles) == 0 {
+			v.errorf(JsonPath(policyPath, "profiles"), "required field is missing")
+		}
+		for j, profile := range policy.Profiles {
+			if profile != "*" && v.config.Profile[profile] == nil {
+				v.errorf(fmt.Sprintf("%s.profiles[%d]", policyPath, j), "profile: %s not found", profile)
+			}
+		}
+	}
+}
+
+func (v *validator) validateProfile(path string, c *CloudStsConfig) {
+	if c.Agent == nil || c.AlibabaCloud != nil || c.Aws != nil || c.OidcToken != nil || c.CloudAccount != nil {
+		v.oneOf(path, []string{"alibaba_cloud_sts", "aws_sts", "oidc_token", "cloud_account_token"},
+			c.AlibabaCloud != nil, c.Aws != nil, c.OidcToken != nil, c.CloudAccount != nil)
+	}
+	if c.AlibabaCloud != nil {
+		alibabaCloudPath := JsonPath(path, "alibaba_cloud_sts")
+		if c.AlibabaCloud.StsEndpoint == "" && c.AlibabaCloud.Region == "" {
+			v.errorf(alibabaCloudPath, "requires one of sts_endpoint, region")
+		}
+		v.required(alibabaCloudPath, "oidc_provider_arn", c.AlibabaCloud.OidcProviderArn)
+		v.required(alibabaCloudPath, "role_arn", c.AlibabaCloud.RoleArn)
+		v.validateRequiredOidcTokenProvider(alibabaCloudPath, "oidc_token_provider", c.AlibabaCloud.OidcTokenProvider)
+	}
+	if c.Aws != nil {
+		awsPath := JsonPath(path, "aws_sts")
+		v.required(awsPath, "region", c.Aws.Region)
+		v.required(awsPath, "role_arn", c.Aws.RoleArn)
+		v.validateRequiredOidcTokenProvider(awsPath, "oidc_token_provider", c.Aws.OidcTokenProvider)
+	}
+	if c.OidcToken != nil {
+		v.validateRequiredOidcTokenProvider(path, "oidc_token", c.OidcToken)
+	}
+	if c.CloudAccount != nil {
+		cloudAccountPath := JsonPath(path, "cloud_account_token")
+		if _, err := c.CloudAccount.GetCloudAccountEndpoint(); err != nil {
+			v.errorf(cloudAccountPath, "%s", err)
+		}
+		v.validateRequiredOidcTokenProvider(cloudAccountPath, "access_token_provider", c.CloudAccount.AccessTokenProvider)
+	}
+	if c.Agent != nil {
+		agentPath := JsonPath(path, "agent")
+		v.required(agentPath, "instance_id", c.Agent.InstanceId)
+		v.required(agentPath, "developer_api_endpoint", c.Agent.DeveloperApiEndpoint)
+		v.validateRequiredOidcTokenProvider(agentPath, "access_token_provider", c.Agent.AccessTokenProvider)
+	}
+}
+
+// validateRequiredOidcTokenProvider shared token provider is validated in token_providers
+func (v *validator) validateRequiredOidcTokenProvider(path, name string, c *OidcTokenProviderConfig) {
+	v.requiredObject(path, name, c != nil)
+	if c == nil || c.Ref != "" {
+		return
+	}
+	for _, tokenProvider := range v.config.TokenProviders {
+		if tokenProvider == c {
+			return
+		}
+	}
+	v.validateOidcTokenProvider(JsonPath(path, name), c)
+}
+
+func (v *validator) validateOidcTokenProvider(path string, c *OidcTokenProviderConfig) {
+	if c == nil {
+		v.errorf(path, "token provider is null")
+		return
+	}
+	v.enum(path, "token_type", c.TokenType, "id_token", "access_token")
+	v.oneOf(path, []string{"client_credentials", "device_code", "authorization_code", "open_api"},
+		c.OidcTokenProviderClientCredentials != nil, c.OidcTokenProviderDeviceCode != nil,
+		c.OidcTokenProviderAuthorizationCode != nil, c.OpenApi != nil)
+	if clientCredentials := c.OidcTokenProviderClientCredentials; clientCredentials != nil {
+		clientCredentialsPath := JsonPath(path, "client_credentials")
+		v.required(clientCredentialsPath, "token_endpoint", clientCredentials.TokenEndpoint)
+		v.required(clientCredentialsPath, "client_id", clientCredentials.ClientId)
+		v.secret(clientCredentialsPath, "client_secret", clientCredentials.ClientSecret)
+		v.oneOf(clientCredentialsPath, []string{"client_secret", "client_assertion_singer",
+			"client_assertion_pkcs7", "client_assertion_private_ca", "client_assertion_oidc_token"},
+			clientCredentials.ClientSecret != "", clientCredentials.ClientAssertionSinger != nil,
+			clientCredentials.ClientAssertionPkcs7Config != nil, clientCredentials.ClientAssertionPrivateCaConfig != nil,
+			clientCredentials.ClientAssertionOidcTokenConfig != nil)
+		if clientCredentials.ClientAssertionSinger != nil {
+			v.validateSigner(JsonPath(clientCredentialsPath, "client_assertion_singer"), clientCredentials.ClientAssertionSinger)
+		}
+		if pkcs7 := clientCredentials.ClientAssertionPkcs7Config; pkcs7 != nil {
+			pkcs7Path := JsonPath(clientCredentialsPath, "client_assertion_pkcs7")
+			v.required(pkcs7Path, "provider", pkcs7.Provider)
+			v.enum(pkcs7Path, "provider", pkcs7.Provider, "alibaba_cloud", "aws", "azure")
+		}
+		if privateCa := clientCredentials.ClientAssertionPrivateCaConfig; privateCa != nil {
+			privateCaPath := JsonPath(clientCredentialsPath, "client_assertion_private_ca")
+			v.oneOf(privateCaPath, []string{"certificate", "certificate_file"},
+				privateCa.Certificate != "", privateCa.CertificateFile != "")
+			v.file(privateCaPath, "certificate_file", privateCa.CertificateFile, false)
+			v.file(privateCaPath, "certificate_chain_file", privateCa.CertificateChainFile, false)
+			if privateCa.CertificateKeySigner != nil {
+				v.validateSigner(JsonPath(privateCaPath, "certificate_key_signer"), privateCa.CertificateKeySigner)
+			}
+		}
+		if oidcToken := clientCredentials.ClientAssertionOidcTokenConfig; oidcToken != nil {
+			oidcTokenPath := JsonPath(clientCredentialsPath, "client_assertion_oidc_token")
+			v.required(oidcTokenPath, "provider", oidcToken.Provider)
+			v.enum(oidcTokenPath, "provider", oidcToken.Provider, "gcp", "custom")
+			if oidcToken.Provider == "custom" {
+				v.oneOf(oidcTokenPath, []string{"oidc_token", "oidc_token_file"},
+					oidcToken.OidcToken != "", oidcToken.OidcTokenFile != "")
+				v.file(oidcTokenPath, "oidc_token_file", oidcToken.OidcTokenFile, true)
+			}
+		}
+	}
+	if deviceCode := c.OidcTokenProviderDeviceCode; deviceCode != nil {
+		deviceCodePath := JsonPath(path, "device_code")
+		v.required(deviceCodePath, "issuer", deviceCode.Issuer)
+		v.required(deviceCodePath, "client_id", deviceCode.ClientId)
+		v.secret(deviceCodePath, "client_secret", deviceCode.ClientSecret)
+	}
+	if authorizationCode := c.OidcTokenProviderAuthorizationCode; authorizationCode != nil {
+		authorizationCodePath := JsonPath(path, "authorization_code")
+		v.required(authorizationCodePath, "issuer", authorizationCode.Issuer)
+		v.required(authorizationCodePath, "client_id", authorizationCode.ClientId)
+		v.secret(authorizationCodePath, "client_secret", authorizationCode.ClientSecret)
+		if authorizationCode.RedirectPort < 0 || authorizationCode.RedirectPort > 65535 {
+			v.errorf(JsonPath(authorizationCodePath, "redirect_port"), "invalid port: %d", authorizationCode.RedirectPort)
+		}
+	}
+	if c.OpenApi != nil {
+		v.validateOpenApi(JsonPath(path, "open_api"), c.OpenApi)
+	}
+}
+
+func (v *validator) validateOpenApi(path string, c *OpenApiConfig) {
+	if c.Type == "access_key" {
+		v.required(path, "access_key_id", c.AccessKeyId)
+		v.required(path, "access_key_secret", c.AccessKeySecret)
+	}
+	v.secret(path, "access_key_secret", c.AccessKeySecret)
+	v.file(path, "oidc_token", c.OIDCTokenFilePath, true)
+}
+
+func (v *validator) validateSigner(path string, c *ExSingerConfig) {
+	v.required(path, "algorithm", c.Algorithm)
+	if c.Algorithm != "" {
+		if _, err := signer.ParseJwtSignAlgorithm(c.Algorithm); err != nil {
+			v.errorf(JsonPath(path, "algorithm"), "invalid algorithm: %s", c.Algorithm)
+		}
+	}
+	v.oneOf(path, []string{"pkcs11", "yubikey_piv", "external_command", "key_file", "kms"},
+		c.Pkcs11 != nil, c.YubikeyPiv != nil, c.ExternalCommand != nil, c.KeyFile != nil, c.Kms != nil)
+	if c.Pkcs11 != nil {
+		pkcs11Path := JsonPath(path, "pkcs11")
+		v.required(pkcs11Path, "library_path", c.Pkcs11.LibraryPath)
+		v.file(pkcs11Path, "library_path", c.Pkcs11.LibraryPath, false)
+		v.required(pkcs11Path, "token_label", c.Pkcs11.TokenLabel)
+		v.required(pkcs11Path, "key_label", c.Pkcs11.KeyLabel)
+		v.secret(pkcs11Path, "pin", c.Pkcs11.Pin)
+	}
+	if c.YubikeyPiv != nil {
+		yubikeyPivPath := JsonPath(path, "yubikey_piv")
+		v.required(yubikeyPivPath, "slot", c.YubikeyPiv.Slot)
+		v.enum(yubikeyPivPath, "pin_policy", c.YubikeyPiv.PinPolicy, "none", "once", "always")
+		v.secret(yubikeyPivPath, "pin", c.YubikeyPiv.Pin)
+	}
+	if c.ExternalCommand != nil {
+		externalCommandPath := JsonPath(path, "external_command")
+		v.required(externalCommandPath, "command", c.ExternalCommand.Command)
+		v.required(externalCommandPath, "parameter", c.ExternalCommand.Parameter)
+		v.enum(externalCommandPath, "protocol", c.ExternalCommand.Protocol, external.ProtocolV1, external.ProtocolV2)
+		if c.ExternalCommand.Command != "" {
+			if _, err := exec.LookPath(c.ExternalCommand.Command); err != nil {
+				v.errorf(JsonPath(externalCommandPath, "command"), "command: %s is not executable: %s", c.ExternalCommand.Command, err)
+			}
+		}
+	}
+	if c.KeyFile != nil {
+		keyFilePath := JsonPath(path, "key_file")
+		v.oneOf(keyFilePath, []string{"key", "file"}, c.KeyFile.Key != "", c.KeyFile.File != "")
+		v.file(keyFilePath, "file", c.KeyFile.File, true)
+		v.secret(keyFilePath, "password", c.KeyFile.Password)
+	}
+	if c.Kms != nil {
+		v.validateKms(JsonPath(path, "kms"), c.Kms)
+	}
+}
+
+func (v *validator) validateKms(path string, c *ExSignerKmsConfig) {
+	v.required(path, "provider", c.Provider)
+	v.enum(path, "provider", c.Provider, kms.ProviderAlibabaCloud, kms.ProviderAws)
+	v.required(path, "key_id", c.KeyId)
+	if c.Provider == kms.ProviderAlibabaCloud {
+		v.required(path, "key_version_id", c.KeyVersionId)
+	}
+	if c.Provider == kms.ProviderAws {
+		v.required(path, "region", c.Region)
+	} else if c.Region == "" && c.Endpoint == "" {
+		v.errorf(path, "requires one of region, endpoint")
+	}
+	v.oneOf(path, []string{"profile", "open_api"}, c.Profile != "", c.OpenApi != nil)
+	if c.Profile != "" && v.config.Profile[c.Profile] == nil {
+		v.warnf(JsonPath(path, "profile"), "profile: %s not found, KMS credential profile is loaded from default config", c.Profile)
+	}
+	if c.OpenApi != nil {
+		if c.Provider != kms.ProviderAlibabaCloud {
+			v.errorf(JsonPath(path, "open_api"), "open_api requires provider %s", kms.ProviderAlibabaCloud)
+		}
+		v.validateOpenApi(JsonPath(path, "open_api"), c.OpenApi)
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, []byte("key"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configFilename := writeConfig(t, `{"version": "1", "unknown": 1,
+"profile": {
+  "both": {"aws_sts": {"region": "us-east-1", "regoin": "us-east-1", "role_arn": "arn"}, "oidc_token": {"device_code": {"issuer": "https://example.com", "client_id": "c"}}},
+  "signer": {"oidc_token": {"client_credentials": {"token_endpoint": "https://example.com/token", "client_id": "c",
+    "client_assertion_singer": {"algorithm": "XX256", "unknown_option": true, "key_file": {"file": "`+filepath.ToSlash(keyFile)+`"}}}}},
+  "missing": {"oidc_token": {"client_credentials": {"client_id": "c", "client_assertion_singer": {"algorithm": "ES256", "key_file": {"file": "/not/exists.pem"}}}}}
+}}`)
+	problems, err := ValidateConfigFile(configFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []string
+	for _, problem := range problems {
+		reported = append(reported, problem.Level+" "+problem.Path+": "+problem.Message)
+	}
+	signerPath := "$.profile.signer.oidc_token.client_credentials.client_assertion_singer"
+	expected := []string{
+		"error $.unknown: unknown field",
+		"error $.profile.both.aws_sts.regoin: unknown field",
+		"error " + signerPath + ".unknown_option: unknown field",
+		"error $.profile.both: only one of alibaba_cloud_sts, aws_sts, oidc_token, cloud_account_token may be set",
+		"error $.profile.both.aws_sts.oidc_token_provider: required field is missing",
+		"error " + signerPath + ".algorithm: invalid algorithm: XX256",
+		"error $.profile.missing.oidc_token.client_credentials.token_endpoint: required field is missing",
+		"error $.profile.missing.oidc_token.client_credentials.client_assertion_singer.key_file.file: file: /not/exists.pem is not accessible",
+	}
+	if runtime.GOOS != "windows" {
+		expected = append(expected, "warning "+signerPath+".key_file.file: file: "+filepath.ToSlash(keyFile)+" permission 0644 is insecure")
+	}
+	for _, e := range expected {
+		found := false
+		for _, r := range reported {
+			found = found || strings.HasPrefix(r, e)
+		}
+		if !found {
+			t.Errorf("problem: %s not reported, reported:\n%s", e, strings.Join(reported, "\n"))
+		}
+	}
+
+	if _, err = LoadCloudCredentialConfig(configFilename); err == nil || !strings.Contains(err.Error(), `unknown field "unknown"`) {
+		t.Errorf("load config should reject unknown field, error: %v", err)
+	}
+}
@@ -9,6 +9,8 @@ var (
 	ConfigRootDir  = getConfigRootDir()
 	ConfigIdaasDir = getConfigIdaasDir()
 	ConfigFilename = getConfigFilename()
+
+	CacheCategories = []string{CategoryOidc, CategoryOidcToken, CategoryCloudToken, CategoryTokenResponse, CategoryJwks}
 )
 
 const (
@@ -16,7 +18,9 @@ const (
 
 	DefaultAudienceAlibabaCloudIdaas = "alibaba-cloud-idaas-v2"
 
-	LogDir = "__log"
+	LogDir   = "__log"
+	AuditDir = "__audit"
+	LockDir  = "__lock"
 
 	// CategoryCloudToken cloud token, e.g. Alibaba Cloud STS Token
 	CategoryCloudToken = "cloud_token"
@@ -26,6 +30,8 @@ const (
 	CategoryOidcToken = "oidc_token"
 	// CategoryTokenResponse Token Response with Refresh Token
 	CategoryTokenResponse = "token_response"
+	// CategoryJwks JWKS for JWT verification
+	CategoryJwks = "jwks"
 
 	EnvUserAgent                         = "ALIBABA_CLOUD_IDAAS_USER_AGENT"
 	EnvUnsafeDebug                       = "ALIBABA_CLOUD_IDAAS_UNSAFE_DEBUG"
@@ -37,6 +43,9 @@ const (
 	EnvConfigFile                        = "ALIBABA_CLOUD_IDAAS_CONFIG_FILE"
 	EnvRootCertificates                  = "ALIBABA_CLOUD_IDAAS_ROOT_CERTIFICATES"
 	EnvUnsafeSkipCertificateVerification = "ALIBABA_CLOUD_IDAAS_UNSAFE_SKIP_CERTIFICATE_VERIFICATION"
+	EnvDisableAudit                      = "ALIBABA_CLOUD_IDAAS_DISABLE_AUDIT"
+	EnvCacheBackend                      = "ALIBABA_CLOUD_IDAAS_CACHE_BACKEND"
+	EnvCachePassphrase                   = "ALIBABA_CLOUD_IDAAS_CACHE_PASSPHRASE"
 
 	UrlIdaasProduct                = "https://www.aliyun.com/product/idaas"
 	UrlAlibabaCloudIdaasRepository = "https://github.com/aliyunidaas/alibaba-cloud-idaas"
@@ -0,0 +1,50 @@
+package idp
+
+import (
+	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+func FetchIdTokenAuthorizationCode(oidcTokenProviderAuthorizationCodeConfig *config.OidcTokenProviderAuthorizationCodeConfig,
+	fetchOptions *FetchOidcTokenOptions) (*oidc.TokenResponse, error) {
+	issuer := oidcTokenProviderAuthorizationCodeConfig.Issuer
+	clientSecret, err := utils.ResolveSecret(oidcTokenProviderAuthorizationCodeConfig.ClientSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve client secret failed")
+	}
+	options := &oidc.FetchAuthorizationCodeFlowOptions{
+		ClientId:     oidcTokenProviderAuthorizationCodeConfig.ClientId,
+		ClientSecret: clientSecret,
+		Scope:        oidcTokenProviderAuthorizationCodeConfig.Scope,
+		RedirectPort: oidcTokenProviderAuthorizationCodeConfig.RedirectPort,
+		RedirectPath: oidcTokenProviderAuthorizationCodeConfig.RedirectPath,
+		AutoOpenUrl:  oidcTokenProviderAuthorizationCodeConfig.AutoOpenUrl,
+		ForceNew:     fetchOptions.ForceNew,
+		CacheKey:     fetchOptions.CacheKey,
+	}
+
+	if !fetchOptions.ForceNew && fetchOptions.CacheKey != "" {
+		refreshTokenOptions := &oidc.FetchRefreshTokenOptions{
+			ClientId:     options.ClientId,
+			ClientSecret: options.ClientSecret,
+			ForceNew:     options.ForceNew,
+		}
+		tokenResponse := oidc.TryFetchTokenViaRefreshToken(issuer, fetchOptions.CacheKey, refreshTokenOptions)
+		if tokenResponse != nil {
+			idaaslog.Unsafe.PrintfLn("Try fetch token via refresh token response success %+v", tokenResponse)
+			return tokenResponse, nil
+		}
+	}
+
+	if err := checkInteractiveFlowAllowed("authorization code"); err != nil {
+		return nil, err
+	}
+	tokenResponse, err := oidc.FetchTokenViaAuthorizationCodeFlow(issuer, options)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed fetch id token via authorization code, issuer: %s", issuer)
+	}
+	return tokenResponse, nil
+}
@@ -3,13 +3,19 @@ package idp
 import (
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
 )
 
 func FetchAccessTokenClientCredentialsClientIdSecret(credentialConfig *config.OidcTokenProviderClientCredentialsConfig) (*oidc.TokenResponse, error) {
 	tokenEndpoint := credentialConfig.TokenEndpoint
+	clientSecret, err := utils.ResolveSecret(credentialConfig.ClientSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve client secret failed")
+	}
 	fetchTokenOptions := &oidc.FetchTokenOptions{
 		ClientId:     credentialConfig.ClientId,
-		ClientSecret: credentialConfig.ClientSecret,
+		ClientSecret: clientSecret,
 		GrantType:    oidc.GrantTypeClientCredentials,
 		Scope:        credentialConfig.Scope,
 	}
@@ -4,15 +4,20 @@ import (
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
 	"github.com/pkg/errors"
 )
 
 func FetchIdTokenDeviceCode(oidcTokenProviderDeviceCodeConfig *config.OidcTokenProviderDeviceCodeConfig,
 	fetchOptions *FetchOidcTokenOptions) (*oidc.TokenResponse, error) {
 	issuer := oidcTokenProviderDeviceCodeConfig.Issuer
+	clientSecret, err := utils.ResolveSecret(oidcTokenProviderDeviceCodeConfig.ClientSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve client secret failed")
+	}
 	options := &oidc.FetchDeviceCodeFlowOptions{
 		ClientId:     oidcTokenProviderDeviceCodeConfig.ClientId,
-		ClientSecret: oidcTokenProviderDeviceCodeConfig.ClientSecret,
+		ClientSecret: clientSecret,
 		Scope:        oidcTokenProviderDeviceCodeConfig.Scope,
 		ShowQrCode:   oidcTokenProviderDeviceCodeConfig.ShowQrCode,
 		SmallQrCode:  oidcTokenProviderDeviceCodeConfig.SmallQrCode,
@@ -22,13 +27,21 @@ func FetchIdTokenDeviceCode(oidcTokenProviderDeviceCodeConfig *config.OidcTokenP
 	}
 
 	if !fetchOptions.ForceNew && fetchOptions.CacheKey != "" {
-		tokenResponse := oidc.TryFetchTokenViaRefreshToken(issuer, fetchOptions.CacheKey, options)
+		refreshTokenOptions := &oidc.FetchRefreshTokenOptions{
+			ClientId:     options.ClientId,
+			ClientSecret: options.ClientSecret,
+			ForceNew:     options.ForceNew,
+		}
+		tokenResponse := oidc.TryFetchTokenViaRefreshToken(issuer, fetchOptions.CacheKey, refreshTokenOptions)
 		if tokenResponse != nil {
 			idaaslog.Unsafe.PrintfLn("Try fetch token via refresh token response success %+v", tokenResponse)
 			return tokenResponse, nil
 		}
 	}
 
+	if err := checkInteractiveFlowAllowed("device code"); err != nil {
+		return nil, err
+	}
 	tokenResponse, err := oidc.FetchTokenViaDeviceCodeFlow(issuer, options)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed fetch id token via device code, issuer: %s", issuer)
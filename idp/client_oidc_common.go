@@ -37,19 +37,25 @@ func FetchOidcToken(profile string, oidcTokenProviderConfig *config.OidcTokenPro
 		IsContentExpired: func(s *utils.StringWithTime) bool {
 			return isContentExpired(s)
 		},
-		ForceNew: options.ForceNew,
+		GetContentExpiration: getContentExpiration,
+		ForceNew:             options.ForceNew,
 	}
 
-	oidcTokenProviderId := oidcTokenProviderConfig.GetId()
-	cacheKey := fmt.Sprintf("%s_%s", oidcTokenProviderId, digest[0:32])
+	cacheKey := OidcTokenCacheKey(oidcTokenProviderConfig)
 	idaaslog.Debug.PrintfLn("Cache key: %s %s", constants.CategoryOidcToken, cacheKey)
 	jwt, err := utils.ReadCacheFileWithEncryptionCallback(
 		constants.CategoryOidcToken, cacheKey, readCacheFileOptions)
 	return jwt, err
 }
 
+// OidcTokenCacheKey cache key of category oidc_token, also used by cache and logout commands
+func OidcTokenCacheKey(oidcTokenProviderConfig *config.OidcTokenProviderConfig) string {
+	return fmt.Sprintf("%s_%s", oidcTokenProviderConfig.GetId(), oidcTokenProviderConfig.Digest()[0:32])
+}
+
 func FetchTokenResponse(oidcTokenProviderConfig *config.OidcTokenProviderConfig, options *FetchOidcTokenOptions) (*oidc.TokenResponse, error) {
 	hasOidcTokenProviderDeviceCode := oidcTokenProviderConfig.OidcTokenProviderDeviceCode != nil
+	hasOidcTokenProviderAuthorizationCode := oidcTokenProviderConfig.OidcTokenProviderAuthorizationCode != nil
 	hasOidcTokenProviderClientCredentials := oidcTokenProviderConfig.OidcTokenProviderClientCredentials != nil
 	hasOpenApi := oidcTokenProviderConfig.OpenApi != nil
 
@@ -57,6 +63,9 @@ func FetchTokenResponse(oidcTokenProviderConfig *config.OidcTokenProviderConfig,
 	if hasOidcTokenProviderDeviceCode {
 		configSet = append(configSet, "OidcTokenProviderDeviceCode")
 	}
+	if hasOidcTokenProviderAuthorizationCode {
+		configSet = append(configSet, "OidcTokenProviderAuthorizationCode")
+	}
 	if hasOidcTokenProviderClientCredentials {
 		configSet = append(configSet, "OidcTokenProviderClientCredentials")
 	}
@@ -71,6 +80,9 @@ func FetchTokenResponse(oidcTokenProviderConfig *config.OidcTokenProviderConfig,
 	if hasOidcTokenProviderDeviceCode {
 		tokenResponse, fetchOidcTokenErr := FetchIdTokenDeviceCode(oidcTokenProviderConfig.OidcTokenProviderDeviceCode, options)
 		return tokenResponse, fetchOidcTokenErr
+	} else if hasOidcTokenProviderAuthorizationCode {
+		tokenResponse, fetchOidcTokenErr := FetchIdTokenAuthorizationCode(oidcTokenProviderConfig.OidcTokenProviderAuthorizationCode, options)
+		return tokenResponse, fetchOidcTokenErr
 	} else if hasOidcTokenProviderClientCredentials {
 		tokenResponse, fetchOidcTokenErr := FetchAccessTokenClientCredentials(oidcTokenProviderConfig.OidcTokenProviderClientCredentials)
 		return tokenResponse, fetchOidcTokenErr
@@ -79,12 +91,13 @@ func FetchTokenResponse(oidcTokenProviderConfig *config.OidcTokenProviderConfig,
 		return tokenResponse, fetchOidcTokenErr
 	} else {
 		return nil, errors.New(
-			"OidcTokenProviderDeviceCode or OidcTokenProviderClientCredentials must set at least one")
+			"OidcTokenProviderDeviceCode, OidcTokenProviderAuthorizationCode, OidcTokenProviderClientCredentials or OpenApi must set at least one")
 	}
 }
 
 func fetchJwt(oidcTokenProviderConfig *config.OidcTokenProviderConfig, options *FetchOidcTokenOptions) (int, string, error) {
 	hasOidcTokenProviderDeviceCode := oidcTokenProviderConfig.OidcTokenProviderDeviceCode != nil
+	hasOidcTokenProviderAuthorizationCode := oidcTokenProviderConfig.OidcTokenProviderAuthorizationCode != nil
 	hasOidcTokenProviderClientCredentials := oidcTokenProviderConfig.OidcTokenProviderClientCredentials != nil
 	hasOpenApi := oidcTokenProviderConfig.OpenApi != nil
 
@@ -92,6 +105,9 @@ func fetchJwt(oidcTokenProviderConfig *config.OidcTokenProviderConfig, options *
 	if hasOidcTokenProviderDeviceCode {
 		configSet = append(configSet, "OidcTokenProviderDeviceCode")
 	}
+	if hasOidcTokenProviderAuthorizationCode {
+		configSet = append(configSet, "OidcTokenProviderAuthorizationCode")
+	}
 	if hasOidcTokenProviderClientCredentials {
 		configSet = append(configSet, "OidcTokenProviderClientCredentials")
 	}
@@ -115,6 +131,16 @@ func fetchJwt(oidcTokenProviderConfig *config.OidcTokenProviderConfig, options *
 				oidcToken = tokenResponse.IdToken
 			}
 		}
+	} else if hasOidcTokenProviderAuthorizationCode {
+		tokenResponse, fetchOidcTokenErr = FetchIdTokenAuthorizationCode(oidcTokenProviderConfig.OidcTokenProviderAuthorizationCode, options)
+		isAccessToken := oidcTokenProviderConfig.TokenType == oidc.TokenAccessToken
+		if tokenResponse != nil {
+			if isAccessToken {
+				oidcToken = tokenResponse.AccessToken
+			} else {
+				oidcToken = tokenResponse.IdToken
+			}
+		}
 	} else if hasOidcTokenProviderClientCredentials {
 		tokenResponse, fetchOidcTokenErr = FetchAccessTokenClientCredentials(oidcTokenProviderConfig.OidcTokenProviderClientCredentials)
 		if tokenResponse != nil {
@@ -127,7 +153,7 @@ func fetchJwt(oidcTokenProviderConfig *config.OidcTokenProviderConfig, options *
 		}
 	} else {
 		return 600, "", errors.New(
-			"OidcTokenProviderDeviceCode or OidcTokenProviderClientCredentials must set at least one")
+			"OidcTokenProviderDeviceCode, OidcTokenProviderAuthorizationCode, OidcTokenProviderClientCredentials or OpenApi must set at least one")
 	}
 	if fetchOidcTokenErr != nil {
 		return 600, "", fetchOidcTokenErr
@@ -145,6 +171,14 @@ func isContentExpiringOrExpired(s *utils.StringWithTime) bool {
 	return !valid
 }
 
+func getContentExpiration(content string) time.Time {
+	jwtTokenClaim, err := ParseJwtTokenClaim(content)
+	if err != nil || jwtTokenClaim.ExpirationAt <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(jwtTokenClaim.ExpirationAt, 0)
+}
+
 func isContentExpired(s *utils.StringWithTime) bool {
 	jwtTokenClaim, err := ParseJwtTokenClaim(s.Content)
 	if err != nil {
@@ -161,6 +195,7 @@ type SimpleJwtClaims struct {
 	Subject      string `json:"sub"`
 	IssueAt      int64  `json:"iat"` // Unix Epoch(seconds)
 	ExpirationAt int64  `json:"exp"` // Unix Epoch(seconds)
+	JwtId        string `json:"jti"`
 }
 
 func (t *SimpleJwtClaims) IsValidAtLeastThreshold(thresholdDuration time.Duration) bool {
@@ -13,8 +13,12 @@ import (
 )
 
 func FetchAccessTokenOpenApi(openApiConfig *config.OpenApiConfig) (*oidc.TokenResponse, error) {
-	credentialsConfig := convertToCredentialConfig(openApiConfig)
-	idaaslog.Unsafe.PrintfLn("CredentialsConfig: %v", credentialsConfig)
+	credentialsConfig, err := config.NewCredentialsConfig(openApiConfig)
+	if err != nil {
+		return nil, err
+	}
+	// credentials config is not logged, it contains resolved access key secret
+	idaaslog.Unsafe.PrintfLn("CredentialsConfig type: %s", openApiConfig.Type)
 	credential, err := credentials.NewCredential(credentialsConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating credential")
@@ -59,63 +63,3 @@ func FetchAccessTokenOpenApi(openApiConfig *config.OpenApiConfig) (*oidc.TokenRe
 
 	return oidcTokenResponse, nil
 }
-
-func convertToCredentialConfig(openApiConfig *config.OpenApiConfig) *credentials.Config {
-	if openApiConfig.Type == "" {
-		return nil
-	}
-
-	credentialsConfig := new(credentials.Config)
-
-	if openApiConfig.Type != "" {
-		credentialsConfig.SetType(openApiConfig.Type)
-	}
-	if openApiConfig.AccessKeyId != "" {
-		credentialsConfig.SetAccessKeyId(openApiConfig.AccessKeyId)
-	}
-	if openApiConfig.AccessKeySecret != "" {
-		credentialsConfig.SetAccessKeySecret(openApiConfig.AccessKeySecret)
-	}
-	if openApiConfig.SecurityToken != "" {
-		credentialsConfig.SetSecurityToken(openApiConfig.SecurityToken)
-	}
-
-	if openApiConfig.OIDCProviderArn != "" {
-		credentialsConfig.SetOIDCProviderArn(openApiConfig.OIDCProviderArn)
-	}
-	if openApiConfig.OIDCTokenFilePath != "" {
-		credentialsConfig.SetOIDCTokenFilePath(openApiConfig.OIDCTokenFilePath)
-	}
-	if openApiConfig.RoleArn != "" {
-		credentialsConfig.SetRoleArn(openApiConfig.RoleArn)
-	}
-
-	if openApiConfig.RoleArn != "" {
-		credentialsConfig.SetRoleArn(openApiConfig.RoleArn)
-	}
-	if openApiConfig.RoleSessionName != "" {
-		credentialsConfig.SetRoleSessionName(openApiConfig.RoleSessionName)
-	}
-	if openApiConfig.RoleSessionExpiration != 0 {
-		credentialsConfig.SetRoleSessionExpiration(openApiConfig.RoleSessionExpiration)
-	}
-	if openApiConfig.Policy != "" {
-		credentialsConfig.SetPolicy(openApiConfig.Policy)
-	}
-	if openApiConfig.ExternalId != "" {
-		credentialsConfig.SetExternalId(openApiConfig.ExternalId)
-	}
-	if openApiConfig.STSEndpoint != "" {
-		credentialsConfig.SetSTSEndpoint(openApiConfig.STSEndpoint)
-	}
-
-	if openApiConfig.RoleName != "" {
-		credentialsConfig.SetRoleName(openApiConfig.RoleName)
-	}
-
-	if openApiConfig.Url != "" {
-		credentialsConfig.SetURLCredential(openApiConfig.Url)
-	}
-
-	return credentialsConfig
-}
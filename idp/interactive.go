@@ -0,0 +1,27 @@
+package idp
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// interactive flows(device code, authorization code) block on user action,
+// they should be disabled when running in background, e.g. agent
+var interactiveFlowDisabled atomic.Bool
+
+func DisableInteractiveFlow() {
+	interactiveFlowDisabled.Store(true)
+}
+
+func IsInteractiveFlowDisabled() bool {
+	return interactiveFlowDisabled.Load()
+}
+
+func checkInteractiveFlowAllowed(flow string) error {
+	if IsInteractiveFlowDisabled() {
+		return errors.Errorf("%s flow requires user interaction, which is disabled, "+
+			"please login via `alibaba-cloud-idaas show-token` first", flow)
+	}
+	return nil
+}
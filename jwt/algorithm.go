@@ -0,0 +1,103 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"math/big"
+
+	// register hash functions
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// algorithms, specification: RFC7518, RFC8037
+const (
+	RS256 = "RS256"
+	RS384 = "RS384"
+	RS512 = "RS512"
+	PS256 = "PS256"
+	PS384 = "PS384"
+	PS512 = "PS512"
+	ES256 = "ES256"
+	ES384 = "ES384"
+	ES512 = "ES512"
+	EdDSA = "EdDSA"
+)
+
+// SupportedAlgorithms `none` and HMAC algorithms are never supported
+var SupportedAlgorithms = []string{RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512, EdDSA}
+
+func getAlgorithmHash(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case RS256, PS256, ES256:
+		return crypto.SHA256, true
+	case RS384, PS384, ES384:
+		return crypto.SHA384, true
+	case RS512, PS512, ES512:
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// isKeyCompatible checks public key type and curve for algorithm
+func isKeyCompatible(alg string, publicKey crypto.PublicKey) bool {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return alg == RS256 || alg == RS384 || alg == RS512 || alg == PS256 || alg == PS384 || alg == PS512
+	case *ecdsa.PublicKey:
+		switch alg {
+		case ES256:
+			return key.Curve.Params().BitSize == 256
+		case ES384:
+			return key.Curve.Params().BitSize == 384
+		case ES512:
+			return key.Curve.Params().BitSize == 521
+		}
+	case ed25519.PublicKey:
+		return alg == EdDSA
+	}
+	return false
+}
+
+func verifySignature(alg string, publicKey crypto.PublicKey, signingInput, signature []byte) error {
+	if !isKeyCompatible(alg, publicKey) {
+		return newValidationError(ErrorCodeInvalidSignature, "key type %T is not compatible with algorithm %s", publicKey, alg)
+	}
+	if alg == EdDSA {
+		if !ed25519.Verify(publicKey.(ed25519.PublicKey), signingInput, signature) {
+			return newValidationError(ErrorCodeInvalidSignature, "EdDSA signature verification failed")
+		}
+		return nil
+	}
+	hash, _ := getAlgorithmHash(alg)
+	hasher := hash.New()
+	hasher.Write(signingInput)
+	digest := hasher.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		var err error
+		if alg == PS256 || alg == PS384 || alg == PS512 {
+			err = rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			err = rsa.VerifyPKCS1v15(key, hash, digest, signature)
+		}
+		if err != nil {
+			return newValidationError(ErrorCodeInvalidSignature, "%s signature verification failed: %v", alg, err)
+		}
+	case *ecdsa.PublicKey:
+		// JWS ECDSA signature is R || S, not ASN.1 DER
+		keySize := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*keySize {
+			return newValidationError(ErrorCodeInvalidSignature, "%s signature length %d is invalid", alg, len(signature))
+		}
+		r := new(big.Int).SetBytes(signature[:keySize])
+		s := new(big.Int).SetBytes(signature[keySize:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return newValidationError(ErrorCodeInvalidSignature, "%s signature verification failed", alg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package jwt
+
+import (
+	"fmt"
+)
+
+// error codes for machine-readable output
+const (
+	ErrorCodeMalformed            = "malformed"
+	ErrorCodeUnsupportedAlgorithm = "unsupported_algorithm"
+	ErrorCodeKeyNotFound          = "key_not_found"
+	ErrorCodeInvalidSignature     = "invalid_signature"
+	ErrorCodeExpired              = "expired"
+	ErrorCodeMissingExpiration    = "missing_expiration"
+	ErrorCodeNotYetValid          = "not_yet_valid"
+	ErrorCodeIssuedInFuture       = "issued_in_future"
+	ErrorCodeInvalidIssuer        = "invalid_issuer"
+	ErrorCodeInvalidAudience      = "invalid_audience"
+)
+
+// ValidationError JWT is invalid, Code is one of ErrorCode*
+type ValidationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func newValidationError(code, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,232 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Jwk JSON Web Key, specification: RFC7517, RFC7518, RFC8037
+type Jwk struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+	// RSA
+	Modulus  string `json:"n,omitempty"`
+	Exponent string `json:"e,omitempty"`
+	// EC and OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// Jwks JSON Web Key Set
+type Jwks struct {
+	Keys []*Jwk `json:"keys"`
+}
+
+func ParseJwks(data []byte) (*Jwks, error) {
+	var jwks Jwks
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, errors.Wrap(err, "invalid JWKS")
+	}
+	return &jwks, nil
+}
+
+// LoadJwksFile loads JWKS from file, for offline verification
+func LoadJwksFile(filename string) (*Jwks, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read JWKS file: %s failed", filename)
+	}
+	return ParseJwks(data)
+}
+
+// PublicKey returns *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+func (j *Jwk) PublicKey() (crypto.PublicKey, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := decodeBase64Url("n", j.Modulus)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64Url("e", j.Exponent)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch j.Curve {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, errors.Errorf("unsupported EC curve: %s", j.Curve)
+		}
+		x, err := decodeBase64Url("x", j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64Url("y", j.Y)
+		if err != nil {
+			return nil, err
+		}
+		keySize := (curve.Params().BitSize + 7) / 8
+		if len(x) != keySize || len(y) != keySize {
+			return nil, errors.Errorf("invalid EC key coordinate length, curve: %s", j.Curve)
+		}
+		// ecdh checks the point is on curve
+		uncompressed := append(append([]byte{4}, x...), y...)
+		if _, err = ecdhCurve.NewPublicKey(uncompressed); err != nil {
+			return nil, errors.Wrap(err, "invalid EC key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, errors.Errorf("unsupported OKP curve: %s", j.Curve)
+		}
+		x, err := decodeBase64Url("x", j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key length")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.Errorf("unsupported key type: %s", j.KeyType)
+}
+
+// NewJwk creates JWK from *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey, kid, alg and use are not set
+func NewJwk(publicKey crypto.PublicKey) (*Jwk, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &Jwk{
+			KeyType:  "RSA",
+			Modulus:  base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			Exponent: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		var curve string
+		switch key.Curve {
+		case elliptic.P256():
+			curve = "P-256"
+		case elliptic.P384():
+			curve = "P-384"
+		case elliptic.P521():
+			curve = "P-521"
+		default:
+			return nil, errors.Errorf("unsupported EC curve: %s", key.Curve.Params().Name)
+		}
+		keySize := (key.Curve.Params().BitSize + 7) / 8
+		return &Jwk{
+			KeyType: "EC",
+			Curve:   curve,
+			X:       base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, keySize))),
+			Y:       base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, keySize))),
+		}, nil
+	case ed25519.PublicKey:
+		return &Jwk{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			X:       base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	}
+	return nil, errors.Errorf("unsupported public key type: %T", publicKey)
+}
+
+// Thumbprint JWK SHA-256 thumbprint, specification: RFC7638, RFC8037
+func (j *Jwk) Thumbprint() (string, error) {
+	// required members in lexicographic order, without whitespace
+	var members []string
+	switch j.KeyType {
+	case "RSA":
+		members = []string{"e", j.Exponent, "kty", j.KeyType, "n", j.Modulus}
+	case "EC":
+		members = []string{"crv", j.Curve, "kty", j.KeyType, "x", j.X, "y", j.Y}
+	case "OKP":
+		members = []string{"crv", j.Curve, "kty", j.KeyType, "x", j.X}
+	default:
+		return "", errors.Errorf("unsupported key type: %s", j.KeyType)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < len(members); i += 2 {
+		if members[i+1] == "" {
+			return "", errors.Errorf("JWK parameter %s is missing", members[i])
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		// member values are base64url or well-known names, no escape required
+		buf.WriteString(`"` + members[i] + `":"` + members[i+1] + `"`)
+	}
+	buf.WriteByte('}')
+	digest := sha256.Sum256(buf.Bytes())
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
+
+func decodeBase64Url(name, value string) ([]byte, error) {
+	if value == "" {
+		return nil, errors.Errorf("JWK parameter %s is missing", name)
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid JWK parameter %s", name)
+	}
+	return decoded, nil
+}
+
+// FindKey finds verification key by kid and alg, when kid is absent the only compatible key is used
+func (j *Jwks) FindKey(kid, alg string) (crypto.PublicKey, error) {
+	var candidates []crypto.PublicKey
+	for _, key := range j.Keys {
+		if kid != "" && key.KeyId != kid {
+			continue
+		}
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != alg {
+			continue
+		}
+		publicKey, err := key.PublicKey()
+		if err != nil {
+			if kid != "" {
+				return nil, errors.Wrapf(err, "parse JWK, kid: %s", kid)
+			}
+			continue
+		}
+		if isKeyCompatible(alg, publicKey) {
+			candidates = append(candidates, publicKey)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, newValidationError(ErrorCodeKeyNotFound, "no key found for kid: %q, alg: %s", kid, alg)
+	}
+	if len(candidates) > 1 {
+		return nil, newValidationError(ErrorCodeKeyNotFound, "multiple keys found for kid: %q, alg: %s", kid, alg)
+	}
+	return candidates[0], nil
+}
@@ -0,0 +1,125 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Header JOSE header, specification: RFC7515
+type Header struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Claims JWT claims, numbers are decoded as json.Number
+type Claims map[string]interface{}
+
+// Jwt parsed JWT, signature is NOT verified until Verify
+type Jwt struct {
+	Header       *Header
+	RawHeader    map[string]interface{}
+	Claims       Claims
+	signingInput string
+	signature    []byte
+}
+
+// Parse parses compact serialized JWS JWT, specification: RFC7519
+func Parse(token string) (*Jwt, error) {
+	jwtParts := strings.Split(strings.TrimSpace(token), ".")
+	if len(jwtParts) != 3 {
+		return nil, newValidationError(ErrorCodeMalformed, "JWT must have 3 parts, actual: %d", len(jwtParts))
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(jwtParts[0])
+	if err != nil {
+		return nil, newValidationError(ErrorCodeMalformed, "invalid JWT header encoding: %v", err)
+	}
+	var header Header
+	if err = json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, newValidationError(ErrorCodeMalformed, "invalid JWT header: %v", err)
+	}
+	var rawHeader map[string]interface{}
+	if err = unmarshalUseNumber(headerBytes, &rawHeader); err != nil {
+		return nil, newValidationError(ErrorCodeMalformed, "invalid JWT header: %v", err)
+	}
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(jwtParts[1])
+	if err != nil {
+		return nil, newValidationError(ErrorCodeMalformed, "invalid JWT claims encoding: %v", err)
+	}
+	var claims Claims
+	if err = unmarshalUseNumber(claimsBytes, &claims); err != nil {
+		return nil, newValidationError(ErrorCodeMalformed, "invalid JWT claims: %v", err)
+	}
+	if claims == nil {
+		return nil, newValidationError(ErrorCodeMalformed, "JWT claims is not a JSON object")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(jwtParts[2])
+	if err != nil {
+		return nil, newValidationError(ErrorCodeMalformed, "invalid JWT signature encoding: %v", err)
+	}
+	return &Jwt{
+		Header:       &header,
+		RawHeader:    rawHeader,
+		Claims:       claims,
+		signingInput: jwtParts[0] + "." + jwtParts[1],
+		signature:    signature,
+	}, nil
+}
+
+func unmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// GetString returns string claim, empty when absent or not string
+func (c Claims) GetString(name string) string {
+	if value, ok := c[name].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// GetNumericDate returns NumericDate claim, e.g. exp, nbf and iat, false when absent
+func (c Claims) GetNumericDate(name string) (time.Time, bool, error) {
+	value, ok := c[name]
+	if !ok || value == nil {
+		return time.Time{}, false, nil
+	}
+	var seconds float64
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false, errors.Errorf("claim %s is not NumericDate: %s", name, v)
+		}
+		seconds = f
+	case float64:
+		seconds = v
+	default:
+		return time.Time{}, false, errors.Errorf("claim %s is not NumericDate: %v", name, value)
+	}
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// GetAudience returns aud claim, aud is string or string array
+func (c Claims) GetAudience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		var audiences []string
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+		return audiences
+	}
+	return nil
+}
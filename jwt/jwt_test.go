@@ -0,0 +1,189 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signToken(t *testing.T, alg, kid string, signer crypto.Signer, claims map[string]interface{}) string {
+	signingInput := encodeSegment(t, &Header{Algorithm: alg, KeyId: kid, Type: "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	var err error
+	switch alg {
+	case RS256:
+		signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case PS256:
+		signature, err = signer.Sign(rand.Reader, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+	case ES256:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, signer.(*ecdsa.PrivateKey), digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case EdDSA:
+		signature, err = signer.Sign(rand.Reader, []byte(signingInput), crypto.Hash(0))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func tamperClaims(token, claims string) string {
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + claims + "." + parts[2]
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwksJson, err := json.Marshal(&Jwks{Keys: []*Jwk{
+		{KeyType: "RSA", KeyId: "rsa", Modulus: b64(rsaKey.N.Bytes()), Exponent: b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{KeyType: "EC", KeyId: "ec", Curve: "P-256", X: b64(ecKey.X.FillBytes(make([]byte, 32))), Y: b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{KeyType: "OKP", KeyId: "ed", Curve: "Ed25519", X: b64(edPublicKey)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := ParseJwks(jwksJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet := NewStaticKeySet(jwks)
+
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss": "https://issuer.example.com",
+			"aud": []string{"aud1", "aud2"},
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	options := &VerifyOptions{
+		Issuer:    "https://issuer.example.com",
+		Audiences: []string{"aud2"},
+		ClockSkew: DefaultClockSkew,
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		options *VerifyOptions
+		code    string
+	}{
+		{"RS256", signToken(t, RS256, "rsa", rsaKey, claims(nil)), options, ""},
+		{"PS256", signToken(t, PS256, "rsa", rsaKey, claims(nil)), options, ""},
+		{"ES256", signToken(t, ES256, "ec", ecKey, claims(nil)), options, ""},
+		{"EdDSA", signToken(t, EdDSA, "ed", edKey, claims(nil)), options, ""},
+		{"without kid", signToken(t, ES256, "", ecKey, claims(nil)), options, ""},
+		{"within clock skew", signToken(t, RS256, "rsa", rsaKey, claims(map[string]interface{}{
+			"exp": now.Add(-30 * time.Second).Unix()})), options, ""},
+		{"malformed", "a.b", options, ErrorCodeMalformed},
+		{"algorithm not allowed", signToken(t, RS256, "rsa", rsaKey, claims(nil)),
+			&VerifyOptions{Algorithms: []string{ES256}}, ErrorCodeUnsupportedAlgorithm},
+		{"key not found", signToken(t, RS256, "unknown", rsaKey, claims(nil)), options, ErrorCodeKeyNotFound},
+		{"key type mismatch", signToken(t, ES256, "rsa", ecKey, claims(nil)), options, ErrorCodeKeyNotFound},
+		{"invalid signature", tamperClaims(signToken(t, RS256, "rsa", rsaKey, claims(nil)),
+			encodeSegment(t, claims(map[string]interface{}{"sub": "other"}))), options, ErrorCodeInvalidSignature},
+		{"expired", signToken(t, RS256, "rsa", rsaKey, claims(map[string]interface{}{
+			"exp": now.Add(-2 * time.Minute).Unix()})), options, ErrorCodeExpired},
+		{"missing exp", signToken(t, RS256, "rsa", rsaKey, claims(map[string]interface{}{"exp": nil})), options, ErrorCodeMissingExpiration},
+		{"not yet valid", signToken(t, RS256, "rsa", rsaKey, claims(map[string]interface{}{
+			"nbf": now.Add(2 * time.Minute).Unix()})), options, ErrorCodeNotYetValid},
+		{"invalid issuer", signToken(t, RS256, "rsa", rsaKey, claims(map[string]interface{}{
+			"iss": "https://other.example.com"})), options, ErrorCodeInvalidIssuer},
+		{"invalid audience", signToken(t, RS256, "rsa", rsaKey, claims(map[string]interface{}{
+			"aud": "aud3"})), options, ErrorCodeInvalidAudience},
+		{"missing iss", signToken(t, RS256, "rsa", rsaKey, claims(map[string]interface{}{"iss": nil})), options, ErrorCodeInvalidIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Verify(tt.token, keySet, tt.options)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var validationError *ValidationError
+			if !errors.As(err, &validationError) {
+				t.Fatalf("expected validation error: %s, actual: %v", tt.code, err)
+			}
+			if validationError.Code != tt.code {
+				t.Errorf("expected error code: %s, actual: %s", tt.code, validationError.Code)
+			}
+		})
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// example of RFC7638 3.1
+	jwk := &Jwk{
+		KeyType: "RSA",
+		Modulus: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+			"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91" +
+			"CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		Exponent:  "AQAB",
+		Algorithm: RS256,
+		KeyId:     "2011-04-29",
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("thumbprint: %s", thumbprint)
+	}
+
+	// example of RFC8037 A.3
+	jwk = &Jwk{KeyType: "OKP", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	thumbprint, err = jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumbprint != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("thumbprint: %s", thumbprint)
+	}
+}
@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"crypto"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/oidc"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+// KeySet provides verification key by kid and alg
+type KeySet interface {
+	GetKey(kid, alg string) (crypto.PublicKey, error)
+}
+
+// StaticKeySet key set from local JWKS file, for offline verification
+type StaticKeySet struct {
+	jwks *Jwks
+}
+
+func NewStaticKeySet(jwks *Jwks) *StaticKeySet {
+	return &StaticKeySet{jwks: jwks}
+}
+
+func (s *StaticKeySet) GetKey(kid, alg string) (crypto.PublicKey, error) {
+	return s.jwks.FindKey(kid, alg)
+}
+
+// RemoteKeySet key set from JWKS URI, JWKS is cached, and refreshed once when key is not found(key rotation)
+type RemoteKeySet struct {
+	jwksUri   string
+	jwks      *Jwks
+	refreshed bool
+}
+
+func NewRemoteKeySet(jwksUri string) *RemoteKeySet {
+	return &RemoteKeySet{jwksUri: jwksUri}
+}
+
+// NewRemoteKeySetFromIssuer resolves JWKS URI via OIDC discovery
+func NewRemoteKeySetFromIssuer(issuer string) (*RemoteKeySet, error) {
+	openIdConfiguration, err := oidc.FetchOpenIdConfiguration(issuer, &oidc.FetchOpenIdConfigurationOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if openIdConfiguration.Issuer != "" && openIdConfiguration.Issuer != issuer {
+		return nil, errors.Errorf("issuer mismatch, expected: %s, discovery: %s", issuer, openIdConfiguration.Issuer)
+	}
+	if openIdConfiguration.JwksUri == "" {
+		return nil, errors.Errorf("jwks_uri not found in OpenID configuration of issuer: %s", issuer)
+	}
+	return NewRemoteKeySet(openIdConfiguration.JwksUri), nil
+}
+
+func (r *RemoteKeySet) GetJwksUri() string {
+	return r.jwksUri
+}
+
+func (r *RemoteKeySet) GetKey(kid, alg string) (crypto.PublicKey, error) {
+	if r.jwks == nil {
+		if err := r.load(false); err != nil {
+			return nil, err
+		}
+	}
+	publicKey, err := r.jwks.FindKey(kid, alg)
+	if err == nil || r.refreshed {
+		return publicKey, err
+	}
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) || validationError.Code != ErrorCodeKeyNotFound {
+		return nil, err
+	}
+	idaaslog.Info.PrintfLn("Key not found, kid: %s, refresh JWKS: %s", kid, r.jwksUri)
+	if err = r.load(true); err != nil {
+		return nil, err
+	}
+	return r.jwks.FindKey(kid, alg)
+}
+
+func (r *RemoteKeySet) load(forceNew bool) error {
+	options := &utils.ReadCacheOptions{
+		Context: map[string]interface{}{
+			"jwks_uri": r.jwksUri,
+		},
+		FetchContent: func() (int, string, error) {
+			idaaslog.Debug.PrintfLn("GET JWKS from URL: %s", r.jwksUri)
+			return utils.GetHttp(r.jwksUri)
+		},
+		ForceNew: forceNew,
+	}
+	jwksJson, err := utils.ReadCacheFileWithEncryptionCallback(constants.CategoryJwks, utils.Sha256ToHex(r.jwksUri), options)
+	if err != nil {
+		return errors.Wrapf(err, "fetch JWKS: %s failed", r.jwksUri)
+	}
+	jwks, err := ParseJwks([]byte(jwksJson))
+	if err != nil {
+		return err
+	}
+	r.jwks = jwks
+	r.refreshed = r.refreshed || forceNew
+	return nil
+}
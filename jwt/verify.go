@@ -0,0 +1,106 @@
+package jwt
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultClockSkew tolerance of exp, nbf and iat
+const DefaultClockSkew = time.Minute
+
+type VerifyOptions struct {
+	Issuer     string        // optional, expected iss
+	Audiences  []string      // optional, aud MUST contain one of audiences
+	ClockSkew  time.Duration // optional, tolerance of exp, nbf and iat
+	Algorithms []string      // optional, allowed algorithms, default SupportedAlgorithms
+	// AllowMissingExpiration optional, exp is required by default
+	AllowMissingExpiration bool
+	// Now optional, for test
+	Now func() time.Time
+}
+
+// Verify parses and verifies JWT signature and claims, returns parsed JWT even when verification failed(except malformed)
+// errors are *ValidationError when JWT is invalid
+func Verify(token string, keySet KeySet, options *VerifyOptions) (*Jwt, error) {
+	if options == nil {
+		options = &VerifyOptions{}
+	}
+	jwt, err := Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if err = jwt.VerifySignature(keySet, options.Algorithms); err != nil {
+		return jwt, err
+	}
+	return jwt, jwt.VerifyClaims(options)
+}
+
+// VerifySignature verifies signature with key from key set
+func (j *Jwt) VerifySignature(keySet KeySet, algorithms []string) error {
+	alg := j.Header.Algorithm
+	if len(algorithms) == 0 {
+		algorithms = SupportedAlgorithms
+	}
+	if !slices.Contains(SupportedAlgorithms, alg) || !slices.Contains(algorithms, alg) {
+		return newValidationError(ErrorCodeUnsupportedAlgorithm, "algorithm %q is not allowed", alg)
+	}
+	publicKey, err := keySet.GetKey(j.Header.KeyId, alg)
+	if err != nil {
+		return err
+	}
+	return verifySignature(alg, publicKey, []byte(j.signingInput), j.signature)
+}
+
+// VerifyClaims verifies exp, nbf, iat, iss and aud
+func (j *Jwt) VerifyClaims(options *VerifyOptions) error {
+	now := time.Now()
+	if options.Now != nil {
+		now = options.Now()
+	}
+	clockSkew := options.ClockSkew
+
+	expiration, hasExpiration, err := j.Claims.GetNumericDate("exp")
+	if err != nil {
+		return newValidationError(ErrorCodeMalformed, "%v", err)
+	}
+	if !hasExpiration && !options.AllowMissingExpiration {
+		return newValidationError(ErrorCodeMissingExpiration, "exp is required")
+	}
+	if hasExpiration && !now.Before(expiration.Add(clockSkew)) {
+		return newValidationError(ErrorCodeExpired, "expired at %s", expiration.Format(time.RFC3339))
+	}
+	notBefore, hasNotBefore, err := j.Claims.GetNumericDate("nbf")
+	if err != nil {
+		return newValidationError(ErrorCodeMalformed, "%v", err)
+	}
+	if hasNotBefore && now.Add(clockSkew).Before(notBefore) {
+		return newValidationError(ErrorCodeNotYetValid, "not valid before %s", notBefore.Format(time.RFC3339))
+	}
+	issuedAt, hasIssuedAt, err := j.Claims.GetNumericDate("iat")
+	if err != nil {
+		return newValidationError(ErrorCodeMalformed, "%v", err)
+	}
+	if hasIssuedAt && now.Add(clockSkew).Before(issuedAt) {
+		return newValidationError(ErrorCodeIssuedInFuture, "issued at %s in the future", issuedAt.Format(time.RFC3339))
+	}
+
+	if options.Issuer != "" {
+		if issuer := j.Claims.GetString("iss"); issuer != options.Issuer {
+			return newValidationError(ErrorCodeInvalidIssuer, "issuer %q does not match %q", issuer, options.Issuer)
+		}
+	}
+	if len(options.Audiences) > 0 {
+		audiences := j.Claims.GetAudience()
+		matched := false
+		for _, audience := range options.Audiences {
+			if slices.Contains(audiences, audience) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return newValidationError(ErrorCodeInvalidAudience, "audience %q does not match %q", audiences, options.Audiences)
+		}
+	}
+	return nil
+}
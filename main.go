@@ -1,27 +1,41 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/openclaw_secret"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/qr"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/rotate_signer_key"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/serve"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/show_signer_public_key"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/start_session"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/validate_config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/validate_jwt"
 
+	"github.com/aliyunidaas/alibaba-cloud-idaas/audit"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/cloud"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/agent"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/cache"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/clean_cache"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/configure"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/configure_aws"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/execute"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/fetch_token"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/logout"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/migrate_cache"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/show_audit"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/show_cache"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/show_profile"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/show_token"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/sync_credentials"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/commands/version"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/config"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/external"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/pkcs11"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/yubikey_piv"
 	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
@@ -30,16 +44,16 @@ import (
 
 func main() {
 	idaaslog.InitLog()
-	defer idaaslog.CloseLog()
+	config.ProfileKmsCredentialFetcher = cloud.FetchKmsCredential
 
-	err := innerMain()
-	if err != nil {
-		utils.Stderr.Fprintf("%v\n", err)
-		os.Exit(-1)
-	}
+	exitCode := innerMain()
+	// os.Exit does not run deferred functions, external signer helpers and log MUST be closed before exit
+	external.ShutdownHelpers()
+	idaaslog.CloseLog()
+	os.Exit(exitCode)
 }
 
-func innerMain() error {
+func innerMain() int {
 	commands := []*cli.Command{
 		fetch_token.BuildCommand(),
 		show_token.BuildCommand(),
@@ -53,6 +67,16 @@ func innerMain() error {
 		qr.BuildCommand(),
 		validate_jwt.BuildCommand(),
 		openclaw_secret.BuildCommand(),
+		configure_aws.BuildCommand(),
+		sync_credentials.BuildCommand(),
+		agent.BuildCommand(),
+		show_audit.BuildCommand(),
+		migrate_cache.BuildCommand(),
+		cache.BuildCommand(),
+		logout.BuildCommand(),
+		rotate_signer_key.BuildCommand(),
+		validate_config.BuildCommand(),
+		configure.BuildCommand(),
 	}
 	if version.IsPreRelease() {
 		commands = append(commands, start_session.BuildCommand())
@@ -61,6 +85,13 @@ func innerMain() error {
 		Name:     "alibaba-cloud-idaas",
 		Usage:    "Alibaba Cloud IDaaS command line util",
 		Commands: commands,
+		Before: func(context *cli.Context) error {
+			// args are parsed by cli, so flag values are never taken as command
+			if command := context.App.Command(context.Args().First()); command != nil {
+				audit.SetCommand(command.Name)
+			}
+			return nil
+		},
 		Action: func(context *cli.Context) error {
 			printBanner()
 			printConfigFileAndFeatures()
@@ -71,12 +102,21 @@ func innerMain() error {
 			}
 			return nil
 		},
+		// exit code is returned to main, cli MUST NOT call os.Exit
+		ExitErrHandler: func(context *cli.Context, err error) {},
 	}
 	if err := app.Run(os.Args); err != nil {
+		var exitCoder cli.ExitCoder
+		if errors.As(err, &exitCoder) {
+			if message := err.Error(); message != "" {
+				utils.Stderr.Fprintf("%s\n", message)
+			}
+			return exitCoder.ExitCode()
+		}
 		utils.Stderr.Fprintf("%s\n", idaaslog.DumpError(err))
-		os.Exit(1)
+		return 1
 	}
-	return nil
+	return 0
 }
 
 func printBanner() {
@@ -126,6 +166,9 @@ func printVerbose(context *cli.Context) {
 		fmt.Printf(" - %s  User agent when send OIDC/OAuth related requests\n", padEnv(constants.EnvUserAgent))
 		fmt.Printf(" - %s  Log unsafe secure data\n", padEnv(constants.EnvUnsafeDebug))
 		fmt.Printf(" - %s  Copy log to console stderr\n", padEnv(constants.EnvUnsafeConsolePrint))
+		fmt.Printf(" - %s  Disable audit records of credential issuance\n", padEnv(constants.EnvDisableAudit))
+		fmt.Printf(" - %s  Cache backend: file, keyring or pass\n", padEnv(constants.EnvCacheBackend))
+		fmt.Printf(" - %s  Cache passphrase, when cache encryption mode is passphrase\n", padEnv(constants.EnvCachePassphrase))
 		if pkcs11.Pkcs11SingerEnabled() {
 			fmt.Printf(" - %s  PKCS#11 PIN\n", padEnv(constants.EnvPkcs11Pin))
 		}
@@ -0,0 +1,285 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	CodeChallengeMethodS256 = "S256"
+
+	DefaultRedirectPath = "/callback"
+
+	authorizationCodeFlowTimeout = 5 * time.Minute
+)
+
+type FetchAuthorizationCodeFlowOptions struct {
+	ClientId     string
+	ClientSecret string
+	Scope        string
+	RedirectPort int
+	RedirectPath string
+	AutoOpenUrl  bool
+	ForceNew     bool
+	CacheKey     string
+}
+
+type authorizationCodeCallbackResult struct {
+	Code string
+	Err  error
+}
+
+// FetchTokenViaAuthorizationCodeFlow
+// specifications:
+// - RFC6749 Section 4.1 Authorization Code Grant
+// - RFC7636 Proof Key for Code Exchange by OAuth Public Clients
+// - RFC8252 Section 7.3 Loopback Interface Redirection
+func FetchTokenViaAuthorizationCodeFlow(issuer string, options *FetchAuthorizationCodeFlowOptions) (*TokenResponse, error) {
+	fetchOpenIdConfigurationOptions := &FetchOpenIdConfigurationOptions{
+		ForceNew: options.ForceNew,
+	}
+	openIdConfiguration, err := FetchOpenIdConfiguration(issuer, fetchOpenIdConfigurationOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch open id configuration, issuer: %s", issuer)
+	}
+	if openIdConfiguration.AuthorizationEndpoint == "" {
+		return nil, errors.Errorf("authorizationEndpoint is empty, issuer: %s", issuer)
+	}
+	if len(openIdConfiguration.CodeChallengeMethodsSupported) > 0 &&
+		!slices.Contains(openIdConfiguration.CodeChallengeMethodsSupported, CodeChallengeMethodS256) {
+		return nil, errors.Errorf("code challenge method %s is not supported, issuer: %s, supported: %v",
+			CodeChallengeMethodS256, issuer, openIdConfiguration.CodeChallengeMethodsSupported)
+	}
+
+	codeVerifier, err := GenerateCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+	state, err := generateRandomString(24)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateRandomString(24)
+	if err != nil {
+		return nil, err
+	}
+
+	redirectPath := options.RedirectPath
+	if redirectPath == "" {
+		redirectPath = DefaultRedirectPath
+	}
+	if !strings.HasPrefix(redirectPath, "/") {
+		redirectPath = "/" + redirectPath
+	}
+	// RFC8252 recommends loopback IP literal instead of `localhost`
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", options.RedirectPort))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen loopback redirect port: %d", options.RedirectPort)
+	}
+	redirectPort := listener.Addr().(*net.TCPAddr).Port
+	redirectUri := fmt.Sprintf("http://127.0.0.1:%d%s", redirectPort, redirectPath)
+	idaaslog.Info.PrintfLn("Authorization code redirect uri: %s", redirectUri)
+
+	resultChan := make(chan *authorizationCodeCallbackResult, 1)
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc(redirectPath, newAuthorizationCodeCallbackHandler(state, resultChan))
+	server := &http.Server{
+		Handler:           serveMux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		serveErr := server.Serve(listener)
+		if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			idaaslog.Error.PrintfLn("Authorization code callback server error: %v", serveErr)
+		}
+	}()
+	defer func() {
+		_ = server.Close()
+	}()
+
+	authorizationUrl, err := BuildAuthorizationUrl(openIdConfiguration.AuthorizationEndpoint, &AuthorizationRequestOptions{
+		ClientId:      options.ClientId,
+		Scope:         options.Scope,
+		RedirectUri:   redirectUri,
+		State:         state,
+		Nonce:         nonce,
+		CodeChallenge: BuildCodeChallengeS256(codeVerifier),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if options.AutoOpenUrl {
+		err := utils.OpenUrl(authorizationUrl)
+		if err != nil {
+			utils.Stderr.Fprintf("failed to open URL: %v\n", err)
+		}
+	}
+	utils.Stderr.Fprintf("Open URL in browser to login: %s\n\n", authorizationUrl)
+
+	var callbackResult *authorizationCodeCallbackResult
+	select {
+	case callbackResult = <-resultChan:
+	case <-time.After(authorizationCodeFlowTimeout):
+		return nil, errors.Errorf("wait for authorization code timeout after %s", authorizationCodeFlowTimeout)
+	}
+	if callbackResult.Err != nil {
+		return nil, callbackResult.Err
+	}
+
+	fetchTokenOptions := &FetchTokenOptions{
+		ClientId:     options.ClientId,
+		ClientSecret: options.ClientSecret,
+		GrantType:    GrantTypeAuthorizationCode,
+		Code:         callbackResult.Code,
+		RedirectUri:  redirectUri,
+		CodeVerifier: codeVerifier,
+	}
+	tokenResponse, tokenErrorResponse, err := FetchToken(openIdConfiguration.TokenEndpoint, fetchTokenOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to exchange authorization code")
+	}
+	if tokenErrorResponse != nil {
+		return nil, errors.Errorf("failed to exchange authorization code, error: %s, description: %s",
+			tokenErrorResponse.Error, tokenErrorResponse.ErrorDescription)
+	}
+	if tokenResponse.IdToken != "" {
+		if err := checkIdTokenNonce(tokenResponse.IdToken, nonce); err != nil {
+			return nil, err
+		}
+	}
+	SaveTokenResponseWithRefreshToken(options.CacheKey, tokenResponse)
+	return tokenResponse, nil
+}
+
+type AuthorizationRequestOptions struct {
+	ClientId      string
+	Scope         string
+	RedirectUri   string
+	State         string
+	Nonce         string
+	CodeChallenge string
+}
+
+func BuildAuthorizationUrl(authorizationEndpoint string, options *AuthorizationRequestOptions) (string, error) {
+	authorizationUrl, err := url.Parse(authorizationEndpoint)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid authorization endpoint: %s", authorizationEndpoint)
+	}
+	scope := options.Scope
+	if scope == "" {
+		scope = "openid"
+	}
+	query := authorizationUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", options.ClientId)
+	query.Set("redirect_uri", options.RedirectUri)
+	query.Set("scope", scope)
+	query.Set("state", options.State)
+	query.Set("nonce", options.Nonce)
+	query.Set("code_challenge", options.CodeChallenge)
+	query.Set("code_challenge_method", CodeChallengeMethodS256)
+	authorizationUrl.RawQuery = query.Encode()
+	return authorizationUrl.String(), nil
+}
+
+// GenerateCodeVerifier
+// code_verifier = high-entropy cryptographic random STRING using the unreserved characters,
+// with a minimum length of 43 characters and a maximum length of 128 characters.
+// specification: RFC7636 Section 4.1
+func GenerateCodeVerifier() (string, error) {
+	return generateRandomString(32)
+}
+
+// BuildCodeChallengeS256
+// code_challenge = BASE64URL-ENCODE(SHA256(ASCII(code_verifier)))
+// specification: RFC7636 Section 4.2
+func BuildCodeChallengeS256(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func newAuthorizationCodeCallbackHandler(state string, resultChan chan<- *authorizationCodeCallbackResult) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			// maybe a forged request, DO NOT finish the flow
+			idaaslog.Warn.PrintfLn("Authorization code callback state mismatch")
+			printCallbackPage(w, http.StatusBadRequest, "Invalid state, please retry login.")
+			return
+		}
+		var result *authorizationCodeCallbackResult
+		if callbackError := query.Get("error"); callbackError != "" {
+			idaaslog.Error.PrintfLn("Authorization code callback error: %s, description: %s",
+				callbackError, query.Get("error_description"))
+			printCallbackPage(w, http.StatusBadRequest, "Login failed: "+html.EscapeString(callbackError))
+			if callbackError == ErrorAccessDenied {
+				result = &authorizationCodeCallbackResult{Err: errors.New(constants.ErrStopFallback)}
+			} else {
+				result = &authorizationCodeCallbackResult{Err: errors.Errorf(
+					"authorization failed, error: %s, description: %s", callbackError, query.Get("error_description"))}
+			}
+		} else if code := query.Get("code"); code == "" {
+			printCallbackPage(w, http.StatusBadRequest, "Authorization code is missing.")
+			result = &authorizationCodeCallbackResult{Err: errors.New("authorization code is missing")}
+		} else {
+			printCallbackPage(w, http.StatusOK, "Login success, you can close this window now.")
+			result = &authorizationCodeCallbackResult{Code: code}
+		}
+		select {
+		case resultChan <- result:
+		default:
+			// already got a result, ignore duplicated callback
+		}
+	}
+}
+
+func printCallbackPage(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	_, _ = fmt.Fprintf(w, "<html><head><title>Alibaba Cloud IDaaS</title></head><body><p>%s</p></body></html>",
+		message)
+}
+
+func checkIdTokenNonce(idToken, nonce string) error {
+	idTokenParts := strings.Split(idToken, ".")
+	if len(idTokenParts) != 3 {
+		return errors.New("invalid ID token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(idTokenParts[1])
+	if err != nil {
+		return errors.Wrap(err, "invalid ID token payload")
+	}
+	var claims struct {
+		Nonce string `json:"nonce"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return errors.Wrap(err, "invalid ID token payload")
+	}
+	if claims.Nonce != nonce {
+		return errors.New("ID token nonce mismatch")
+	}
+	return nil
+}
+
+func generateRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate random string")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,59 @@
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildCodeChallengeS256(t *testing.T) {
+	// RFC7636 Appendix B
+	codeChallenge := BuildCodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if codeChallenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("unexpected code challenge: %s", codeChallenge)
+	}
+}
+
+func TestGenerateCodeVerifier(t *testing.T) {
+	codeVerifier, err := GenerateCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codeVerifier) < 43 || len(codeVerifier) > 128 {
+		t.Errorf("invalid code verifier length: %d", len(codeVerifier))
+	}
+}
+
+func TestAuthorizationCodeCallbackHandler(t *testing.T) {
+	resultChan := make(chan *authorizationCodeCallbackResult, 1)
+	handler := newAuthorizationCodeCallbackHandler("state1", resultChan)
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/callback?code=code1&state=state2", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("state mismatch should be rejected, got: %d", recorder.Code)
+	}
+	if len(resultChan) != 0 {
+		t.Fatal("state mismatch should not finish the flow")
+	}
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/callback?code=code1&state=state1", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("unexpected status: %d", recorder.Code)
+	}
+	result := <-resultChan
+	if result.Err != nil || result.Code != "code1" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	handler = newAuthorizationCodeCallbackHandler("state1", make(chan *authorizationCodeCallbackResult, 1))
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet,
+		"/callback?error=%3Cscript%3Ealert(1)%3C%2Fscript%3E&state=state1", nil))
+	if body := recorder.Body.String(); strings.Contains(body, "<script>") ||
+		!strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("callback error should be escaped: %s", body)
+	}
+}
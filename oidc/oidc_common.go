@@ -19,6 +19,7 @@ const (
 
 	GrantTypeClientCredentials = "client_credentials"
 	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeAuthorizationCode = "authorization_code"
 	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
 
 	ErrorCodeAuthorizationPending = "authorization_pending"
@@ -103,6 +104,11 @@ type FetchTokenOptions struct {
 	GrantType    string
 	Scope        string
 	RefreshToken string
+	Code         string
+	RedirectUri  string
+
+	// for RFC7636
+	CodeVerifier string
 
 	// for RFC8628
 	DeviceCode string
@@ -124,6 +130,7 @@ type FetchOpenIdConfigurationOptions struct {
 // FetchToken
 // specifications:
 // - RFC6749
+// - RFC7636
 // - RFC8628
 // - RFC7523
 func FetchToken(tokenEndpoint string, options *FetchTokenOptions) (*TokenResponse, *ErrorResponse, error) {
@@ -158,6 +165,15 @@ func innerFetchToken(tokenEndpoint string, options *FetchTokenOptions) (int, *To
 	if options.RefreshToken != "" {
 		parameter["refresh_token"] = options.RefreshToken
 	}
+	if options.Code != "" {
+		parameter["code"] = options.Code
+	}
+	if options.RedirectUri != "" {
+		parameter["redirect_uri"] = options.RedirectUri
+	}
+	if options.CodeVerifier != "" {
+		parameter["code_verifier"] = options.CodeVerifier
+	}
 	if options.ClientAssertionType != "" {
 		parameter["client_assertion_type"] = options.ClientAssertionType
 	}
@@ -215,7 +231,7 @@ func FetchOpenIdConfiguration(issuer string, fetchOptions *FetchOpenIdConfigurat
 		AllowExpired: true,
 		ForceNew:     fetchOptions.ForceNew,
 	}
-	cacheKey := utils.Sha256ToHex(issuer)
+	cacheKey := OpenIdConfigurationCacheKey(issuer)
 	openIdConfigurationJson, err := utils.ReadCacheFileWithEncryptionCallback(constants.CategoryOidc, cacheKey, options)
 	if err != nil {
 		idaaslog.Error.PrintfLn("Failed to fetch OpenID configuration, error: %v", err)
@@ -241,3 +257,8 @@ func parseErrorResponse(statusCode int, response string) (*ErrorResponse, error)
 	errorResponse.StatusCode = statusCode
 	return &errorResponse, nil
 }
+
+// OpenIdConfigurationCacheKey cache key of category oidc, also used by cache and logout commands
+func OpenIdConfigurationCacheKey(issuer string) string {
+	return utils.Sha256ToHex(issuer)
+}
@@ -27,8 +27,14 @@ type FetchDeviceCodeOptions struct {
 	Scope    string
 }
 
-func TryFetchTokenViaRefreshToken(issuer string, cacheKey string, options *FetchDeviceCodeFlowOptions) *TokenResponse {
-	tokenResponseJsonStr, err := utils.ReadCacheFileWithEncryption(constants.CategoryTokenResponse, cacheKey)
+type FetchRefreshTokenOptions struct {
+	ClientId     string
+	ClientSecret string
+	ForceNew     bool
+}
+
+func TryFetchTokenViaRefreshToken(issuer string, cacheKey string, options *FetchRefreshTokenOptions) *TokenResponse {
+	tokenResponseJsonStr, err := utils.ReadCache(constants.CategoryTokenResponse, cacheKey)
 	if err != nil {
 		idaaslog.Debug.PrintfLn("Read token response category: %s, key: %s failed: %v", constants.CategoryTokenResponse, cacheKey, err)
 		return nil
@@ -73,7 +79,7 @@ func TryFetchTokenViaRefreshToken(issuer string, cacheKey string, options *Fetch
 		isTooManyRequests := tokenErrorResponse.StatusCode == http.StatusTooManyRequests
 		if !isTooManyRequests && tokenErrorResponse.StatusCode >= 400 && tokenErrorResponse.StatusCode < 500 {
 			idaaslog.Info.PrintfLn("Remove cache file %s %s", constants.CategoryTokenResponse, cacheKey)
-			err = utils.RemoveCacheFile(constants.CategoryTokenResponse, cacheKey)
+			err = utils.RemoveCache(constants.CategoryTokenResponse, cacheKey)
 			if err != nil {
 				idaaslog.Warn.PrintfLn("Remove cache file %s %s failed: %v", constants.CategoryTokenResponse, cacheKey, err)
 			}
@@ -92,7 +98,7 @@ func SaveTokenResponseWithRefreshToken(cacheKey string, tokenResponse *TokenResp
 			idaaslog.Warn.PrintfLn("Marshal token response failed: %v", err)
 			return
 		}
-		err = utils.WriteCacheFileWithEncryption(constants.CategoryTokenResponse, cacheKey, string(tokenResponseJsonBytes))
+		err = utils.WriteCache(constants.CategoryTokenResponse, cacheKey, string(tokenResponseJsonBytes))
 		if err != nil {
 			idaaslog.Warn.PrintfLn("Write token response category: %s, key: %s failed: %v", constants.CategoryTokenResponse, cacheKey, err)
 		}
@@ -162,7 +168,7 @@ func FetchTokenViaDeviceCodeFlow(issuer string, options *FetchDeviceCodeFlowOpti
 		if err != nil {
 			tokenErrorCounting++
 			if tokenErrorCounting > 3 {
-				return nil, errors.Errorf("failed to fetch token with response: %w", err)
+				return nil, errors.Wrap(err, "failed to fetch token with response")
 			}
 			// LOGGING ...
 			continue
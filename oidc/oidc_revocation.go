@@ -0,0 +1,55 @@
+package oidc
+
+import (
+	"net/http"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+type RevokeTokenOptions struct {
+	ClientId      string
+	ClientSecret  string // optional, when public client
+	Token         string
+	TokenTypeHint string // optional, access_token or refresh_token
+}
+
+// RevokeToken
+// specification: RFC7009
+// invalid token is also responded with HTTP 200, so revoke a token multiple times is fine
+func RevokeToken(revocationEndpoint string, options *RevokeTokenOptions) (*ErrorResponse, error) {
+	if revocationEndpoint == "" {
+		return nil, errors.New("revocation endpoint is empty")
+	}
+	parameter := map[string]string{}
+	parameter["client_id"] = options.ClientId
+	if options.ClientSecret != "" {
+		parameter["client_secret"] = options.ClientSecret
+	}
+	parameter["token"] = options.Token
+	if options.TokenTypeHint != "" {
+		parameter["token_type_hint"] = options.TokenTypeHint
+	}
+	idaaslog.Unsafe.PrintfLn("Revoke token: %s, with parameter: %+v", revocationEndpoint, parameter)
+	statusCode, response, err := utils.PostHttp(revocationEndpoint, parameter)
+	if err != nil {
+		idaaslog.Error.PrintfLn("Failed to revoke token, error: %v", err)
+		return nil, errors.Wrapf(err, "failed to revoke token from: %s", revocationEndpoint)
+	}
+	if statusCode != http.StatusOK {
+		idaaslog.Error.PrintfLn("Failed to revoke token, status: %d, response: %s", statusCode, response)
+		errorResponse, err := parseErrorResponse(statusCode, response)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to revoke token, status: %d", statusCode)
+		}
+		return errorResponse, nil
+	}
+	idaaslog.Info.PrintfLn("Successfully revoked token, type hint: %s", options.TokenTypeHint)
+	return nil, nil
+}
@@ -49,7 +49,7 @@ func (w *ExCommandSignerWrap) Sign(rand io.Reader, digest []byte, opts crypto.Si
 // --ApiPassthrough.Extensions.ExtendedKeyUsages.1 clientAuth \
 // --version 2020-06-30 \
 // --force
-func SignCertificateRequest(command, parameter string) (string, error) {
+func SignCertificateRequest(command, parameter, protocol string) (string, error) {
 	subject := pkix.Name{
 		Organization:       []string{"Example Inc"},
 		OrganizationalUnit: []string{"IT"},
@@ -61,7 +61,7 @@ func SignCertificateRequest(command, parameter string) (string, error) {
 		SignatureAlgorithm: x509.ECDSAWithSHA256,
 	}
 
-	exCommandSigner, err := external.NewExCommandSigner(command, parameter)
+	exCommandSigner, err := external.NewExCommandSigner(command, parameter, protocol)
 	if err != nil {
 		return "", errors.Wrapf(err, "error creating new ex command signer")
 	}
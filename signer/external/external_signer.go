@@ -32,33 +32,90 @@ type PublicKeyResponse struct {
 type ExCommandSigner struct {
 	command   string
 	parameter string
+	protocol  string
 }
 
-func NewExCommandSigner(command string, parameter string) (*ExCommandSigner, error) {
+// NewExCommandSigner protocol v1(default) runs command for every call, v2 keeps helper process running
+func NewExCommandSigner(command, parameter, protocol string) (*ExCommandSigner, error) {
 	if command == "" {
 		return nil, errors.New("external signer command is empty")
 	}
 	if parameter == "" {
 		return nil, errors.New("external signer parameter is empty")
 	}
+	if protocol == "" {
+		protocol = ProtocolV1
+	}
+	if protocol != ProtocolV1 && protocol != ProtocolV2 {
+		return nil, errors.Errorf("invalid external signer protocol: %s, must be %s or %s", protocol, ProtocolV1, ProtocolV2)
+	}
 	return &ExCommandSigner{
 		command:   command,
 		parameter: parameter,
+		protocol:  protocol,
 	}, nil
 }
 
 func (ex *ExCommandSigner) Public() (publicKey crypto.PublicKey, err error) {
+	if ex.protocol == ProtocolV2 {
+		publicKeyDer, err := internalGetPublicKeyV2(ex.command, ex.parameter)
+		if err != nil {
+			return nil, err
+		}
+		return parsePublicKey(publicKeyDer)
+	}
 	return internalGetPublicKey(ex.command, ex.parameter)
 }
 
 func (ex *ExCommandSigner) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, message []byte) (signature []byte, err error) {
+	if ex.protocol == ProtocolV2 {
+		signParams, err := newSignParams(alg, message, true)
+		if err != nil {
+			return nil, err
+		}
+		return internalSignV2(ex.command, ex.parameter, signParams)
+	}
 	return internalSign(ex.command, ex.parameter, alg, message, true)
 }
 
 func (ex *ExCommandSigner) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm, digest []byte) (signature []byte, err error) {
+	if alg.IsEdDSA() {
+		return nil, errors.New("EdDSA signs message without pre-hash, sign digest is not supported")
+	}
+	if ex.protocol == ProtocolV2 {
+		signParams, err := newSignParams(alg, digest, false)
+		if err != nil {
+			return nil, err
+		}
+		return internalSignV2(ex.command, ex.parameter, signParams)
+	}
 	return internalSign(ex.command, ex.parameter, alg, digest, false)
 }
 
+// SignBatch signs messages, v2 helper with sign_batch capability signs all messages in one request
+func (ex *ExCommandSigner) SignBatch(rand io.Reader, alg signer.JwtSignAlgorithm, messages [][]byte) ([][]byte, error) {
+	if ex.protocol != ProtocolV2 {
+		signatures := make([][]byte, 0, len(messages))
+		for _, message := range messages {
+			signature, err := ex.Sign(rand, alg, message)
+			if err != nil {
+				return nil, err
+			}
+			signatures = append(signatures, signature)
+		}
+		return signatures, nil
+	}
+	signParamsList := make([]*SignParams, 0, len(messages))
+	for _, message := range messages {
+		signParams, err := newSignParams(alg, message, true)
+		if err != nil {
+			return nil, err
+		}
+		signParamsList = append(signParamsList, signParams)
+	}
+	return internalSignBatchV2(ex.command, ex.parameter, signParamsList)
+}
+
 func internalGetPublicKey(command, parameter string) (crypto.PublicKey, error) {
 	cmd := exec.Command(command,
 		"external_public_key",
@@ -89,6 +146,10 @@ func internalGetPublicKey(command, parameter string) (crypto.PublicKey, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "error in base64 decode: %s", externalPublicKeyResponse.PublicKeyBase64)
 	}
+	return parsePublicKey(publicKeyDer)
+}
+
+func parsePublicKey(publicKeyDer []byte) (crypto.PublicKey, error) {
 	publicKey, err := x509.ParsePKIXPublicKey(publicKeyDer)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse public key")
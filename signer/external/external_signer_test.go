@@ -0,0 +1,241 @@
+package external
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+)
+
+const envTestSignerKey = "ALIBABA_CLOUD_IDAAS_TEST_EXTERNAL_SIGNER_KEY"
+
+// TestMain test binary acts as external signer command when env is set
+func TestMain(m *testing.M) {
+	if keyPem := os.Getenv(envTestSignerKey); keyPem != "" {
+		if err := runTestSigner(keyPem, os.Args[1:]); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runTestSigner(keyPem string, args []string) error {
+	block, _ := pem.Decode([]byte(keyPem))
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	publicKeyDer, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return err
+	}
+	sign := func(messageBase64 string) (string, error) {
+		message, err := base64.StdEncoding.DecodeString(messageBase64)
+		if err != nil {
+			return "", err
+		}
+		digest := signer.ES256.GetHash().New()
+		digest.Write(message)
+		signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest.Sum(nil))
+		return base64.StdEncoding.EncodeToString(signature), err
+	}
+
+	switch args[0] {
+	case "external_public_key":
+		return json.NewEncoder(os.Stdout).Encode(&PublicKeyResponse{
+			Success: true, PublicKeyBase64: base64.StdEncoding.EncodeToString(publicKeyDer)})
+	case "external_sign":
+		// external_sign --alg ES256 --parameter p --message-base64 m --message-type raw
+		signature, err := sign(args[6])
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(&SignResponse{Success: true, SignatureBase64: signature})
+	case "external_signer_serve":
+		for {
+			var request struct {
+				Id     int64           `json:"id"`
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			if err = ReadMessage(os.Stdin, &request); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			response := &RpcResponse{JsonRpc: "2.0", Id: request.Id}
+			var result interface{}
+			switch request.Method {
+			case MethodInitialize:
+				capabilities := []string{CapabilitySignBatch}
+				if args[2] == "no-batch" {
+					capabilities = []string{}
+				}
+				result = &InitializeResult{ProtocolVersion: ProtocolVersion2, Capabilities: capabilities}
+			case MethodPublicKey:
+				if args[2] == "no-response" {
+					// helper hangs, e.g. waiting for a prompt that is never answered
+					time.Sleep(time.Minute)
+				}
+				result = &PublicKeyResult{PublicKeyBase64: base64.StdEncoding.EncodeToString(publicKeyDer)}
+			case MethodSign:
+				var signParams SignParams
+				_ = json.Unmarshal(request.Params, &signParams)
+				signature, err := sign(signParams.MessageBase64)
+				if err != nil {
+					return err
+				}
+				result = &SignResult{SignatureBase64: signature}
+			case MethodSignBatch:
+				if args[2] == "no-batch" {
+					response.Error = &RpcError{Code: -32601, Message: "method not found: " + request.Method}
+					break
+				}
+				var signBatchParams SignBatchParams
+				_ = json.Unmarshal(request.Params, &signBatchParams)
+				signBatchResult := &SignBatchResult{}
+				for _, signParams := range signBatchParams.Requests {
+					signature, err := sign(signParams.MessageBase64)
+					if err != nil {
+						return err
+					}
+					signBatchResult.Results = append(signBatchResult.Results, &SignBatchItemResult{SignatureBase64: signature})
+				}
+				result = signBatchResult
+			case MethodShutdown:
+			default:
+				response.Error = &RpcError{Code: -32601, Message: "method not found: " + request.Method}
+			}
+			if result != nil {
+				response.Result, _ = json.Marshal(result)
+			}
+			if err = WriteMessage(os.Stdout, response); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("unknown command: %v", args)
+}
+
+func TestExCommandSigner(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envTestSignerKey, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})))
+	defer ShutdownHelpers()
+
+	jwk, err := jwt.NewJwk(privateKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet := jwt.NewStaticKeySet(&jwt.Jwks{Keys: []*jwt.Jwk{jwk}})
+
+	for _, protocol := range []string{ProtocolV1, ProtocolV2} {
+		t.Run(protocol, func(t *testing.T) {
+			exCommandSigner, err := NewExCommandSigner(os.Args[0], "parameter1", protocol)
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := exCommandSigner.Public()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !privateKey.PublicKey.Equal(publicKey) {
+				t.Fatal("public key mismatch")
+			}
+			exJwtSigner := signer.NewExJwtSigner("", signer.ES256, exCommandSigner)
+			for i := 0; i < 2; i++ {
+				token, err := exJwtSigner.SignJwtWithOptions(nil, &signer.JwtSignerOptions{Validity: time.Minute})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err = jwt.Verify(token, keySet, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// helper without sign_batch capability signs one by one
+			for _, parameter := range []string{"parameter1", "no-batch"} {
+				batchSigner, err := NewExCommandSigner(os.Args[0], parameter, protocol)
+				if err != nil {
+					t.Fatal(err)
+				}
+				messages := [][]byte{[]byte("message1"), []byte("message2")}
+				signatures, err := batchSigner.SignBatch(rand.Reader, signer.ES256, messages)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(signatures) != len(messages) {
+					t.Fatalf("signatures: %d, expected: %d", len(signatures), len(messages))
+				}
+				for i, signature := range signatures {
+					digest := signer.ES256.GetHash().New()
+					digest.Write(messages[i])
+					if !ecdsa.VerifyASN1(&privateKey.PublicKey, digest.Sum(nil), signature) {
+						t.Errorf("signature #%d of %s is invalid", i, parameter)
+					}
+				}
+			}
+		})
+	}
+
+	helpersLock.Lock()
+	helperCount := len(helpers)
+	helpersLock.Unlock()
+	if helperCount != 2 {
+		t.Errorf("v2 helper process count: %d, expected: 2", helperCount)
+	}
+
+	if _, err = NewExCommandSigner(os.Args[0], "parameter1", "v3"); err == nil {
+		t.Error("protocol v3 should fail")
+	}
+}
+
+func TestExCommandSignerTimeout(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envTestSignerKey, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})))
+	defer ShutdownHelpers()
+
+	originalTimeout := helperCallTimeout
+	helperCallTimeout = 500 * time.Millisecond
+	defer func() { helperCallTimeout = originalTimeout }()
+
+	exCommandSigner, err := NewExCommandSigner(os.Args[0], "no-response", ProtocolV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err = exCommandSigner.Public(); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("timeout takes too long: %s", elapsed)
+	}
+}
@@ -0,0 +1,402 @@
+package external
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+	"github.com/pkg/errors"
+)
+
+// Protocol v2, helper process is started once by `<command> external_signer_serve --parameter <parameter>`,
+// and exchanges JSON-RPC 2.0 messages over stdin/stdout, every message is prefixed with 4 bytes big-endian length.
+// Helper exits when stdin is closed, prompts(e.g. vault password) MUST use TTY or stderr, stdin/stdout are reserved.
+const (
+	ProtocolV1 = "v1"
+	ProtocolV2 = "v2"
+
+	ProtocolVersion2 = 2
+
+	MethodInitialize = "initialize"
+	MethodPublicKey  = "public_key"
+	MethodSign       = "sign"
+	MethodSignBatch  = "sign_batch"
+	MethodShutdown   = "shutdown"
+
+	// CapabilitySignBatch helper accepts sign_batch, public_key and sign are always required
+	CapabilitySignBatch = "sign_batch"
+
+	MaxMessageSize = 16 * 1024 * 1024
+)
+
+type RpcRequest struct {
+	JsonRpc string      `json:"jsonrpc"`
+	Id      int64       `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type RpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RpcError       `json:"error,omitempty"`
+}
+
+type RpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type InitializeParams struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+type PublicKeyResult struct {
+	PublicKeyBase64 string `json:"public_key_base64"`
+}
+
+// SignParams MessageType is raw or hash name(e.g. sha256), same as v1 `--message-type`
+type SignParams struct {
+	Alg           string `json:"alg"`
+	MessageBase64 string `json:"message_base64"`
+	MessageType   string `json:"message_type"`
+}
+
+type SignResult struct {
+	SignatureBase64 string `json:"signature_base64"`
+}
+
+type SignBatchParams struct {
+	Requests []*SignParams `json:"requests"`
+}
+
+// SignBatchResult Results are in the same order of requests
+type SignBatchResult struct {
+	Results []*SignBatchItemResult `json:"results"`
+}
+
+type SignBatchItemResult struct {
+	SignatureBase64 string `json:"signature_base64,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// WriteMessage writes length-prefixed JSON message
+func WriteMessage(w io.Writer, v interface{}) error {
+	message, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "marshal message failed")
+	}
+	if len(message) > MaxMessageSize {
+		return errors.Errorf("message too large: %d", len(message))
+	}
+	frame := make([]byte, 4+len(message))
+	binary.BigEndian.PutUint32(frame, uint32(len(message)))
+	copy(frame[4:], message)
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadMessage reads length-prefixed JSON message
+func ReadMessage(r io.Reader, v interface{}) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length > MaxMessageSize {
+		return errors.Errorf("message too large: %d", length)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(message, v); err != nil {
+		return errors.Wrapf(err, "unmarshal message failed: %s", message)
+	}
+	return nil
+}
+
+type helperProcess struct {
+	lock         sync.Mutex
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	stdout       *bufio.Reader
+	nextId       int64
+	capabilities []string
+	closed       atomic.Bool
+}
+
+var (
+	// helperCallTimeout helper may prompt user(e.g. vault password), so timeout is not too short
+	helperCallTimeout = 2 * time.Minute
+
+	helpersLock sync.Mutex
+	helpers     = map[string]*helperProcess{}
+)
+
+// getHelperProcess returns running helper process of command and parameter, helper is started when absent
+func getHelperProcess(command, parameter string) (*helperProcess, error) {
+	key := command + "\x00" + parameter
+	helpersLock.Lock()
+	defer helpersLock.Unlock()
+	if helper, ok := helpers[key]; ok && !helper.closed.Load() {
+		return helper, nil
+	}
+	helper, err := startHelperProcess(command, parameter)
+	if err != nil {
+		return nil, err
+	}
+	helpers[key] = helper
+	return helper, nil
+}
+
+func startHelperProcess(command, parameter string) (*helperProcess, error) {
+	cmd := exec.Command(command,
+		"external_signer_serve",
+		"--parameter", parameter)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "error in external_signer_serve stdin")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "error in external_signer_serve stdout")
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "error in external_signer_serve exec")
+	}
+	idaaslog.Debug.PrintfLn("Started external signer helper: %s, pid: %d", command, cmd.Process.Pid)
+	helper := &helperProcess{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+	}
+
+	var initializeResult InitializeResult
+	err = helper.call(MethodInitialize, &InitializeParams{
+		ProtocolVersion: ProtocolVersion2,
+		Capabilities:    []string{CapabilitySignBatch},
+	}, &initializeResult)
+	if err != nil {
+		helper.close()
+		return nil, errors.Wrap(err, "external signer initialize failed")
+	}
+	if initializeResult.ProtocolVersion != ProtocolVersion2 {
+		helper.close()
+		return nil, errors.Errorf("external signer protocol version %d is not supported", initializeResult.ProtocolVersion)
+	}
+	helper.capabilities = initializeResult.Capabilities
+	idaaslog.Debug.PrintfLn("External signer helper capabilities: %v", helper.capabilities)
+	return helper, nil
+}
+
+func (h *helperProcess) hasCapability(capability string) bool {
+	return slices.Contains(h.capabilities, capability)
+}
+
+// call sends request and waits response, helper is closed on I/O error or timeout and restarted by next getHelperProcess
+func (h *helperProcess) call(method string, params, result interface{}) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.closed.Load() {
+		return errors.New("external signer helper is closed")
+	}
+	h.nextId++
+	request := &RpcRequest{JsonRpc: "2.0", Id: h.nextId, Method: method, Params: params}
+	var response RpcResponse
+	done := make(chan error, 1)
+	go func() {
+		if err := WriteMessage(h.stdin, request); err != nil {
+			done <- errors.Wrapf(err, "error in external signer %s write", method)
+			return
+		}
+		if err := ReadMessage(h.stdout, &response); err != nil {
+			done <- errors.Wrapf(err, "error in external signer %s read", method)
+			return
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			h.closeLocked()
+			return err
+		}
+	case <-time.After(helperCallTimeout):
+		idaaslog.Warn.PrintfLn("External signer %s timeout, kill pid: %d", method, h.cmd.Process.Pid)
+		// kill unblocks pending write or read
+		_ = h.cmd.Process.Kill()
+		h.closeLocked()
+		return errors.Errorf("external signer %s timeout after %s", method, helperCallTimeout)
+	}
+	if response.Id != request.Id {
+		h.closeLocked()
+		return errors.Errorf("external signer %s response id mismatch: %d, expected: %d", method, response.Id, request.Id)
+	}
+	if response.Error != nil {
+		return errors.Errorf("external signer %s error: %d, %s", method, response.Error.Code, response.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return errors.Wrapf(err, "error in external signer %s unmarshal: %s", method, response.Result)
+	}
+	return nil
+}
+
+func (h *helperProcess) close() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.closeLocked()
+}
+
+// closeLocked closes stdin so helper exits, helper is killed when it does not exit in time
+func (h *helperProcess) closeLocked() {
+	if h.closed.Swap(true) {
+		return
+	}
+	_ = h.stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		_ = h.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(3 * time.Second):
+		idaaslog.Warn.PrintfLn("External signer helper does not exit, kill pid: %d", h.cmd.Process.Pid)
+		_ = h.cmd.Process.Kill()
+	}
+}
+
+// ShutdownHelpers shuts down all v2 helper processes, should be called before exit
+func ShutdownHelpers() {
+	helpersLock.Lock()
+	runningHelpers := make([]*helperProcess, 0, len(helpers))
+	for key, helper := range helpers {
+		runningHelpers = append(runningHelpers, helper)
+		delete(helpers, key)
+	}
+	helpersLock.Unlock()
+	for _, helper := range runningHelpers {
+		_ = helper.call(MethodShutdown, nil, nil)
+		helper.close()
+	}
+}
+
+func internalGetPublicKeyV2(command, parameter string) ([]byte, error) {
+	helper, err := getHelperProcess(command, parameter)
+	if err != nil {
+		return nil, err
+	}
+	var publicKeyResult PublicKeyResult
+	if err = helper.call(MethodPublicKey, nil, &publicKeyResult); err != nil {
+		return nil, err
+	}
+	publicKeyDer, err := base64.StdEncoding.DecodeString(publicKeyResult.PublicKeyBase64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error in base64 decode: %s", publicKeyResult.PublicKeyBase64)
+	}
+	return publicKeyDer, nil
+}
+
+func internalSignV2(command, parameter string, signParams *SignParams) ([]byte, error) {
+	helper, err := getHelperProcess(command, parameter)
+	if err != nil {
+		return nil, err
+	}
+	var signResult SignResult
+	if err = helper.call(MethodSign, signParams, &signResult); err != nil {
+		return nil, err
+	}
+	return decodeSignature(signResult.SignatureBase64)
+}
+
+// internalSignBatchV2 signs in one request when helper has sign_batch capability, or signs one by one
+func internalSignBatchV2(command, parameter string, signParamsList []*SignParams) ([][]byte, error) {
+	helper, err := getHelperProcess(command, parameter)
+	if err != nil {
+		return nil, err
+	}
+	signatures := make([][]byte, 0, len(signParamsList))
+	if !helper.hasCapability(CapabilitySignBatch) {
+		for _, signParams := range signParamsList {
+			signature, err := internalSignV2(command, parameter, signParams)
+			if err != nil {
+				return nil, err
+			}
+			signatures = append(signatures, signature)
+		}
+		return signatures, nil
+	}
+	var signBatchResult SignBatchResult
+	if err = helper.call(MethodSignBatch, &SignBatchParams{Requests: signParamsList}, &signBatchResult); err != nil {
+		return nil, err
+	}
+	if len(signBatchResult.Results) != len(signParamsList) {
+		return nil, errors.Errorf("external signer sign_batch results: %d, expected: %d",
+			len(signBatchResult.Results), len(signParamsList))
+	}
+	for i, result := range signBatchResult.Results {
+		if result == nil || result.Error != "" {
+			var resultError string
+			if result != nil {
+				resultError = result.Error
+			}
+			return nil, errors.Errorf("external signer sign_batch #%d error: %s", i, resultError)
+		}
+		signature, err := decodeSignature(result.SignatureBase64)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+func newSignParams(alg signer.JwtSignAlgorithm, message []byte, isRaw bool) (*SignParams, error) {
+	algStr := alg.ToString()
+	if algStr == "" {
+		return nil, errors.New("invalid algorithm")
+	}
+	messageType := "raw"
+	if !isRaw {
+		messageType = alg.GetHashStrName()
+		if len(message) != alg.GetHash().Size() {
+			return nil, errors.Errorf("Algorithm: %s requires digest length: %d, provided length: %d",
+				alg.GetHashStrName(), alg.GetHash().Size(), len(message))
+		}
+	}
+	return &SignParams{
+		Alg:           algStr,
+		MessageBase64: base64.StdEncoding.EncodeToString(message),
+		MessageType:   messageType,
+	}, nil
+}
+
+func decodeSignature(signatureBase64 string) ([]byte, error) {
+	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode signature: %s", signatureBase64)
+	}
+	return signature, nil
+}
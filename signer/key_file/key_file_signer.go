@@ -3,6 +3,7 @@ package key_file
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
@@ -48,12 +49,17 @@ func (s *KeyFileSigner) Public() (crypto.PublicKey, error) {
 		return key.Public(), nil
 	case *ecdsa.PrivateKey:
 		return key.Public(), nil
+	case ed25519.PrivateKey:
+		return key.Public(), nil
 	default:
 		return nil, errors.Errorf("unsupported private key type: %T", privateKey)
 	}
 }
 
 func (s *KeyFileSigner) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, message []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		return s.signEd25519(message)
+	}
 	hash := alg.GetHash()
 	hasher := hash.New()
 	_, err := hasher.Write(message)
@@ -67,6 +73,9 @@ func (s *KeyFileSigner) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, messag
 }
 
 func (s *KeyFileSigner) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm, digest []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		return nil, errors.New("EdDSA signs message without pre-hash, sign digest is not supported")
+	}
 	privateKey, err := s.loadKey()
 	if err != nil {
 		return nil, err
@@ -82,10 +91,10 @@ func (s *KeyFileSigner) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm,
 	var signErr error
 	switch key := privateKey.(type) {
 	case *rsa.PrivateKey:
-		if !alg.IsRsa() {
+		if !alg.IsRsa() && !alg.IsRsaPss() {
 			return nil, errors.Errorf("unsupported algorithm: %s", alg.ToString())
 		}
-		signature, signErr = key.Sign(rand, digest, hash)
+		signature, signErr = key.Sign(rand, digest, alg.GetSignerOpts())
 	case *ecdsa.PrivateKey:
 		if !alg.IsEcc() {
 			return nil, errors.Errorf("unsupported algorithm: %s", alg.ToString())
@@ -109,6 +118,18 @@ func (s *KeyFileSigner) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm,
 	return signature, nil
 }
 
+func (s *KeyFileSigner) signEd25519(message []byte) ([]byte, error) {
+	privateKey, err := s.loadKey()
+	if err != nil {
+		return nil, err
+	}
+	key, ok := privateKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("unsupported algorithm: %s for private key type: %T", signer.EdDSA.ToString(), privateKey)
+	}
+	return ed25519.Sign(key, message), nil
+}
+
 func (s *KeyFileSigner) loadKey() (crypto.PrivateKey, error) {
 	content, err := loadKeyOrFile(s.key, s.file)
 	if err != nil {
@@ -0,0 +1,99 @@
+package key_file
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+)
+
+func marshalPrivateKey(t *testing.T, privateKey crypto.PrivateKey) string {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestSignJwt(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		alg        signer.JwtSignAlgorithm
+		privateKey crypto.PrivateKey
+	}{
+		{signer.RS256, rsaKey},
+		{signer.PS256, rsaKey},
+		{signer.PS512, rsaKey},
+		{signer.ES384, ecKey},
+		{signer.EdDSA, edKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg.ToString(), func(t *testing.T) {
+			keyFileSigner, err := NewKeyFileSigner(marshalPrivateKey(t, tt.privateKey), "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := keyFileSigner.Public()
+			if err != nil {
+				t.Fatal(err)
+			}
+			jwk, err := jwt.NewJwk(publicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jwk.KeyId = "key1"
+
+			exJwtSigner := signer.NewExJwtSigner("key1", tt.alg, keyFileSigner)
+			token, err := exJwtSigner.SignJwtWithOptions(nil, &signer.JwtSignerOptions{
+				Issuer:   "client1",
+				Audience: "https://example.com/token",
+				Subject:  "client1",
+				Validity: time.Minute,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			keySet := jwt.NewStaticKeySet(&jwt.Jwks{Keys: []*jwt.Jwk{jwk}})
+			parsedJwt, err := jwt.Verify(token, keySet, &jwt.VerifyOptions{
+				Issuer:     "client1",
+				Audiences:  []string{"https://example.com/token"},
+				Algorithms: []string{tt.alg.ToString()},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsedJwt.Header.Algorithm != tt.alg.ToString() {
+				t.Errorf("alg: %s, expected: %s", parsedJwt.Header.Algorithm, tt.alg.ToString())
+			}
+		})
+	}
+
+	keyFileSigner, err := NewKeyFileSigner(marshalPrivateKey(t, edKey), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = keyFileSigner.Sign(rand.Reader, signer.PS256, []byte("message")); err == nil {
+		t.Error("PS256 with Ed25519 key should fail")
+	}
+}
@@ -0,0 +1,114 @@
+package kms
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+
+	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
+	util "github.com/alibabacloud-go/tea-utils/v2/service"
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+	"github.com/pkg/errors"
+)
+
+// Alibaba Cloud KMS RPC API
+// reference: https://www.alibabacloud.com/help/en/kms/key-management-service/developer-reference/api-asymmetricsign
+const alibabaCloudKmsApiVersion = "2016-01-20"
+
+func (s *KmsSigner) alibabaCloudGetPublicKey(credential *Credential) (crypto.PublicKey, error) {
+	body, err := s.alibabaCloudCallApi(credential, "GetPublicKey", map[string]*string{
+		"KeyId":        tea.String(s.keyId),
+		"KeyVersionId": tea.String(s.keyVersionId),
+	})
+	if err != nil {
+		return nil, err
+	}
+	publicKeyPem, _ := body["PublicKey"].(string)
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil {
+		return nil, errors.Errorf("invalid kms public key: %s", publicKeyPem)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse kms public key failed")
+	}
+	return publicKey, nil
+}
+
+func (s *KmsSigner) alibabaCloudSign(credential *Credential, alg signer.JwtSignAlgorithm, digest []byte) ([]byte, error) {
+	algorithm, err := getAlibabaCloudKmsAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+	body, err := s.alibabaCloudCallApi(credential, "AsymmetricSign", map[string]*string{
+		"KeyId":        tea.String(s.keyId),
+		"KeyVersionId": tea.String(s.keyVersionId),
+		"Algorithm":    tea.String(algorithm),
+		"Digest":       tea.String(base64.StdEncoding.EncodeToString(digest)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	value, _ := body["Value"].(string)
+	signature, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(signature) == 0 {
+		return nil, errors.Errorf("invalid kms signature: %s", value)
+	}
+	return signature, nil
+}
+
+func (s *KmsSigner) alibabaCloudCallApi(credential *Credential, action string, query map[string]*string) (map[string]interface{}, error) {
+	clientConfig := &openapi.Config{
+		AccessKeyId:     tea.String(credential.AccessKeyId),
+		AccessKeySecret: tea.String(credential.AccessKeySecret),
+		Endpoint:        tea.String(s.endpoint.Host),
+		Protocol:        tea.String(s.endpoint.Scheme),
+	}
+	if credential.SecurityToken != "" {
+		clientConfig.SecurityToken = tea.String(credential.SecurityToken)
+	}
+	if s.region != "" {
+		clientConfig.RegionId = tea.String(s.region)
+	}
+	client, err := openapi.NewClient(clientConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating kms client")
+	}
+	params := &openapi.Params{
+		Action:      tea.String(action),
+		Version:     tea.String(alibabaCloudKmsApiVersion),
+		Protocol:    tea.String(s.endpoint.Scheme),
+		Pathname:    tea.String("/"),
+		Method:      tea.String("POST"),
+		AuthType:    tea.String("AK"),
+		Style:       tea.String("RPC"),
+		ReqBodyType: tea.String("formData"),
+		BodyType:    tea.String("json"),
+	}
+	idaaslog.Debug.PrintfLn("Call Alibaba Cloud KMS: %s, key id: %s, key version id: %s", action, s.keyId, s.keyVersionId)
+	result, err := client.CallApi(params, &openapi.OpenApiRequest{Query: query}, &util.RuntimeOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "call kms %s failed", action)
+	}
+	body, ok := result["body"].(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid kms %s response: %v", action, result)
+	}
+	return body, nil
+}
+
+// getAlibabaCloudKmsAlgorithm Alibaba Cloud KMS supports SHA-256 only
+func getAlibabaCloudKmsAlgorithm(alg signer.JwtSignAlgorithm) (string, error) {
+	switch alg {
+	case signer.RS256:
+		return "RSA_PKCS1_SHA_256", nil
+	case signer.PS256:
+		return "RSA_PSS_SHA_256", nil
+	case signer.ES256:
+		return "ECDSA_SHA_256", nil
+	}
+	return "", errors.Errorf("algorithm %s is not supported by alibaba_cloud kms", alg.ToString())
+}
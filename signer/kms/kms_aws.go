@@ -0,0 +1,164 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/pkg/errors"
+)
+
+// AWS KMS JSON API
+// reference: https://docs.aws.amazon.com/kms/latest/APIReference/API_Sign.html
+
+type awsSignRequest struct {
+	KeyId            string `json:"KeyId"`
+	Message          string `json:"Message"`
+	MessageType      string `json:"MessageType"`
+	SigningAlgorithm string `json:"SigningAlgorithm"`
+}
+
+type awsSignResponse struct {
+	KeyId            string `json:"KeyId"`
+	Signature        string `json:"Signature"`
+	SigningAlgorithm string `json:"SigningAlgorithm"`
+}
+
+type awsGetPublicKeyRequest struct {
+	KeyId string `json:"KeyId"`
+}
+
+type awsGetPublicKeyResponse struct {
+	KeyId     string `json:"KeyId"`
+	PublicKey string `json:"PublicKey"`
+}
+
+type awsErrorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (s *KmsSigner) awsGetPublicKey(credential *Credential) (crypto.PublicKey, error) {
+	var response awsGetPublicKeyResponse
+	err := s.awsCallApi(credential, "GetPublicKey", &awsGetPublicKeyRequest{KeyId: s.keyId}, &response)
+	if err != nil {
+		return nil, err
+	}
+	publicKeyDer, err := base64.StdEncoding.DecodeString(response.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode kms public key failed")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(publicKeyDer)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse kms public key failed")
+	}
+	return publicKey, nil
+}
+
+func (s *KmsSigner) awsSign(credential *Credential, alg signer.JwtSignAlgorithm, digest []byte) ([]byte, error) {
+	algorithm, err := getAwsKmsAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+	var response awsSignResponse
+	err = s.awsCallApi(credential, "Sign", &awsSignRequest{
+		KeyId:            s.keyId,
+		Message:          base64.StdEncoding.EncodeToString(digest),
+		MessageType:      "DIGEST",
+		SigningAlgorithm: algorithm,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(response.Signature)
+	if err != nil || len(signature) == 0 {
+		return nil, errors.Errorf("invalid kms signature: %s", response.Signature)
+	}
+	return signature, nil
+}
+
+func (s *KmsSigner) awsCallApi(credential *Credential, action string, request, response interface{}) error {
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrapf(err, "marshal kms %s request failed", action)
+	}
+	httpRequest, err := http.NewRequest(http.MethodPost, s.endpoint.String(), bytes.NewReader(requestBody))
+	if err != nil {
+		return errors.Wrapf(err, "create kms %s request failed", action)
+	}
+	httpRequest.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	httpRequest.Header.Set("X-Amz-Target", "TrentService."+action)
+
+	payloadHash := sha256.Sum256(requestBody)
+	awsCredentials := aws.Credentials{
+		AccessKeyID:     credential.AccessKeyId,
+		SecretAccessKey: credential.AccessKeySecret,
+		SessionToken:    credential.SecurityToken,
+	}
+	err = v4.NewSigner().SignHTTP(context.Background(), awsCredentials, httpRequest,
+		hex.EncodeToString(payloadHash[:]), "kms", s.region, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "sign kms %s request failed", action)
+	}
+
+	idaaslog.Debug.PrintfLn("Call AWS KMS: %s, key id: %s", action, s.keyId)
+	httpResponse, err := utils.BuildHttpClient().Do(httpRequest)
+	if err != nil {
+		return errors.Wrapf(err, "call kms %s failed", action)
+	}
+	defer func() {
+		_ = httpResponse.Body.Close()
+	}()
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return errors.Wrapf(err, "read kms %s response failed", action)
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		var errorResponse awsErrorResponse
+		_ = json.Unmarshal(responseBody, &errorResponse)
+		return errors.Errorf("call kms %s failed, status: %d, type: %s, message: %s",
+			action, httpResponse.StatusCode, errorResponse.Type, errorResponse.Message)
+	}
+	err = json.Unmarshal(responseBody, response)
+	if err != nil {
+		return errors.Wrapf(err, "parse kms %s response failed", action)
+	}
+	return nil
+}
+
+func getAwsKmsAlgorithm(alg signer.JwtSignAlgorithm) (string, error) {
+	switch alg {
+	case signer.RS256:
+		return "RSASSA_PKCS1_V1_5_SHA_256", nil
+	case signer.RS384:
+		return "RSASSA_PKCS1_V1_5_SHA_384", nil
+	case signer.RS512:
+		return "RSASSA_PKCS1_V1_5_SHA_512", nil
+	case signer.PS256:
+		return "RSASSA_PSS_SHA_256", nil
+	case signer.PS384:
+		return "RSASSA_PSS_SHA_384", nil
+	case signer.PS512:
+		return "RSASSA_PSS_SHA_512", nil
+	case signer.ES256:
+		return "ECDSA_SHA_256", nil
+	case signer.ES384:
+		return "ECDSA_SHA_384", nil
+	case signer.ES512:
+		return "ECDSA_SHA_512", nil
+	}
+	return "", errors.Errorf("algorithm %s is not supported by aws kms", alg.ToString())
+}
@@ -0,0 +1,158 @@
+package kms
+
+import (
+	"crypto"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+	"github.com/pkg/errors"
+)
+
+const (
+	ProviderAlibabaCloud = "alibaba_cloud"
+	ProviderAws          = "aws"
+)
+
+// Credential cloud access key or STS token used to call KMS
+type Credential struct {
+	AccessKeyId     string
+	AccessKeySecret string
+	SecurityToken   string
+}
+
+// CredentialProvider is called before each KMS request, credential cache and refresh are up to provider
+type CredentialProvider func() (*Credential, error)
+
+type KmsSignerOptions struct {
+	Provider           string // required, alibaba_cloud or aws
+	KeyId              string // required, key ID, alias or ARN
+	KeyVersionId       string // required for Alibaba Cloud
+	Region             string // optional *, required for AWS
+	Endpoint           string // optional *, e.g. https://kms.cn-hangzhou.aliyuncs.com
+	CredentialProvider CredentialProvider
+	// * region, endpoint requires one
+}
+
+// KmsSigner signs with asymmetric key in cloud KMS, private key never leaves KMS
+type KmsSigner struct {
+	provider           string
+	keyId              string
+	keyVersionId       string
+	region             string
+	endpoint           *url.URL
+	credentialProvider CredentialProvider
+}
+
+func NewKmsSigner(options *KmsSignerOptions) (*KmsSigner, error) {
+	if options == nil {
+		return nil, errors.New("kms signer options is nil")
+	}
+	if options.Provider != ProviderAlibabaCloud && options.Provider != ProviderAws {
+		return nil, errors.Errorf("invalid kms provider: %s, must be %s or %s",
+			options.Provider, ProviderAlibabaCloud, ProviderAws)
+	}
+	if options.KeyId == "" {
+		return nil, errors.New("kms key id is empty")
+	}
+	if options.Provider == ProviderAlibabaCloud && options.KeyVersionId == "" {
+		return nil, errors.New("kms key version id is required for alibaba_cloud")
+	}
+	if options.Provider == ProviderAws && options.Region == "" {
+		return nil, errors.New("kms region is required for aws")
+	}
+	if options.CredentialProvider == nil {
+		return nil, errors.New("kms credential provider is nil")
+	}
+	endpoint, err := resolveEndpoint(options.Provider, options.Region, options.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &KmsSigner{
+		provider:           options.Provider,
+		keyId:              options.KeyId,
+		keyVersionId:       options.KeyVersionId,
+		region:             options.Region,
+		endpoint:           endpoint,
+		credentialProvider: options.CredentialProvider,
+	}, nil
+}
+
+func (s *KmsSigner) Public() (crypto.PublicKey, error) {
+	credential, err := s.fetchCredential()
+	if err != nil {
+		return nil, err
+	}
+	if s.provider == ProviderAws {
+		return s.awsGetPublicKey(credential)
+	}
+	return s.alibabaCloudGetPublicKey(credential)
+}
+
+func (s *KmsSigner) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, message []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		return nil, errors.New("EdDSA is not supported by kms signer")
+	}
+	hasher := alg.GetHash().New()
+	_, err := hasher.Write(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash message")
+	}
+	return s.SignDigest(rand, alg, hasher.Sum(nil))
+}
+
+// SignDigest returns PKCS#1 v1.5 or RSASSA-PSS signature, or ASN.1 DER encoded ECDSA signature
+func (s *KmsSigner) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm, digest []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		return nil, errors.New("EdDSA is not supported by kms signer")
+	}
+	hash := alg.GetHash()
+	if len(digest) != hash.Size() {
+		return nil, errors.Errorf("Algorithm: %s requires digest length: %d, provided length: %d",
+			alg.GetHashStrName(), hash.Size(), len(digest))
+	}
+	credential, err := s.fetchCredential()
+	if err != nil {
+		return nil, err
+	}
+	if s.provider == ProviderAws {
+		return s.awsSign(credential, alg, digest)
+	}
+	return s.alibabaCloudSign(credential, alg, digest)
+}
+
+func (s *KmsSigner) fetchCredential() (*Credential, error) {
+	credential, err := s.credentialProvider()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch kms credential failed")
+	}
+	if credential == nil || credential.AccessKeyId == "" || credential.AccessKeySecret == "" {
+		return nil, errors.New("kms credential is empty")
+	}
+	return credential, nil
+}
+
+func resolveEndpoint(provider, region, endpoint string) (*url.URL, error) {
+	if endpoint == "" {
+		if region == "" {
+			return nil, errors.New("kms region or endpoint is required")
+		}
+		if provider == ProviderAws {
+			endpoint = "https://kms." + region + ".amazonaws.com"
+		} else {
+			endpoint = "https://kms." + region + ".aliyuncs.com"
+		}
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse kms endpoint: %s", endpoint)
+	}
+	if endpointUrl.Scheme != "https" && endpointUrl.Scheme != "http" {
+		return nil, errors.Errorf("invalid kms endpoint scheme: %s", endpointUrl.Scheme)
+	}
+	return endpointUrl, nil
+}
@@ -0,0 +1,112 @@
+package kms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/jwt"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/signer/kms/kmstest"
+)
+
+func TestKmsSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := kmstest.NewServer("ak1")
+	defer server.Close()
+	server.AddKey("rsa-key", rsaKey)
+	server.AddKey("ec-key", ecKey)
+
+	credentialProvider := func() (*Credential, error) {
+		return &Credential{AccessKeyId: "ak1", AccessKeySecret: "sk1", SecurityToken: "token1"}, nil
+	}
+	tests := []struct {
+		provider string
+		keyId    string
+		alg      signer.JwtSignAlgorithm
+	}{
+		{ProviderAlibabaCloud, "rsa-key", signer.RS256},
+		{ProviderAlibabaCloud, "rsa-key", signer.PS256},
+		{ProviderAlibabaCloud, "ec-key", signer.ES256},
+		{ProviderAws, "rsa-key", signer.RS512},
+		{ProviderAws, "rsa-key", signer.PS384},
+		{ProviderAws, "ec-key", signer.ES256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider+"_"+tt.alg.ToString(), func(t *testing.T) {
+			kmsSigner, err := NewKmsSigner(&KmsSignerOptions{
+				Provider:           tt.provider,
+				KeyId:              tt.keyId,
+				KeyVersionId:       "v1",
+				Region:             "cn-hangzhou",
+				Endpoint:           server.URL,
+				CredentialProvider: credentialProvider,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := kmsSigner.Public()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !publicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(publicKeyOf(tt.keyId, rsaKey, ecKey)) {
+				t.Fatal("public key mismatch")
+			}
+			jwk, err := jwt.NewJwk(publicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jwk.KeyId = tt.keyId
+
+			exJwtSigner := signer.NewExJwtSigner(tt.keyId, tt.alg, kmsSigner)
+			token, err := exJwtSigner.SignJwtWithOptions(nil, &signer.JwtSignerOptions{
+				Subject:  "client1",
+				Validity: time.Minute,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			keySet := jwt.NewStaticKeySet(&jwt.Jwks{Keys: []*jwt.Jwk{jwk}})
+			if _, err = jwt.Verify(token, keySet, &jwt.VerifyOptions{Algorithms: []string{tt.alg.ToString()}}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	kmsSigner, err := NewKmsSigner(&KmsSignerOptions{
+		Provider: ProviderAws,
+		KeyId:    "rsa-key",
+		Region:   "us-east-1",
+		Endpoint: server.URL,
+		CredentialProvider: func() (*Credential, error) {
+			return &Credential{AccessKeyId: "ak2", AccessKeySecret: "sk2"}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kmsSigner.Public(); err == nil {
+		t.Error("unknown access key should fail")
+	}
+	if _, err = kmsSigner.Sign(rand.Reader, signer.EdDSA, []byte("message")); err == nil {
+		t.Error("EdDSA should fail")
+	}
+}
+
+func publicKeyOf(keyId string, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) crypto.PublicKey {
+	if keyId == "rsa-key" {
+		return rsaKey.Public()
+	}
+	return ecKey.Public()
+}
@@ -0,0 +1,212 @@
+// Package kmstest provides a fake Alibaba Cloud and AWS KMS server, so KMS signer can be tested offline.
+// Request signatures are NOT verified, only the access key ID is checked.
+package kmstest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+type Server struct {
+	*httptest.Server
+	accessKeyId string
+	lock        sync.Mutex
+	keys        map[string]crypto.Signer
+}
+
+type signAlgorithm struct {
+	hash crypto.Hash
+	pss  bool
+}
+
+var signAlgorithms = map[string]*signAlgorithm{
+	// Alibaba Cloud
+	"RSA_PKCS1_SHA_256": {crypto.SHA256, false},
+	"RSA_PSS_SHA_256":   {crypto.SHA256, true},
+	"ECDSA_SHA_256":     {crypto.SHA256, false},
+	// AWS
+	"RSASSA_PKCS1_V1_5_SHA_256": {crypto.SHA256, false},
+	"RSASSA_PKCS1_V1_5_SHA_384": {crypto.SHA384, false},
+	"RSASSA_PKCS1_V1_5_SHA_512": {crypto.SHA512, false},
+	"RSASSA_PSS_SHA_256":        {crypto.SHA256, true},
+	"RSASSA_PSS_SHA_384":        {crypto.SHA384, true},
+	"RSASSA_PSS_SHA_512":        {crypto.SHA512, true},
+	"ECDSA_SHA_384":             {crypto.SHA384, false},
+	"ECDSA_SHA_512":             {crypto.SHA512, false},
+}
+
+// NewServer starts fake KMS server, requests must use accessKeyId, close server after use
+func NewServer(accessKeyId string) *Server {
+	server := &Server{
+		accessKeyId: accessKeyId,
+		keys:        map[string]crypto.Signer{},
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	return server
+}
+
+// AddKey adds RSA or ECDSA private key, Alibaba Cloud key version ID is ignored
+func (s *Server) AddKey(keyId string, privateKey crypto.Signer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.keys[keyId] = privateKey
+}
+
+func (s *Server) getKey(keyId string) crypto.Signer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.keys[keyId]
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	target := r.Header.Get("X-Amz-Target")
+	if target != "" {
+		s.handleAws(w, r, strings.TrimPrefix(target, "TrentService."))
+		return
+	}
+	s.handleAlibabaCloud(w, r)
+}
+
+func (s *Server) handleAlibabaCloud(w http.ResponseWriter, r *http.Request) {
+	writeError := func(code, message string) {
+		writeJson(w, http.StatusBadRequest, map[string]string{"Code": code, "Message": message, "RequestId": "fake"})
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError("InvalidParameter", err.Error())
+		return
+	}
+	if r.Form.Get("AccessKeyId") != s.accessKeyId && !strings.Contains(r.Header.Get("Authorization"), s.accessKeyId) {
+		writeError("InvalidAccessKeyId.NotFound", "Specified access key is not found.")
+		return
+	}
+	key := s.getKey(r.Form.Get("KeyId"))
+	if key == nil || r.Form.Get("KeyVersionId") == "" {
+		writeError("Forbidden.KeyNotFound", "The specified Key is not found.")
+		return
+	}
+	// RPC signature v1 sends action as parameter, ACS3 signature sends action as header
+	action := r.Form.Get("Action")
+	if action == "" {
+		action = r.Header.Get("x-acs-action")
+	}
+	switch action {
+	case "GetPublicKey":
+		publicKeyDer, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			writeError("InternalFailure", err.Error())
+			return
+		}
+		publicKeyPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDer})
+		writeJson(w, http.StatusOK, map[string]string{"PublicKey": string(publicKeyPem), "RequestId": "fake"})
+	case "AsymmetricSign":
+		digest, err := base64.StdEncoding.DecodeString(r.Form.Get("Digest"))
+		if err != nil {
+			writeError("InvalidParameter", "Digest is invalid")
+			return
+		}
+		signature, err := sign(key, r.Form.Get("Algorithm"), digest)
+		if err != nil {
+			writeError("InvalidParameter", err.Error())
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]string{
+			"Value": base64.StdEncoding.EncodeToString(signature), "RequestId": "fake"})
+	default:
+		writeError("InvalidAction.NotFound", "Specified api is not found.")
+	}
+}
+
+func (s *Server) handleAws(w http.ResponseWriter, r *http.Request, action string) {
+	writeError := func(errorType, message string) {
+		writeJson(w, http.StatusBadRequest, map[string]string{"__type": errorType, "message": message})
+	}
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+s.accessKeyId+"/") {
+		writeError("UnrecognizedClientException", "The security token included in the request is invalid.")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError("ValidationException", err.Error())
+		return
+	}
+	var request struct {
+		KeyId            string `json:"KeyId"`
+		Message          string `json:"Message"`
+		MessageType      string `json:"MessageType"`
+		SigningAlgorithm string `json:"SigningAlgorithm"`
+	}
+	if err = json.Unmarshal(body, &request); err != nil {
+		writeError("ValidationException", err.Error())
+		return
+	}
+	key := s.getKey(request.KeyId)
+	if key == nil {
+		writeError("NotFoundException", "Key '"+request.KeyId+"' does not exist")
+		return
+	}
+	switch action {
+	case "GetPublicKey":
+		publicKeyDer, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			writeError("KMSInternalException", err.Error())
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]string{
+			"KeyId": request.KeyId, "PublicKey": base64.StdEncoding.EncodeToString(publicKeyDer)})
+	case "Sign":
+		if request.MessageType != "DIGEST" {
+			writeError("ValidationException", "fake server supports DIGEST message type only")
+			return
+		}
+		digest, err := base64.StdEncoding.DecodeString(request.Message)
+		if err != nil {
+			writeError("ValidationException", "Message is invalid")
+			return
+		}
+		signature, err := sign(key, request.SigningAlgorithm, digest)
+		if err != nil {
+			writeError("ValidationException", err.Error())
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]string{"KeyId": request.KeyId,
+			"Signature": base64.StdEncoding.EncodeToString(signature), "SigningAlgorithm": request.SigningAlgorithm})
+	default:
+		writeError("UnknownOperationException", "")
+	}
+}
+
+func sign(key crypto.Signer, algorithm string, digest []byte) ([]byte, error) {
+	alg, ok := signAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("algorithm %s is not supported by key", algorithm)
+	}
+	_, isRsa := key.(*rsa.PrivateKey)
+	_, isEcdsa := key.(*ecdsa.PrivateKey)
+	if (isRsa && strings.HasPrefix(algorithm, "ECDSA")) || (isEcdsa && !strings.HasPrefix(algorithm, "ECDSA")) {
+		return nil, fmt.Errorf("algorithm %s is not supported by key", algorithm)
+	}
+	var opts crypto.SignerOpts = alg.hash
+	if alg.pss {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: alg.hash}
+	}
+	// ECDSA signature is ASN.1 DER encoded, same as KMS
+	return key.Sign(rand.Reader, digest, opts)
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,142 @@
+//go:build !disable_pkcs11
+// +build !disable_pkcs11
+
+package pkcs11
+
+import (
+	"crypto/ed25519"
+	"strings"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+// Ed25519 keys are not supported by crypto11, signed with PKCS#11 directly, specification: PKCS#11 v3.0
+const (
+	ckmEdDsa     = 0x00001057
+	ckkEcEdwards = 0x00000040
+)
+
+func (s *Pkcs11Signer) publicEd25519() (ed25519.PublicKey, error) {
+	var publicKey ed25519.PublicKey
+	err := s.withSession(func(ctx *pkcs11.Ctx, session pkcs11.SessionHandle) error {
+		object, err := findEd25519Object(ctx, session, pkcs11.CKO_PUBLIC_KEY, s.keyLabel)
+		if err != nil {
+			return err
+		}
+		attributes, err := ctx.GetAttributeValue(session, object, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to get CKA_EC_POINT")
+		}
+		publicKey, err = decodeEd25519Point(attributes[0].Value)
+		return err
+	})
+	return publicKey, err
+}
+
+func (s *Pkcs11Signer) signEd25519(message []byte) ([]byte, error) {
+	var signature []byte
+	err := s.withSession(func(ctx *pkcs11.Ctx, session pkcs11.SessionHandle) error {
+		object, err := findEd25519Object(ctx, session, pkcs11.CKO_PRIVATE_KEY, s.keyLabel)
+		if err != nil {
+			return err
+		}
+		if err = ctx.SignInit(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(ckmEdDsa, nil)}, object); err != nil {
+			return errors.Wrap(err, "failed to init CKM_EDDSA sign")
+		}
+		signature, err = ctx.Sign(session, message)
+		if err != nil {
+			return errors.Wrap(err, "failed to sign message")
+		}
+		return nil
+	})
+	return signature, err
+}
+
+func (s *Pkcs11Signer) withSession(callback func(ctx *pkcs11.Ctx, session pkcs11.SessionHandle) error) error {
+	ctx := pkcs11.New(s.config.Path)
+	if ctx == nil {
+		return errors.Errorf("failed to load PKCS#11 library: %s", s.config.Path)
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		return errors.Wrap(err, "failed to initialize PKCS#11 library")
+	}
+	defer func() {
+		_ = ctx.Finalize()
+	}()
+
+	slot, err := findSlotByTokenLabel(ctx, s.config.TokenLabel)
+	if err != nil {
+		return err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return errors.Wrap(err, "failed to open session")
+	}
+	defer func() {
+		_ = ctx.CloseSession(session)
+	}()
+	if s.config.Pin != "" {
+		err = ctx.Login(session, pkcs11.CKU_USER, s.config.Pin)
+		if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			return errors.Wrap(err, "failed to login")
+		}
+		defer func() {
+			_ = ctx.Logout(session)
+		}()
+	}
+	return callback(ctx, session)
+}
+
+func findSlotByTokenLabel(ctx *pkcs11.Ctx, tokenLabel string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get slot list")
+	}
+	for _, slot := range slots {
+		tokenInfo, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if strings.TrimRight(tokenInfo.Label, " \x00") == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, errors.Errorf("token not found, token label: %s", tokenLabel)
+}
+
+func findEd25519Object(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, class uint, keyLabel string) (pkcs11.ObjectHandle, error) {
+	err := ctx.FindObjectsInit(session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkEcEdwards),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyLabel),
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to find objects")
+	}
+	defer func() {
+		_ = ctx.FindObjectsFinal(session)
+	}()
+	objects, _, err := ctx.FindObjects(session, 1)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to find objects")
+	}
+	if len(objects) == 0 {
+		return 0, errors.Errorf("Ed25519 key not found, key label: %s", keyLabel)
+	}
+	return objects[0], nil
+}
+
+// decodeEd25519Point CKA_EC_POINT is DER encoded OCTET STRING, some tokens return raw public key
+func decodeEd25519Point(point []byte) (ed25519.PublicKey, error) {
+	if len(point) == ed25519.PublicKeySize+2 && point[0] == 0x04 && point[1] == ed25519.PublicKeySize {
+		point = point[2:]
+	}
+	if len(point) != ed25519.PublicKeySize {
+		return nil, errors.Errorf("invalid Ed25519 public key length: %d", len(point))
+	}
+	return ed25519.PublicKey(point), nil
+}
@@ -64,6 +64,10 @@ func (s *Pkcs11Signer) Public() (crypto.PublicKey, error) {
 
 	privKey, err := ctx.FindKeyPair(nil, []byte(s.keyLabel))
 	if err != nil {
+		// Ed25519 key is not supported by crypto11
+		if publicKey, ed25519Err := s.publicEd25519(); ed25519Err == nil {
+			return publicKey, nil
+		}
 		return nil, errors.Wrapf(err, "failed to find private key, key label: %s", s.keyLabel)
 	}
 	pubKey := privKey.Public()
@@ -71,6 +75,9 @@ func (s *Pkcs11Signer) Public() (crypto.PublicKey, error) {
 }
 
 func (s *Pkcs11Signer) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, message []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		return s.signEd25519(message)
+	}
 	hash := alg.GetHash()
 	hasher := hash.New()
 	_, err := hasher.Write(message)
@@ -83,6 +90,9 @@ func (s *Pkcs11Signer) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, message
 }
 
 func (s *Pkcs11Signer) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm, digest []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		return nil, errors.New("EdDSA signs message without pre-hash, sign digest is not supported")
+	}
 	ctx, err := crypto11.Configure(s.config)
 	if err != nil {
 		return nil, err
@@ -100,7 +110,8 @@ func (s *Pkcs11Signer) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm, d
 			alg.GetHashStrName(), hash.Size(), len(digest))
 	}
 
-	signature, err := pkcsSigner.Sign(rand, digest, hash)
+	// RSASSA-PSS is signed with CKM_RSA_PKCS_PSS by crypto11
+	signature, err := pkcsSigner.Sign(rand, digest, alg.GetSignerOpts())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to sign message")
 	}
@@ -3,6 +3,7 @@ package signer
 import (
 	"crypto"
 	"crypto/rand"
+	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -23,16 +24,31 @@ const (
 	ES256
 	ES384
 	ES512
+	PS256
+	PS384
+	PS512
+	EdDSA
 )
 
+// IsRsa RSASSA-PKCS1-v1_5, signature is deterministic
 func (a JwtSignAlgorithm) IsRsa() bool {
 	return a == RS256 || a == RS384 || a == RS512
 }
 
+// IsRsaPss RSASSA-PSS, salt length equals hash length, specification: RFC7518 3.5
+func (a JwtSignAlgorithm) IsRsaPss() bool {
+	return a == PS256 || a == PS384 || a == PS512
+}
+
 func (a JwtSignAlgorithm) IsEcc() bool {
 	return a == ES256 || a == ES384 || a == ES512
 }
 
+// IsEdDSA Ed25519, message is signed without pre-hash, specification: RFC8037
+func (a JwtSignAlgorithm) IsEdDSA() bool {
+	return a == EdDSA
+}
+
 func (a JwtSignAlgorithm) ToString() string {
 	switch a {
 	case RS256:
@@ -47,6 +63,14 @@ func (a JwtSignAlgorithm) ToString() string {
 		return "ES384"
 	case ES512:
 		return "ES512"
+	case PS256:
+		return "PS256"
+	case PS384:
+		return "PS384"
+	case PS512:
+		return "PS512"
+	case EdDSA:
+		return "EdDSA"
 	default:
 		panic("SHOULD NOT HAPPEN")
 	}
@@ -66,6 +90,15 @@ func (a JwtSignAlgorithm) GetHash() crypto.Hash {
 		return crypto.SHA512
 	case RS512:
 		return crypto.SHA512
+	case PS256:
+		return crypto.SHA256
+	case PS384:
+		return crypto.SHA384
+	case PS512:
+		return crypto.SHA512
+	case EdDSA:
+		// Ed25519 does not pre-hash message
+		return crypto.Hash(0)
 	default:
 		panic("SHOULD NOT HAPPEN")
 	}
@@ -85,11 +118,30 @@ func (a JwtSignAlgorithm) GetHashStrName() string {
 		return "sha512"
 	case RS512:
 		return "sha512"
+	case PS256:
+		return "sha256"
+	case PS384:
+		return "sha384"
+	case PS512:
+		return "sha512"
+	case EdDSA:
+		return "raw"
 	default:
 		panic("SHOULD NOT HAPPEN")
 	}
 }
 
+// GetSignerOpts options of crypto.Signer
+func (a JwtSignAlgorithm) GetSignerOpts() crypto.SignerOpts {
+	if a.IsRsaPss() {
+		return &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       a.GetHash(),
+		}
+	}
+	return a.GetHash()
+}
+
 func ParseJwtSignAlgorithm(s string) (JwtSignAlgorithm, error) {
 	switch s {
 	case "RS256":
@@ -104,6 +156,14 @@ func ParseJwtSignAlgorithm(s string) (JwtSignAlgorithm, error) {
 		return ES384, nil
 	case "ES512":
 		return ES512, nil
+	case "PS256":
+		return PS256, nil
+	case "PS384":
+		return PS384, nil
+	case "PS512":
+		return PS512, nil
+	case "EdDSA":
+		return EdDSA, nil
 	default:
 		return 0, errors.Errorf("unsupported sign algorithm %s", s)
 	}
@@ -61,6 +61,10 @@ func (s *YubiKeyPivSigner) Public() (crypto.PublicKey, error) {
 }
 
 func (s *YubiKeyPivSigner) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, message []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		// Ed25519 signs message without pre-hash, requires YubiKey firmware 5.7+
+		return s.sign(rand, message, alg.GetSignerOpts())
+	}
 	hash := alg.GetHash()
 	hasher := hash.New()
 	_, err := hasher.Write(message)
@@ -73,6 +77,18 @@ func (s *YubiKeyPivSigner) Sign(rand io.Reader, alg signer.JwtSignAlgorithm, mes
 }
 
 func (s *YubiKeyPivSigner) SignDigest(rand io.Reader, alg signer.JwtSignAlgorithm, digest []byte) ([]byte, error) {
+	if alg.IsEdDSA() {
+		return nil, errors.New("EdDSA signs message without pre-hash, sign digest is not supported")
+	}
+	hash := alg.GetHash()
+	if len(digest) != hash.Size() {
+		return nil, errors.Errorf("Algorithm: %s requires digest length: %d, provided length: %d",
+			alg.GetHashStrName(), hash.Size(), len(digest))
+	}
+	return s.sign(rand, digest, alg.GetSignerOpts())
+}
+
+func (s *YubiKeyPivSigner) sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
 	yubikey, err := findYubiKey()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to find YubiKey")
@@ -98,13 +114,7 @@ func (s *YubiKeyPivSigner) SignDigest(rand io.Reader, alg signer.JwtSignAlgorith
 		return nil, errors.Errorf("expected private key to implement crypto.Signer")
 	}
 
-	hash := alg.GetHash()
-	if len(digest) != hash.Size() {
-		return nil, errors.Errorf("Algorithm: %s requires digest length: %d, provided length: %d",
-			alg.GetHashStrName(), hash.Size(), len(digest))
-	}
-
-	signature, err := pivSigner.Sign(rand, digest, hash)
+	signature, err := pivSigner.Sign(rand, digest, opts)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to sign message")
 	}
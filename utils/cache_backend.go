@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CacheBackendFile encrypted files in ~/.aliyun/alibaba-cloud-idaas/, key is derived from seed files in $HOME
+	CacheBackendFile = "file"
+	// CacheBackendKeyring OS keyring, freedesktop Secret Service(D-Bus) on Linux, Keychain on macOS,
+	// Credential Manager on Windows
+	CacheBackendKeyring = "keyring"
+	// CacheBackendPass pass(https://www.passwordstore.org/), encrypted with GPG
+	CacheBackendPass = "pass"
+)
+
+var (
+	cacheBackendMutex      sync.Mutex
+	cacheBackendFromConfig string
+)
+
+// SetCacheBackendFromConfig sets cache backend from config file, environment has higher priority
+func SetCacheBackendFromConfig(cacheBackend string) {
+	cacheBackendMutex.Lock()
+	defer cacheBackendMutex.Unlock()
+	cacheBackendFromConfig = cacheBackend
+}
+
+// GetCacheBackend returns cache backend, priority: environment, config file, default file
+func GetCacheBackend() string {
+	if cacheBackend := strings.TrimSpace(os.Getenv(constants.EnvCacheBackend)); cacheBackend != "" {
+		return cacheBackend
+	}
+	cacheBackendMutex.Lock()
+	defer cacheBackendMutex.Unlock()
+	if cacheBackendFromConfig != "" {
+		return cacheBackendFromConfig
+	}
+	return CacheBackendFile
+}
+
+func GetCacheReadWrite() (CacheReadWrite, error) {
+	cacheBackend := GetCacheBackend()
+	idaaslog.Debug.PrintfLn("Cache backend: %s", cacheBackend)
+	switch cacheBackend {
+	case CacheBackendFile:
+		return &EncryptedFileCacheReadWrite{}, nil
+	case CacheBackendKeyring:
+		return &KeyringCacheReadWrite{}, nil
+	case CacheBackendPass:
+		return &PassCacheReadWrite{}, nil
+	}
+	return nil, errors.Errorf("unknown cache backend: %s, supports: %s, %s, %s",
+		cacheBackend, CacheBackendFile, CacheBackendKeyring, CacheBackendPass)
+}
+
+func ReadCache(category, key string) (string, error) {
+	cacheReadWrite, err := GetCacheReadWrite()
+	if err != nil {
+		return "", err
+	}
+	return cacheReadWrite.Read(category, key)
+}
+
+func WriteCache(category, key, content string) error {
+	cacheReadWrite, err := GetCacheReadWrite()
+	if err != nil {
+		return err
+	}
+	return cacheReadWrite.Write(category, key, content)
+}
+
+func RemoveCache(category, key string) error {
+	cacheReadWrite, err := GetCacheReadWrite()
+	if err != nil {
+		return err
+	}
+	return cacheReadWrite.Remove(category, key)
+}
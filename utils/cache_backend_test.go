@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+)
+
+func TestGetCacheBackend(t *testing.T) {
+	t.Setenv(constants.EnvCacheBackend, "")
+	SetCacheBackendFromConfig("")
+	defer SetCacheBackendFromConfig("")
+
+	if GetCacheBackend() != CacheBackendFile {
+		t.Errorf("default cache backend: %s", GetCacheBackend())
+	}
+	SetCacheBackendFromConfig(CacheBackendPass)
+	if GetCacheBackend() != CacheBackendPass {
+		t.Errorf("cache backend from config: %s", GetCacheBackend())
+	}
+	t.Setenv(constants.EnvCacheBackend, CacheBackendKeyring)
+	if GetCacheBackend() != CacheBackendKeyring {
+		t.Errorf("cache backend from env: %s", GetCacheBackend())
+	}
+	t.Setenv(constants.EnvCacheBackend, "unknown")
+	if _, err := GetCacheReadWrite(); err == nil {
+		t.Errorf("unknown cache backend should fail")
+	}
+}
+
+// fakePass stores content as plaintext, only for test
+const fakePass = `#!/bin/sh
+cmd="$1"; shift
+while [ "${1#--}" != "$1" ]; do shift; done
+f="$PASSWORD_STORE_DIR/$1.gpg"
+case "$cmd" in
+  show) cat "$f" ;;
+  insert) mkdir -p "$(dirname "$f")" && cat > "$f" ;;
+  rm) rm -rf "$f" "$PASSWORD_STORE_DIR/$1" ;;
+esac
+`
+
+func TestPassCacheReadWrite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pass is not supported on windows")
+	}
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, passCommand), []byte(fakePass), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(envPasswordStoreDir, t.TempDir())
+
+	p := &PassCacheReadWrite{}
+	content, err := p.Read("cloud_token", "key1")
+	if err != nil || content != "" {
+		t.Fatalf("read not exists: %q, %v", content, err)
+	}
+	if err = p.Write("cloud_token", "key1", `{"content":"value"}`); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	content, err = p.Read("cloud_token", "key1")
+	if err != nil || content != `{"content":"value"}` {
+		t.Fatalf("read: %q, %v", content, err)
+	}
+	if err = p.Remove("cloud_token", "key1"); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	content, _ = p.Read("cloud_token", "key1")
+	if content != "" {
+		t.Errorf("read after remove: %q", content)
+	}
+	_ = p.Write("cloud_token", "key2", "value2")
+	if err = p.Clear("cloud_token"); err != nil {
+		t.Fatalf("clear failed: %v", err)
+	}
+	content, _ = p.Read("cloud_token", "key2")
+	if content != "" {
+		t.Errorf("read after clear: %q", content)
+	}
+}
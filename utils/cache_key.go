@@ -0,0 +1,363 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// CacheEncryptionModeDefault key derived from seed files in $HOME
+	CacheEncryptionModeDefault = "default"
+	// CacheEncryptionModePassphrase random key wrapped by key derived from passphrase
+	CacheEncryptionModePassphrase = "passphrase"
+	// CacheEncryptionModeSigner random key wrapped by key derived from signature of external signer
+	CacheEncryptionModeSigner = "signer"
+
+	CacheKdfArgon2id = "argon2id"
+	CacheKdfScrypt   = "scrypt"
+
+	cacheKeyFilename     = "cache_key.json"
+	cacheKeyFileVersion  = 1
+	cacheKeySignerPrefix = "alibaba-cloud-idaas cache key challenge v1:"
+	cacheKeyHkdfInfo     = "alibaba-cloud-idaas cache key wrapping v1"
+)
+
+type CacheKeyOptions struct {
+	// Id identifies the options, cached key is reset when Id changes
+	Id   string
+	Mode string
+	Kdf  string
+	// Sign signs challenge by external signer, returns signature and signer ID(e.g. public key digest),
+	// signature MUST be deterministic(e.g. RSA PKCS#1 v1.5), so the same key can be derived every time
+	Sign func(challenge []byte) ([]byte, string, error)
+}
+
+type CacheKeyArgon2idParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+type CacheKeyScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// CacheKeyFile stores wrapped(encrypted) cache key, the wrapping key is NOT stored
+type CacheKeyFile struct {
+	Version    int                     `json:"version"`
+	Mode       string                  `json:"mode"`
+	Kdf        string                  `json:"kdf,omitempty"`
+	Argon2id   *CacheKeyArgon2idParams `json:"argon2id,omitempty"`
+	Scrypt     *CacheKeyScryptParams   `json:"scrypt,omitempty"`
+	SignerId   string                  `json:"signer_id,omitempty"`
+	Salt       string                  `json:"salt"`
+	WrappedKey string                  `json:"wrapped_key"`
+}
+
+var (
+	cacheKeyMutex   sync.Mutex
+	cacheKeyOptions *CacheKeyOptions
+	// unwrapped cache key, kept in memory for the life of the process
+	unwrappedCacheKey []byte
+)
+
+// SetCacheKeyOptions sets cache key wrapping options from config file, nil means default mode
+func SetCacheKeyOptions(options *CacheKeyOptions) {
+	cacheKeyMutex.Lock()
+	defer cacheKeyMutex.Unlock()
+	if getCacheKeyOptionsId(cacheKeyOptions) != getCacheKeyOptionsId(options) {
+		unwrappedCacheKey = nil
+	}
+	cacheKeyOptions = options
+}
+
+func GetCacheEncryptionMode() string {
+	cacheKeyMutex.Lock()
+	defer cacheKeyMutex.Unlock()
+	if cacheKeyOptions == nil || cacheKeyOptions.Mode == "" {
+		return CacheEncryptionModeDefault
+	}
+	return cacheKeyOptions.Mode
+}
+
+// RotateCacheKey removes cache key file, a new cache key is generated when next encryption
+func RotateCacheKey() error {
+	cacheKeyMutex.Lock()
+	defer cacheKeyMutex.Unlock()
+	cacheKeyFile, err := getCacheKeyFilename()
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(cacheKeyFile); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "remove cache key file: %s failed", cacheKeyFile)
+	}
+	unwrappedCacheKey = nil
+	return nil
+}
+
+func getCacheKeyOptionsId(options *CacheKeyOptions) string {
+	if options == nil {
+		return ""
+	}
+	return options.Mode + "|" + options.Id
+}
+
+func getUnwrappedCacheKey() ([]byte, error) {
+	cacheKeyMutex.Lock()
+	defer cacheKeyMutex.Unlock()
+	if unwrappedCacheKey != nil {
+		return unwrappedCacheKey, nil
+	}
+	if cacheKeyOptions == nil || cacheKeyOptions.Mode == "" || cacheKeyOptions.Mode == CacheEncryptionModeDefault {
+		return nil, errors.New("cache key wrapping is not configured")
+	}
+	cacheKeyFilename, err := getCacheKeyFilename()
+	if err != nil {
+		return nil, err
+	}
+	cacheKeyFile, err := readCacheKeyFile(cacheKeyFilename)
+	if err != nil {
+		return nil, err
+	}
+	if cacheKeyFile != nil && cacheKeyFile.Mode != cacheKeyOptions.Mode {
+		// NEVER overwrite cache key silently, entries encrypted by the old cache key cannot be read any more
+		return nil, errors.Errorf("cache key file: %s mode: %s mismatch cache encryption mode: %s, "+
+			"run `alibaba-cloud-idaas migrate-cache --rotate-key` to reset cache key "+
+			"(cache entries encrypted by old cache key are skipped)",
+			cacheKeyFilename, cacheKeyFile.Mode, cacheKeyOptions.Mode)
+	}
+	var key []byte
+	if cacheKeyFile == nil {
+		key, cacheKeyFile, err = newWrappedCacheKey(cacheKeyOptions)
+		if err != nil {
+			return nil, err
+		}
+		cacheKeyFileBytes, err := json.MarshalIndent(cacheKeyFile, "", "  ")
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal cache key file failed")
+		}
+		if err = WriteFileAtomic(cacheKeyFilename, cacheKeyFileBytes, 0600); err != nil {
+			return nil, errors.Wrapf(err, "write cache key file: %s failed", cacheKeyFilename)
+		}
+		idaaslog.Info.PrintfLn("Generated new cache key, mode: %s", cacheKeyOptions.Mode)
+	} else {
+		key, err = unwrapCacheKey(cacheKeyOptions, cacheKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	unwrappedCacheKey = key
+	return key, nil
+}
+
+func newWrappedCacheKey(options *CacheKeyOptions) ([]byte, *CacheKeyFile, error) {
+	key := make([]byte, 32)
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, errors.Wrap(err, "generate cache key failed")
+	}
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, nil, errors.Wrap(err, "generate salt failed")
+	}
+	cacheKeyFile := &CacheKeyFile{
+		Version: cacheKeyFileVersion,
+		Mode:    options.Mode,
+		Salt:    base64.RawURLEncoding.EncodeToString(salt),
+	}
+	switch options.Mode {
+	case CacheEncryptionModePassphrase:
+		cacheKeyFile.Kdf = options.Kdf
+		if cacheKeyFile.Kdf == "" {
+			cacheKeyFile.Kdf = CacheKdfArgon2id
+		}
+		switch cacheKeyFile.Kdf {
+		case CacheKdfArgon2id:
+			// RFC9106 Section 7.4 second recommended option
+			cacheKeyFile.Argon2id = &CacheKeyArgon2idParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+		case CacheKdfScrypt:
+			cacheKeyFile.Scrypt = &CacheKeyScryptParams{N: 1 << 15, R: 8, P: 1}
+		default:
+			return nil, nil, errors.Errorf("unknown cache key kdf: %s, supports: %s, %s",
+				cacheKeyFile.Kdf, CacheKdfArgon2id, CacheKdfScrypt)
+		}
+	case CacheEncryptionModeSigner:
+		// filled by deriveWrappingKey
+	default:
+		return nil, nil, errors.Errorf("unknown cache encryption mode: %s, supports: %s, %s, %s",
+			options.Mode, CacheEncryptionModeDefault, CacheEncryptionModePassphrase, CacheEncryptionModeSigner)
+	}
+	wrappingKey, err := deriveWrappingKey(options, cacheKeyFile, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	wrappedKey, err := encryptWithKey(wrappingKey, ciphertextPrefixDefault, key, []byte(cacheKeyFilename))
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheKeyFile.WrappedKey = wrappedKey
+	return key, cacheKeyFile, nil
+}
+
+func unwrapCacheKey(options *CacheKeyOptions, cacheKeyFile *CacheKeyFile) ([]byte, error) {
+	wrappingKey, err := deriveWrappingKey(options, cacheKeyFile, false)
+	if err != nil {
+		return nil, err
+	}
+	key, err := decryptWithKey(wrappingKey, ciphertextPrefixDefault, cacheKeyFile.WrappedKey, []byte(cacheKeyFilename))
+	if err != nil {
+		if cacheKeyFile.Mode == CacheEncryptionModePassphrase {
+			return nil, errors.New("unwrap cache key failed, wrong passphrase?")
+		}
+		return nil, errors.Wrap(err, "unwrap cache key failed")
+	}
+	return key, nil
+}
+
+func deriveWrappingKey(options *CacheKeyOptions, cacheKeyFile *CacheKeyFile, isNew bool) ([]byte, error) {
+	salt, err := base64.RawURLEncoding.DecodeString(cacheKeyFile.Salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cache key salt")
+	}
+	switch cacheKeyFile.Mode {
+	case CacheEncryptionModePassphrase:
+		passphrase, err := readCachePassphrase(isNew)
+		if err != nil {
+			return nil, err
+		}
+		return derivePassphraseKey(passphrase, salt, cacheKeyFile)
+	case CacheEncryptionModeSigner:
+		if options.Sign == nil {
+			return nil, errors.New("cache encryption signer is not configured")
+		}
+		challenge := []byte(cacheKeySignerPrefix + cacheKeyFile.Salt)
+		signature, signerId, err := options.Sign(challenge)
+		if err != nil {
+			return nil, errors.Wrap(err, "sign cache key challenge failed")
+		}
+		if isNew {
+			// make sure the same wrapping key can be derived next time
+			signature2, _, err := options.Sign(challenge)
+			if err != nil {
+				return nil, errors.Wrap(err, "sign cache key challenge failed")
+			}
+			if !bytes.Equal(signature, signature2) {
+				return nil, errors.New("signature of cache encryption signer is not deterministic, RSA key is required")
+			}
+			cacheKeyFile.SignerId = signerId
+		} else if cacheKeyFile.SignerId != signerId {
+			return nil, errors.Errorf("cache encryption signer mismatch, expected: %s, actual: %s",
+				cacheKeyFile.SignerId, signerId)
+		}
+		return hkdfKey(signature, salt)
+	}
+	return nil, errors.Errorf("unknown cache encryption mode: %s", cacheKeyFile.Mode)
+}
+
+func derivePassphraseKey(passphrase, salt []byte, cacheKeyFile *CacheKeyFile) ([]byte, error) {
+	switch cacheKeyFile.Kdf {
+	case CacheKdfArgon2id:
+		params := cacheKeyFile.Argon2id
+		if params == nil {
+			return nil, errors.New("argon2id params is missing")
+		}
+		return argon2.IDKey(passphrase, salt, params.Time, params.Memory, params.Threads, 32), nil
+	case CacheKdfScrypt:
+		params := cacheKeyFile.Scrypt
+		if params == nil {
+			return nil, errors.New("scrypt params is missing")
+		}
+		key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, 32)
+		if err != nil {
+			return nil, errors.Wrap(err, "scrypt derive key failed")
+		}
+		return key, nil
+	}
+	return nil, errors.Errorf("unknown cache key kdf: %s", cacheKeyFile.Kdf)
+}
+
+func hkdfKey(secret, salt []byte) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(cacheKeyHkdfInfo)), key); err != nil {
+		return nil, errors.Wrap(err, "hkdf derive key failed")
+	}
+	return key, nil
+}
+
+// readCachePassphrase reads passphrase from environment, or else from terminal
+func readCachePassphrase(confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(constants.EnvCachePassphrase); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.Errorf("cache passphrase is required, set environment %s", constants.EnvCachePassphrase)
+	}
+	Stderr.Print("Cache passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	Stderr.Println("")
+	if err != nil {
+		return nil, errors.Wrap(err, "read cache passphrase failed")
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("cache passphrase is empty")
+	}
+	if confirm {
+		Stderr.Print("Confirm cache passphrase: ")
+		passphrase2, err := term.ReadPassword(fd)
+		Stderr.Println("")
+		if err != nil {
+			return nil, errors.Wrap(err, "read cache passphrase failed")
+		}
+		if !bytes.Equal(passphrase, passphrase2) {
+			return nil, errors.New("cache passphrase mismatch")
+		}
+	}
+	return passphrase, nil
+}
+
+func readCacheKeyFile(cacheKeyFilename string) (*CacheKeyFile, error) {
+	cacheKeyFileBytes, err := os.ReadFile(cacheKeyFilename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "read cache key file: %s failed", cacheKeyFilename)
+	}
+	var cacheKeyFile CacheKeyFile
+	if err = json.Unmarshal(cacheKeyFileBytes, &cacheKeyFile); err != nil {
+		return nil, errors.Wrapf(err, "parse cache key file: %s failed", cacheKeyFilename)
+	}
+	if cacheKeyFile.Version != cacheKeyFileVersion {
+		return nil, errors.Errorf("cache key file version: %d is not supported", cacheKeyFile.Version)
+	}
+	return &cacheKeyFile, nil
+}
+
+func getCacheKeyFilename() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get home dir failed")
+	}
+	cacheKeyDir := filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir)
+	if err = os.MkdirAll(cacheKeyDir, 0700); err != nil {
+		return "", errors.Wrapf(err, "create dir: %s failed", cacheKeyDir)
+	}
+	return filepath.Join(cacheKeyDir, cacheKeyFilename), nil
+}
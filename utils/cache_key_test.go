@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+)
+
+func TestCacheKeyPassphrase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCachePassphrase, "passphrase1")
+	defer SetCacheKeyOptions(nil)
+
+	plaintextDefault := "plaintext default"
+	ciphertextDefault, err := EncryptText(plaintextDefault, []byte("ad"))
+	if err != nil || !strings.HasPrefix(ciphertextDefault, ciphertextPrefixDefault+":") {
+		t.Fatalf("encrypt with default key: %s, %v", ciphertextDefault, err)
+	}
+
+	for _, kdf := range []string{CacheKdfArgon2id, CacheKdfScrypt} {
+		_ = RotateCacheKey()
+		SetCacheKeyOptions(&CacheKeyOptions{Id: kdf, Mode: CacheEncryptionModePassphrase, Kdf: kdf})
+		ciphertext, err := EncryptText("plaintext", []byte("ad"))
+		if err != nil || !strings.HasPrefix(ciphertext, ciphertextPrefixWrapped+":") {
+			t.Fatalf("encrypt with wrapped key: %s, %v", ciphertext, err)
+		}
+		// reset in memory key, unwrap from cache key file
+		SetCacheKeyOptions(nil)
+		SetCacheKeyOptions(&CacheKeyOptions{Id: kdf, Mode: CacheEncryptionModePassphrase, Kdf: kdf})
+		plaintext, err := DecryptText(ciphertext, []byte("ad"))
+		if err != nil || plaintext != "plaintext" {
+			t.Fatalf("decrypt with wrapped key: %s, %v", plaintext, err)
+		}
+		// entries encrypted with default key can be read, for migration
+		plaintext, err = DecryptText(ciphertextDefault, []byte("ad"))
+		if err != nil || plaintext != plaintextDefault {
+			t.Fatalf("decrypt with default key: %s, %v", plaintext, err)
+		}
+
+		t.Setenv(constants.EnvCachePassphrase, "passphrase2")
+		SetCacheKeyOptions(nil)
+		SetCacheKeyOptions(&CacheKeyOptions{Id: kdf, Mode: CacheEncryptionModePassphrase, Kdf: kdf})
+		if _, err = DecryptText(ciphertext, []byte("ad")); err == nil {
+			t.Fatalf("decrypt with wrong passphrase should fail")
+		}
+		t.Setenv(constants.EnvCachePassphrase, "passphrase1")
+		SetCacheKeyOptions(nil)
+	}
+}
+
+func TestCacheKeySigner(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer SetCacheKeyOptions(nil)
+
+	deterministicSign := func(challenge []byte) ([]byte, string, error) {
+		signature := sha256.Sum256(append([]byte("secret"), challenge...))
+		return signature[:], "signer1", nil
+	}
+	SetCacheKeyOptions(&CacheKeyOptions{Id: "1", Mode: CacheEncryptionModeSigner, Sign: deterministicSign})
+	ciphertext, err := EncryptText("plaintext", nil)
+	if err != nil {
+		t.Fatalf("encrypt with signer: %v", err)
+	}
+	SetCacheKeyOptions(nil)
+	SetCacheKeyOptions(&CacheKeyOptions{Id: "1", Mode: CacheEncryptionModeSigner, Sign: deterministicSign})
+	plaintext, err := DecryptText(ciphertext, nil)
+	if err != nil || plaintext != "plaintext" {
+		t.Fatalf("decrypt with signer: %s, %v", plaintext, err)
+	}
+
+	randomSign := func(challenge []byte) ([]byte, string, error) {
+		signature := make([]byte, 32)
+		_, _ = rand.Read(signature)
+		return signature, "signer2", nil
+	}
+	_ = RotateCacheKey()
+	SetCacheKeyOptions(&CacheKeyOptions{Id: "2", Mode: CacheEncryptionModeSigner, Sign: randomSign})
+	if _, err = EncryptText("plaintext", nil); err == nil {
+		t.Fatalf("non-deterministic signer should fail")
+	}
+}
+
+func TestCacheKeyModeMismatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(constants.EnvCachePassphrase, "passphrase")
+	defer SetCacheKeyOptions(nil)
+
+	SetCacheKeyOptions(&CacheKeyOptions{Id: "1", Mode: CacheEncryptionModePassphrase, Kdf: CacheKdfScrypt})
+	if _, err := EncryptText("plaintext", nil); err != nil {
+		t.Fatalf("encrypt with passphrase: %v", err)
+	}
+	deterministicSign := func(challenge []byte) ([]byte, string, error) {
+		signature := sha256.Sum256(append([]byte("secret"), challenge...))
+		return signature[:], "signer1", nil
+	}
+	SetCacheKeyOptions(&CacheKeyOptions{Id: "1", Mode: CacheEncryptionModeSigner, Sign: deterministicSign})
+	if _, err := EncryptText("plaintext", nil); err == nil || !strings.Contains(err.Error(), "migrate-cache --rotate-key") {
+		t.Fatalf("cache key mode mismatch should fail, got: %v", err)
+	}
+}
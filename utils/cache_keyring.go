@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/pkg/errors"
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringCacheReadWrite stores cache in OS keyring, the keyring encrypts content itself,
+// so no encryption key is stored in local disk
+type KeyringCacheReadWrite struct{}
+
+func (k *KeyringCacheReadWrite) Read(category string, key string) (string, error) {
+	content, err := keyring.Get(getKeyringService(category), key)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "read keyring [%s, %s] failed", category, key)
+	}
+	return content, nil
+}
+
+func (k *KeyringCacheReadWrite) Write(category string, key string, content string) error {
+	err := keyring.Set(getKeyringService(category), key, content)
+	if err != nil {
+		return errors.Wrapf(err, "write keyring [%s, %s] failed", category, key)
+	}
+	return nil
+}
+
+func (k *KeyringCacheReadWrite) Remove(category string, key string) error {
+	err := keyring.Delete(getKeyringService(category), key)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return errors.Wrapf(err, "remove keyring [%s, %s] failed", category, key)
+	}
+	return nil
+}
+
+func (k *KeyringCacheReadWrite) Clear(category string) error {
+	err := keyring.DeleteAll(getKeyringService(category))
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return errors.Wrapf(err, "clear keyring [%s] failed", category)
+	}
+	return nil
+}
+
+func getKeyringService(category string) string {
+	return constants.ConfigIdaasDir + ":" + category
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/pkg/errors"
+)
+
+const (
+	passCommand = "pass"
+	// same as pass, default password store dir is ~/.password-store
+	envPasswordStoreDir = "PASSWORD_STORE_DIR"
+)
+
+// PassCacheReadWrite stores cache in pass(the standard unix password manager), content is encrypted by GPG,
+// the private key is managed by gpg-agent, not stored along with the encrypted content
+type PassCacheReadWrite struct{}
+
+func (p *PassCacheReadWrite) Read(category string, key string) (string, error) {
+	passName := getPassName(category, key)
+	exists, err := isPassEntryExists(passName)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+	stdout, err := runPass(nil, "show", passName)
+	if err != nil {
+		return "", errors.Wrapf(err, "read pass [%s] failed", passName)
+	}
+	return strings.TrimSuffix(stdout, "\n"), nil
+}
+
+func (p *PassCacheReadWrite) Write(category string, key string, content string) error {
+	passName := getPassName(category, key)
+	_, err := runPass([]byte(content+"\n"), "insert", "--multiline", "--force", passName)
+	if err != nil {
+		return errors.Wrapf(err, "write pass [%s] failed", passName)
+	}
+	return nil
+}
+
+func (p *PassCacheReadWrite) Remove(category string, key string) error {
+	passName := getPassName(category, key)
+	exists, err := isPassEntryExists(passName)
+	if err != nil || !exists {
+		return err
+	}
+	_, err = runPass(nil, "rm", "--force", passName)
+	if err != nil {
+		return errors.Wrapf(err, "remove pass [%s] failed", passName)
+	}
+	return nil
+}
+
+func (p *PassCacheReadWrite) Clear(category string) error {
+	passDir := constants.ConfigIdaasDir + "/" + category
+	passStoreDir, err := getPasswordStoreDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(passStoreDir, passDir)); os.IsNotExist(err) {
+		return nil
+	}
+	_, err = runPass(nil, "rm", "--recursive", "--force", passDir)
+	if err != nil {
+		return errors.Wrapf(err, "clear pass [%s] failed", passDir)
+	}
+	return nil
+}
+
+func getPassName(category, key string) string {
+	return constants.ConfigIdaasDir + "/" + category + "/" + key
+}
+
+// isPassEntryExists checks the encrypted file directly, `pass show` cannot tell not found from other errors
+func isPassEntryExists(passName string) (bool, error) {
+	passStoreDir, err := getPasswordStoreDir()
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(filepath.Join(passStoreDir, passName+".gpg"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "stat pass [%s] failed", passName)
+	}
+	return true, nil
+}
+
+func getPasswordStoreDir() (string, error) {
+	if passwordStoreDir := os.Getenv(envPasswordStoreDir); passwordStoreDir != "" {
+		return passwordStoreDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get home dir failed")
+	}
+	return filepath.Join(homeDir, ".password-store"), nil
+}
+
+func runPass(stdin []byte, args ...string) (string, error) {
+	idaaslog.Debug.PrintfLn("Run pass: %v", args)
+	cmd := exec.Command(passCommand, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "run pass failed, stderr: %s", strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
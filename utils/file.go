@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFileAtomic writes data to a temp file in the same dir, then renames it to filename,
+// so readers never see a partial written file. Permission of existing file is preserved.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	if fileInfo, err := os.Stat(filename); err == nil {
+		perm = fileInfo.Mode().Perm()
+	}
+	dir := filepath.Dir(filename)
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return errors.Wrapf(err, "create temp file in dir: %s failed", dir)
+	}
+	tempFilename := tempFile.Name()
+	success := false
+	defer func() {
+		if !success {
+			_ = tempFile.Close()
+			_ = os.Remove(tempFilename)
+		}
+	}()
+
+	// chmod is not fully supported on some platforms, e.g. Windows, ignore error
+	_ = tempFile.Chmod(perm)
+	if _, err = tempFile.Write(data); err != nil {
+		return errors.Wrapf(err, "write temp file: %s failed", tempFilename)
+	}
+	if err = tempFile.Sync(); err != nil {
+		return errors.Wrapf(err, "sync temp file: %s failed", tempFilename)
+	}
+	if err = tempFile.Close(); err != nil {
+		return errors.Wrapf(err, "close temp file: %s failed", tempFilename)
+	}
+	if err = os.Rename(tempFilename, filename); err != nil {
+		return errors.Wrapf(err, "rename temp file: %s to %s failed", tempFilename, filename)
+	}
+	success = true
+	return nil
+}
+
+// BackupFile copies filename to <filename>.<timestamp>.bak with permission 0600, since backed up files usually
+// contain secrets, existing backup is never overwritten. Returns empty backup filename when file not exists
+func BackupFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "read file: %s failed", filename)
+	}
+	timestamp := time.Now().Format("20060102150405")
+	for i := 0; ; i++ {
+		backupFilename := fmt.Sprintf("%s.%s.bak", filename, timestamp)
+		if i > 0 {
+			// backed up more than once in one second
+			backupFilename = fmt.Sprintf("%s.%s-%d.bak", filename, timestamp, i)
+		}
+		file, err := os.OpenFile(backupFilename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "create backup file: %s failed", backupFilename)
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "write backup file: %s failed", backupFilename)
+		}
+		return backupFilename, nil
+	}
+}
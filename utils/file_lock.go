@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aliyunidaas/alibaba-cloud-idaas/constants"
+	"github.com/aliyunidaas/alibaba-cloud-idaas/idaaslog"
+	"github.com/pkg/errors"
+)
+
+const (
+	fileLockRetryInterval = 100 * time.Millisecond
+	// cacheLockTimeout long enough for interactive flows, e.g. device code flow
+	cacheLockTimeout = 10 * time.Minute
+)
+
+// FileLock advisory file lock across processes, flock on unix, LockFileEx on Windows
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile acquires exclusive lock of filename, waits until timeout
+func LockFile(filename string, timeout time.Duration) (*FileLock, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open lock file: %s failed", filename)
+	}
+	deadline := time.Now().Add(timeout)
+	waitLogged := false
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrapf(err, "lock file: %s failed", filename)
+		}
+		if locked {
+			return &FileLock{file: file}, nil
+		}
+		if time.Now().After(deadline) {
+			_ = file.Close()
+			return nil, errors.Errorf("lock file: %s timeout after %s", filename, timeout)
+		}
+		if !waitLogged {
+			idaaslog.Info.PrintfLn("Wait for lock file: %s", filename)
+			waitLogged = true
+		}
+		time.Sleep(fileLockRetryInterval)
+	}
+}
+
+func (l *FileLock) Unlock() {
+	if l == nil || l.file == nil {
+		return
+	}
+	if err := unlockFile(l.file); err != nil {
+		idaaslog.Warn.PrintfLn("Unlock file: %s failed: %v", l.file.Name(), err)
+	}
+	_ = l.file.Close()
+	l.file = nil
+}
+
+// LockCache acquires lock of cache category and key, lock files are not in cache dir, so they are not listed as cache
+func LockCache(category, key string) (*FileLock, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "get home dir failed")
+	}
+	lockDir := filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir, constants.LockDir, category)
+	if err = os.MkdirAll(lockDir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "create lock dir: %s failed", lockDir)
+	}
+	return LockFile(filepath.Join(lockDir, key+".lock"), cacheLockTimeout)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	lockFilename := filepath.Join(t.TempDir(), "test.lock")
+	lock, err := LockFile(lockFilename, time.Second)
+	if err != nil {
+		t.Fatalf("lock failed: %v", err)
+	}
+	if _, err = LockFile(lockFilename, 200*time.Millisecond); err == nil {
+		t.Fatalf("lock locked file should timeout")
+	}
+	lock.Unlock()
+	lock, err = LockFile(lockFilename, time.Second)
+	if err != nil {
+		t.Fatalf("lock after unlock failed: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestReadCacheWithLock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var fetchCount int32
+	options := &ReadCacheOptions{
+		FetchContent: func() (int, string, error) {
+			atomic.AddInt32(&fetchCount, 1)
+			time.Sleep(200 * time.Millisecond)
+			return http.StatusOK, "content", nil
+		},
+		IsContentExpiringOrExpired: func(s *StringWithTime) bool {
+			return s == nil
+		},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, err := ReadCacheFileWithEncryptionCallback("test", "key", options)
+			if err != nil || content != "content" {
+				t.Errorf("read cache: %s, %v", content, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if fetchCount != 1 {
+		t.Errorf("fetch count: %d", fetchCount)
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestBackupFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "credentials")
+	backupFilename, err := BackupFile(filename)
+	if err != nil || backupFilename != "" {
+		t.Fatalf("backup not exists file, backup: %s, error: %v", backupFilename, err)
+	}
+
+	// backup made by user is kept
+	userBackupFilename := filename + ".20250101120000.bak"
+	if err = os.WriteFile(userBackupFilename, []byte("user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var backupFilenames []string
+	for _, content := range []string{"v1", "v2"} {
+		if err = os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if backupFilename, err = BackupFile(filename); err != nil {
+			t.Fatal(err)
+		}
+		backupFilenames = append(backupFilenames, backupFilename)
+	}
+	for i, content := range []string{"v1", "v2"} {
+		backupContent, err := os.ReadFile(backupFilenames[i])
+		if err != nil || string(backupContent) != content {
+			t.Errorf("unexpected backup: %s content: %s, error: %v", backupFilenames[i], backupContent, err)
+		}
+		if fileInfo, err := os.Stat(backupFilenames[i]); err != nil ||
+			(runtime.GOOS != "windows" && fileInfo.Mode().Perm() != 0600) {
+			t.Errorf("backup file permission should be 0600, error: %v", err)
+		}
+	}
+	if userBackupContent, err := os.ReadFile(userBackupFilename); err != nil || string(userBackupContent) != "user" {
+		t.Errorf("backup made by user should be kept, error: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"strings"
+)
+
+type IniKeyValue struct {
+	Key   string
+	Value string
+}
+
+// UpdateIniSection updates or appends keys in section, other sections, keys and comments are kept as is
+func UpdateIniSection(content, section string, keyValues []IniKeyValue) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	sectionStart := -1
+	sectionEnd := len(lines)
+	for i, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if !isIniSectionLine(trimmedLine) {
+			continue
+		}
+		if sectionStart >= 0 {
+			sectionEnd = i
+			break
+		}
+		if parseIniSectionName(trimmedLine) == section {
+			sectionStart = i
+		}
+	}
+
+	if sectionStart < 0 {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]")
+		for _, keyValue := range keyValues {
+			lines = append(lines, keyValue.Key+" = "+keyValue.Value)
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	for _, keyValue := range keyValues {
+		keyLine := keyValue.Key + " = " + keyValue.Value
+		found := false
+		for i := sectionStart + 1; i < sectionEnd; i++ {
+			if parseIniKey(lines[i]) == keyValue.Key {
+				lines[i] = keyLine
+				found = true
+				break
+			}
+		}
+		if !found {
+			// insert after last non-empty line of section
+			insertAt := sectionEnd
+			for insertAt > sectionStart+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+				insertAt--
+			}
+			lines = append(lines[:insertAt], append([]string{keyLine}, lines[insertAt:]...)...)
+			sectionEnd++
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func isIniSectionLine(trimmedLine string) bool {
+	return strings.HasPrefix(trimmedLine, "[") && strings.HasSuffix(trimmedLine, "]")
+}
+
+func parseIniSectionName(trimmedLine string) string {
+	name := strings.TrimSpace(trimmedLine[1 : len(trimmedLine)-1])
+	// normalize `[profile  name]` to `[profile name]`
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func parseIniKey(line string) string {
+	trimmedLine := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, ";") {
+		return ""
+	}
+	index := strings.Index(trimmedLine, "=")
+	if index < 0 {
+		return ""
+	}
+	return strings.TrimSpace(trimmedLine[:index])
+}
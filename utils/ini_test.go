@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestUpdateIniSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		section  string
+		expected string
+	}{
+		{
+			name:     "empty content",
+			content:  "",
+			section:  "profile aws1",
+			expected: "[profile aws1]\nregion = us-east-1\n",
+		},
+		{
+			name:     "append section",
+			content:  "[default]\nregion = us-east-2\n",
+			section:  "profile aws1",
+			expected: "[default]\nregion = us-east-2\n\n[profile aws1]\nregion = us-east-1\n",
+		},
+		{
+			name:     "replace key",
+			content:  "# comment\n[profile  aws1]\nregion = us-east-2\noutput = json\n\n[default]\nregion = us-east-2\n",
+			section:  "profile aws1",
+			expected: "# comment\n[profile  aws1]\nregion = us-east-1\noutput = json\n\n[default]\nregion = us-east-2\n",
+		},
+		{
+			name:     "insert key",
+			content:  "[profile aws1]\noutput = json\n\n[default]\n",
+			section:  "profile aws1",
+			expected: "[profile aws1]\noutput = json\nregion = us-east-1\n\n[default]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := UpdateIniSection(tt.content, tt.section, []IniKeyValue{{Key: "region", Value: "us-east-1"}})
+			if result != tt.expected {
+				t.Errorf("UpdateIniSection() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
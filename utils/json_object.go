@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// JsonObject JSON object keeps order of keys, config file is updated without reordering fields,
+// nested objects are *JsonObject, numbers are json.Number
+type JsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func NewJsonObject() *JsonObject {
+	return &JsonObject{values: map[string]interface{}{}}
+}
+
+// UnmarshalJsonObject unmarshals JSON object, top level MUST be object
+func UnmarshalJsonObject(data []byte) (*JsonObject, error) {
+	object := NewJsonObject()
+	if err := json.Unmarshal(data, object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (o *JsonObject) Keys() []string {
+	return o.keys
+}
+
+func (o *JsonObject) Get(key string) (interface{}, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// GetObject returns nil when key not found or value is not object
+func (o *JsonObject) GetObject(key string) *JsonObject {
+	object, _ := o.values[key].(*JsonObject)
+	return object
+}
+
+// Set updates value in place, or appends key when absent
+func (o *JsonObject) Set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *JsonObject) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (o *JsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueBytes, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal field: %s failed", key)
+		}
+		buf.Write(keyBytes)
+		buf.WriteByte(':')
+		buf.Write(valueBytes)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o *JsonObject) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return errors.New("JSON object is required")
+	}
+	o.keys = nil
+	o.values = map[string]interface{}{}
+	return o.decodeFields(decoder)
+}
+
+func (o *JsonObject) decodeFields(decoder *json.Decoder) error {
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return errors.Errorf("invalid JSON object key: %v", token)
+		}
+		value, err := decodeJsonValue(decoder)
+		if err != nil {
+			return err
+		}
+		o.Set(key, value)
+	}
+	// consume '}'
+	_, err := decoder.Token()
+	return err
+}
+
+func decodeJsonValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil, errors.New("unexpected end of JSON")
+	}
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		object := NewJsonObject()
+		if err = object.decodeFields(decoder); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case '[':
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		// consume ']'
+		if _, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+		return array, nil
+	}
+	return nil, errors.Errorf("unexpected JSON delimiter: %s", delim)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJsonObject(t *testing.T) {
+	content := `{"version":"1","profile":{"z":{"n":1.50,"a":[{"y":true,"x":null}]},"a":{}},"$schema":"s"}`
+	object, err := UnmarshalJsonObject([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	marshaled, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(marshaled) != content {
+		t.Errorf("key order or number format is not kept: %s", marshaled)
+	}
+
+	profiles := object.GetObject("profile")
+	profiles.Set("b", map[string]interface{}{"k": "v"})
+	profiles.GetObject("z").Set("n", 2)
+	profiles.Delete("a")
+	object.Delete("version")
+	marshaled, _ = json.Marshal(object)
+	expected := `{"profile":{"z":{"n":2,"a":[{"y":true,"x":null}]},"b":{"k":"v"}},"$schema":"s"}`
+	if string(marshaled) != expected {
+		t.Errorf("unexpected JSON: %s, expected: %s", marshaled, expected)
+	}
+
+	if _, err = UnmarshalJsonObject([]byte(`[]`)); err == nil {
+		t.Errorf("JSON array should be rejected")
+	}
+}
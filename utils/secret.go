@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	SecretRefEnv     = "env:"
+	SecretRefFile    = "file:"
+	SecretRefExec    = "exec:"
+	SecretRefKeyring = "keyring:"
+	// SecretRefLiteral escapes inline secret starts with reference prefix, e.g. literal:env:abc is secret env:abc
+	SecretRefLiteral = "literal:"
+)
+
+// IsSecretRef secret value is reference: env:NAME, file:/path, exec:command or keyring:service/account
+func IsSecretRef(value string) bool {
+	for _, prefix := range []string{SecretRefEnv, SecretRefFile, SecretRefExec, SecretRefKeyring} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveSecret resolves secret reference, value without reference prefix is inline secret and returned as is,
+// resolved secret MUST NOT be logged, so error message never contains secret
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretRefLiteral):
+		return strings.TrimPrefix(value, SecretRefLiteral), nil
+	case strings.HasPrefix(value, SecretRefEnv):
+		name := strings.TrimPrefix(value, SecretRefEnv)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", errors.Errorf("secret env: %s not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, SecretRefFile):
+		filename := strings.TrimPrefix(value, SecretRefFile)
+		secret, err := os.ReadFile(filename)
+		if err != nil {
+			return "", errors.Wrapf(err, "read secret file: %s failed", filename)
+		}
+		return strings.TrimRight(string(secret), "\r\n"), nil
+	case strings.HasPrefix(value, SecretRefExec):
+		return execSecret(strings.TrimPrefix(value, SecretRefExec))
+	case strings.HasPrefix(value, SecretRefKeyring):
+		service, account, err := ParseKeyringSecretRef(value)
+		if err != nil {
+			return "", err
+		}
+		secret, err := keyring.Get(service, account)
+		if err != nil {
+			return "", errors.Wrapf(err, "read secret keyring [%s, %s] failed", service, account)
+		}
+		return secret, nil
+	}
+	return value, nil
+}
+
+// ParseKeyringSecretRef parses keyring:service/account, service may contain `/`, account is after last `/`
+func ParseKeyringSecretRef(value string) (string, string, error) {
+	ref := strings.TrimPrefix(value, SecretRefKeyring)
+	index := strings.LastIndex(ref, "/")
+	if index <= 0 || index == len(ref)-1 {
+		return "", "", errors.Errorf("invalid keyring secret reference: %s, should be keyring:service/account", value)
+	}
+	return ref[:index], ref[index+1:], nil
+}
+
+// execSecret runs command by shell, stdout is secret, stderr is passed through for password manager prompts
+func execSecret(command string) (string, error) {
+	cmd := newShellCommand(command)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "run secret command: %s failed", command)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// CheckSecretCommand checks secret command without running it, command is parsed by shell as it is executed,
+// `sh -n` checks syntax only, on Windows only `cmd` is checked
+func CheckSecretCommand(command string) error {
+	if strings.TrimSpace(command) == "" {
+		return errors.New("secret command is empty")
+	}
+	if runtime.GOOS == "windows" {
+		if _, err := exec.LookPath("cmd"); err != nil {
+			return errors.Wrap(err, "shell: cmd is not executable")
+		}
+		return nil
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		return errors.Wrap(err, "shell: sh is not executable")
+	}
+	if output, err := exec.Command("sh", "-n", "-c", command).CombinedOutput(); err != nil {
+		return errors.Errorf("secret command: %s is invalid: %s", command, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func newShellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/c", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
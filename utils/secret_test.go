@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("TEST_IDAAS_SECRET", "env-secret")
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"inline-secret":         "inline-secret",
+		"literal:env:secret":    "env:secret",
+		"env:TEST_IDAAS_SECRET": "env-secret",
+		"file:" + secretFile:    "file-secret",
+		"exec:echo exec-secret": "exec-secret",
+		"":                      "",
+	}
+	for value, expected := range tests {
+		if value == "exec:echo exec-secret" && runtime.GOOS == "windows" {
+			continue
+		}
+		secret, err := ResolveSecret(value)
+		if err != nil || secret != expected {
+			t.Errorf("resolve %s: %q, %v, expected: %q", value, secret, err, expected)
+		}
+	}
+
+	for _, value := range []string{"env:TEST_IDAAS_SECRET_NOT_SET", "file:/not/exists", "exec:exit 1", "keyring:service"} {
+		if _, err := ResolveSecret(value); err == nil {
+			t.Errorf("resolve %s should fail", value)
+		}
+	}
+	if service, account, err := ParseKeyringSecretRef("keyring:idaas/prod/client"); err != nil ||
+		service != "idaas/prod" || account != "client" {
+		t.Errorf("parse keyring: %s, %s, %v", service, account, err)
+	}
+}
+
+func TestCheckSecretCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("syntax is not checked on Windows")
+	}
+	// env assignment, quotes and pipes are parsed by shell, command is not run
+	if err := CheckSecretCommand(`TOKEN_NAME="client secret" printenv TOKEN_NAME | tr -d '\n'`); err != nil {
+		t.Errorf("valid shell command: %v", err)
+	}
+	for _, command := range []string{"", "  ", `op read "op://dev/idaas`} {
+		if err := CheckSecretCommand(command); err == nil {
+			t.Errorf("command: %q should be invalid", command)
+		}
+	}
+}
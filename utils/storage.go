@@ -39,6 +39,34 @@ type StringWithTime struct {
 	Content   string                 `json:"content"`
 }
 
+// ContextKeyExpiration content expiration in cache context, Unix Epoch(seconds)
+const ContextKeyExpiration = "expiration"
+
+// GetExpiration returns content expiration from context, false when absent
+func (s *StringWithTime) GetExpiration() (time.Time, bool) {
+	if s == nil || s.Context == nil {
+		return time.Time{}, false
+	}
+	// JSON numbers are unmarshalled as float64
+	switch expiration := s.Context[ContextKeyExpiration].(type) {
+	case float64:
+		return time.Unix(int64(expiration), 0), true
+	case int64:
+		return time.Unix(expiration, 0), true
+	}
+	return time.Time{}, false
+}
+
+func (s *StringWithTime) GetContextString(key string) string {
+	if s == nil || s.Context == nil {
+		return ""
+	}
+	if value, ok := s.Context[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
 func UnmarshalStringWithTime(str string) (*StringWithTime, error) {
 	var stringWithTime StringWithTime
 	err := json.Unmarshal([]byte(str), &stringWithTime)
@@ -93,11 +121,24 @@ type ReadCacheOptions struct {
 	AllowExpired               bool
 	IsContentExpiringOrExpired func(time *StringWithTime) bool
 	IsContentExpired           func(time *StringWithTime) bool
+	// GetContentExpiration optional, expiration is stored in context for cache inspection, zero time when unknown
+	GetContentExpiration func(content string) time.Time
+}
+
+// ReadCacheResult content with cache context, FromCache is false when content is fetched just now
+type ReadCacheResult struct {
+	Content   string
+	Context   map[string]interface{}
+	CacheTime int64
+	FromCache bool
 }
 
 type CacheReadWrite interface {
 	Read(string, string) (string, error)
 	Write(string, string, string) error
+	Remove(string, string) error
+	// Clear removes all cache in category
+	Clear(string) error
 }
 
 type EncryptedFileCacheReadWrite struct{}
@@ -110,24 +151,60 @@ func (e *EncryptedFileCacheReadWrite) Write(category string, key string, content
 	return WriteCacheFileWithEncryption(category, key, content)
 }
 
-func ReadCacheFileWithEncryptionCallback(category, key string, options *ReadCacheOptions) (string, error) {
-	return ReadCacheWithEncryptionCallback(category, key, &EncryptedFileCacheReadWrite{}, options)
+func (e *EncryptedFileCacheReadWrite) Remove(category string, key string) error {
+	return removeCacheFile(category, key)
 }
 
-func ReadCacheWithEncryptionCallback(category, key string, cacheReadWrite CacheReadWrite, options *ReadCacheOptions) (string, error) {
-	var stringWithTime *StringWithTime
-	data, err := cacheReadWrite.Read(category, key)
+func (e *EncryptedFileCacheReadWrite) Clear(category string) error {
+	cacheDir, err := getCacheDir(category)
 	if err != nil {
-		idaaslog.Warn.PrintfLn("Read cache file [%s, %s] with encryption failed: %v, ignore error",
-			category, key, err)
+		return err
 	}
-	if data != "" {
-		stringWithTime, err = UnmarshalStringWithTime(data)
-		if err != nil {
-			idaaslog.Warn.PrintfLn("Parse cache file[%s, %s] with encryption failed: %v, ignore error",
-				category, key, err)
+	cacheFiles, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "read dir: %s failed", cacheDir)
+	}
+	for _, cacheFile := range cacheFiles {
+		if !cacheFile.IsDir() {
+			if err := os.Remove(filepath.Join(cacheDir, cacheFile.Name())); err != nil {
+				return errors.Wrapf(err, "remove file: %s failed", cacheFile.Name())
+			}
 		}
 	}
+	return nil
+}
+
+// ReadCacheFileWithEncryptionCallback reads cache from backend configured, see GetCacheBackend
+func ReadCacheFileWithEncryptionCallback(category, key string, options *ReadCacheOptions) (string, error) {
+	result, err := ReadCacheFileWithEncryptionCallbackResult(category, key, options)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+func ReadCacheFileWithEncryptionCallbackResult(category, key string, options *ReadCacheOptions) (*ReadCacheResult, error) {
+	cacheReadWrite, err := GetCacheReadWrite()
+	if err != nil {
+		return nil, err
+	}
+	return ReadCacheWithEncryptionCallbackResult(category, key, cacheReadWrite, options)
+}
+
+func ReadCacheWithEncryptionCallback(category, key string, cacheReadWrite CacheReadWrite, options *ReadCacheOptions) (string, error) {
+	result, err := ReadCacheWithEncryptionCallbackResult(category, key, cacheReadWrite, options)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+func ReadCacheWithEncryptionCallbackResult(category, key string, cacheReadWrite CacheReadWrite, options *ReadCacheOptions) (
+	*ReadCacheResult, error) {
+	stringWithTime := readStringWithTime(category, key, cacheReadWrite)
 	expiringOrExpired := stringWithTime.IsExpiringOrExpiredWithCustomFunc(options.IsContentExpiringOrExpired)
 	if options.ForceNew {
 		expiringOrExpired = true
@@ -137,16 +214,35 @@ func ReadCacheWithEncryptionCallback(category, key string, cacheReadWrite CacheR
 	var fetchContent string
 	var fetchContentErr error
 	if expiringOrExpired {
+		// only one process fetches content, others wait and reuse the fetched content
+		cacheLock, lockErr := LockCache(category, key)
+		if lockErr != nil {
+			idaaslog.Warn.PrintfLn("Lock cache [%s, %s] failed: %v, ignore error", category, key, lockErr)
+		} else {
+			defer cacheLock.Unlock()
+			if !options.ForceNew {
+				lockedStringWithTime := readStringWithTime(category, key, cacheReadWrite)
+				if !lockedStringWithTime.IsExpiringOrExpiredWithCustomFunc(options.IsContentExpiringOrExpired) {
+					idaaslog.Debug.PrintfLn("Cache [%s, %s] refreshed by another process", category, key)
+					return newReadCacheResultFromCache(lockedStringWithTime), nil
+				}
+				if lockedStringWithTime != nil {
+					stringWithTime = lockedStringWithTime
+				}
+			}
+		}
 		fetchStatusCode, fetchContent, fetchContentErr = options.FetchContent()
 		if fetchContentErr != nil {
 			idaaslog.Error.PrintfLn("Fetch content failed: %v", fetchContentErr)
 		} else {
 			if fetchStatusCode != http.StatusOK {
 				idaaslog.Error.PrintfLn("Fetch content failed, statusCode: %d", fetchStatusCode)
+				// result is nil without error otherwise
+				fetchContentErr = errors.Errorf("fetch content failed, status code: %d", fetchStatusCode)
 			} else {
 				stringWithTimeForStore := StringWithTime{
 					CacheTime: time.Now().UnixMilli(),
-					Context:   options.Context,
+					Context:   contextWithExpiration(options, fetchContent),
 					Content:   fetchContent,
 				}
 				marshaledContent, err := stringWithTimeForStore.Marshal()
@@ -157,17 +253,22 @@ func ReadCacheWithEncryptionCallback(category, key string, cacheReadWrite CacheR
 					if err != nil {
 						idaaslog.Error.PrintfLn("Write content failed: %v", err)
 					}
-					return fetchContent, nil
+					return &ReadCacheResult{
+						Content:   fetchContent,
+						Context:   stringWithTimeForStore.Context,
+						CacheTime: stringWithTimeForStore.CacheTime,
+						FromCache: false,
+					}, nil
 				}
 			}
 		}
 	}
 	if fetchContentErr != nil && strings.Contains(fetchContentErr.Error(), constants.ErrStopFallback) {
-		return "", errors.New("user denied, stop fallback to local cached credentials")
+		return nil, errors.New("user denied, stop fallback to local cached credentials")
 	}
 
 	if options.ForceNew {
-		return "", errors.Errorf("fetch content failed, with ForceNew option, original error: %v", fetchContentErr)
+		return nil, errors.Errorf("fetch content failed, with ForceNew option, original error: %v", fetchContentErr)
 	}
 
 	if stringWithTime != nil {
@@ -175,14 +276,89 @@ func ReadCacheWithEncryptionCallback(category, key string, cacheReadWrite CacheR
 		if !expired {
 			idaaslog.Warn.PrintfLn("Expired cache file [%s, %s], not expired", category, key)
 			idaaslog.Unsafe.PrintfLn("Cached file [%s, %s], content: %v", category, key, stringWithTime)
-			return stringWithTime.Content, nil
+			return newReadCacheResultFromCache(stringWithTime), nil
 		}
 		if options.AllowExpired {
 			idaaslog.Error.PrintfLn("Expired cache file [%s, %s], allow expired", category, key)
-			return stringWithTime.Content, nil
+			return newReadCacheResultFromCache(stringWithTime), nil
 		}
 	}
-	return "", errors.Wrapf(fetchContentErr, "read cache file [%s, %s], context: %+v", category, key, options.Context)
+	return nil, errors.Wrapf(fetchContentErr, "read cache file [%s, %s], context: %+v", category, key, options.Context)
+}
+
+func contextWithExpiration(options *ReadCacheOptions, content string) map[string]interface{} {
+	if options.GetContentExpiration == nil {
+		return options.Context
+	}
+	expiration := options.GetContentExpiration(content)
+	if expiration.IsZero() {
+		return options.Context
+	}
+	context := make(map[string]interface{}, len(options.Context)+1)
+	for k, v := range options.Context {
+		context[k] = v
+	}
+	context[ContextKeyExpiration] = expiration.Unix()
+	return context
+}
+
+func readStringWithTime(category, key string, cacheReadWrite CacheReadWrite) *StringWithTime {
+	data, err := cacheReadWrite.Read(category, key)
+	if err != nil {
+		idaaslog.Warn.PrintfLn("Read cache file [%s, %s] with encryption failed: %v, ignore error",
+			category, key, err)
+	}
+	if data == "" {
+		return nil
+	}
+	stringWithTime, err := UnmarshalStringWithTime(data)
+	if err != nil {
+		idaaslog.Warn.PrintfLn("Parse cache file[%s, %s] with encryption failed: %v, ignore error",
+			category, key, err)
+		return nil
+	}
+	return stringWithTime
+}
+
+func newReadCacheResultFromCache(stringWithTime *StringWithTime) *ReadCacheResult {
+	return &ReadCacheResult{
+		Content:   stringWithTime.Content,
+		Context:   stringWithTime.Context,
+		CacheTime: stringWithTime.CacheTime,
+		FromCache: true,
+	}
+}
+
+// ListCacheFiles lists keys of file cache in category
+func ListCacheFiles(category string) ([]string, error) {
+	cacheDir, err := getCacheDir(category)
+	if err != nil {
+		return nil, err
+	}
+	cacheFiles, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "read dir: %s failed", cacheDir)
+	}
+	var keys []string
+	for _, cacheFile := range cacheFiles {
+		// skip temp files of atomic write
+		if !cacheFile.IsDir() && !strings.HasPrefix(cacheFile.Name(), ".") {
+			keys = append(keys, cacheFile.Name())
+		}
+	}
+	return keys, nil
+}
+
+// StatCacheFile returns file info of file cache
+func StatCacheFile(category, key string) (os.FileInfo, error) {
+	cacheFile, err := getCacheFile(category, key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(cacheFile)
 }
 
 func RemoveCacheFile(category, key string) error {
@@ -214,8 +390,43 @@ func WriteCacheFileWithEncryption(category, key string, plaintext string) error
 	return writeCacheFile(category, key, []byte(ciphertext))
 }
 
+const (
+	// ciphertextPrefixDefault encrypted with key derived from seed files
+	ciphertextPrefixDefault = "encrypted"
+	// ciphertextPrefixWrapped encrypted with wrapped cache key, see CacheKeyOptions
+	ciphertextPrefixWrapped = "encrypted-wrapped"
+)
+
 func EncryptText(plaintext string, additionalData []byte) (string, error) {
-	key := getEncryptionKey()
+	if GetCacheEncryptionMode() != CacheEncryptionModeDefault {
+		key, err := getUnwrappedCacheKey()
+		if err != nil {
+			return "", errors.Wrap(err, "get cache key failed")
+		}
+		return encryptWithKey(key, ciphertextPrefixWrapped, []byte(plaintext), additionalData)
+	}
+	return encryptWithKey(getEncryptionKey(), ciphertextPrefixDefault, []byte(plaintext), additionalData)
+}
+
+func DecryptText(ciphertext string, additionalData []byte) (string, error) {
+	var plaintext []byte
+	var err error
+	if strings.HasPrefix(ciphertext, ciphertextPrefixWrapped+":") {
+		key, keyErr := getUnwrappedCacheKey()
+		if keyErr != nil {
+			return "", errors.Wrap(keyErr, "get cache key failed")
+		}
+		plaintext, err = decryptWithKey(key, ciphertextPrefixWrapped, ciphertext, additionalData)
+	} else {
+		plaintext, err = decryptWithKey(getEncryptionKey(), ciphertextPrefixDefault, ciphertext, additionalData)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func encryptWithKey(key []byte, prefix string, plaintext, additionalData []byte) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", errors.Wrap(err, "new cipher failed")
@@ -228,44 +439,43 @@ func EncryptText(plaintext string, additionalData []byte) (string, error) {
 	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", errors.Wrap(err, "read nonce failed")
 	}
-	ciphertext := gcm.Seal(nil, nonce, []byte(plaintext), additionalData)
-	return "encrypted:" +
+	ciphertext := gcm.Seal(nil, nonce, plaintext, additionalData)
+	return prefix + ":" +
 		base64.RawURLEncoding.EncodeToString(nonce) + ":" +
 		base64.RawURLEncoding.EncodeToString(ciphertext), nil
 }
 
-func DecryptText(ciphertext string, additionalData []byte) (string, error) {
-	key := getEncryptionKey()
+func decryptWithKey(key []byte, prefix string, ciphertext string, additionalData []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", errors.Wrap(err, "new cipher failed")
+		return nil, errors.Wrap(err, "new cipher failed")
 	}
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", errors.Wrap(err, "new GCM failed")
+		return nil, errors.Wrap(err, "new GCM failed")
 	}
 
 	parts := strings.Split(ciphertext, ":")
 	if len(parts) != 3 {
-		return "", errors.Errorf("invalid ciphertext, len() == %d", len(parts))
+		return nil, errors.Errorf("invalid ciphertext, len() == %d", len(parts))
 	}
-	if parts[0] != "encrypted" {
-		return "", errors.New("invalid ciphertext, not starts with encrypted")
+	if parts[0] != prefix {
+		return nil, errors.Errorf("invalid ciphertext, not starts with %s", prefix)
 	}
 
 	nonce, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
-		return "", errors.Wrapf(err, "invalid nonce: %s", parts[1])
+		return nil, errors.Wrapf(err, "invalid nonce: %s", parts[1])
 	}
 	ciphertextBody, err := base64.RawURLEncoding.DecodeString(parts[2])
 	if err != nil {
-		return "", errors.Wrapf(err, "invalid ciphertext: %s", parts[2])
+		return nil, errors.Wrapf(err, "invalid ciphertext: %s", parts[2])
 	}
 	plaintext, err := gcm.Open(nil, nonce, ciphertextBody, additionalData)
 	if err != nil {
-		return "", errors.Wrap(err, "decrypt failed")
+		return nil, errors.Wrap(err, "decrypt failed")
 	}
-	return string(plaintext), nil
+	return plaintext, nil
 }
 
 var encryptionKey []byte
@@ -337,7 +547,8 @@ func writeCacheFile(category, key string, content []byte) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(cacheFile, content, 0600)
+	// readers never see half-written cache file
+	return WriteFileAtomic(cacheFile, content, 0600)
 }
 
 func readCacheFile(category, key string) ([]byte, error) {
@@ -351,12 +562,19 @@ func readCacheFile(category, key string) ([]byte, error) {
 	return os.ReadFile(cacheFile)
 }
 
-func getCacheFile(category, key string) (string, error) {
+func getCacheDir(category string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", errors.Wrap(err, "get home dir failed")
 	}
-	cacheDir := filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir, category)
+	return filepath.Join(homeDir, constants.ConfigRootDir, constants.ConfigIdaasDir, category), nil
+}
+
+func getCacheFile(category, key string) (string, error) {
+	cacheDir, err := getCacheDir(category)
+	if err != nil {
+		return "", err
+	}
 	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
 		mkdirErr := os.MkdirAll(cacheDir, 0755)
 		if mkdirErr != nil {
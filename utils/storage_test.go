@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReadCacheFetchStatusError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	options := &ReadCacheOptions{
+		FetchContent: func() (int, string, error) {
+			return http.StatusNotFound, "not found", nil
+		},
+		IsContentExpiringOrExpired: func(s *StringWithTime) bool {
+			return s == nil
+		},
+	}
+	if _, err := ReadCacheFileWithEncryptionCallback("test", "key", options); err == nil {
+		t.Error("read cache should fail when fetch status is not 200")
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnixSocketInUse = errors.New("unix socket is in use")
+
+// RemoveStaleUnixSocket removes socket left by exited process, ErrUnixSocketInUse is returned when socket accepts
+// connection, path is never removed when it is not a socket(e.g. regular file or symlink), so a typo never deletes data
+func RemoveStaleUnixSocket(socket string) error {
+	fileInfo, err := os.Lstat(socket)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "stat unix socket: %s failed", socket)
+	}
+	if fileInfo.Mode()&os.ModeSocket == 0 {
+		return errors.Errorf("path: %s exists and is not unix socket", socket)
+	}
+	conn, err := net.DialTimeout("unix", socket, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return ErrUnixSocketInUse
+	}
+	if err = os.Remove(socket); err != nil {
+		return errors.Wrapf(err, "remove stale unix socket: %s failed", socket)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRemoveStaleUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket file mode is not checked on Windows")
+	}
+	dir := t.TempDir()
+	if err := RemoveStaleUnixSocket(filepath.Join(dir, "not-exists.sock")); err != nil {
+		t.Errorf("not exists socket: %v", err)
+	}
+
+	regularFilename := filepath.Join(dir, "data")
+	if err := os.WriteFile(regularFilename, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	symlinkFilename := filepath.Join(dir, "link.sock")
+	if err := os.Symlink(regularFilename, symlinkFilename); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{regularFilename, symlinkFilename} {
+		if err := RemoveStaleUnixSocket(filename); err == nil {
+			t.Errorf("path: %s is not socket, should fail", filename)
+		}
+		if _, err := os.Lstat(filename); err != nil {
+			t.Errorf("path: %s should not be removed: %v", filename, err)
+		}
+	}
+
+	socket := filepath.Join(dir, "s.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = RemoveStaleUnixSocket(socket); !errors.Is(err, ErrUnixSocketInUse) {
+		t.Errorf("socket in use, error: %v", err)
+	}
+	// socket file is left as process exited
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = listener.Close()
+	if err = RemoveStaleUnixSocket(socket); err != nil {
+		t.Errorf("remove stale socket: %v", err)
+	}
+	if _, err = os.Lstat(socket); !os.IsNotExist(err) {
+		t.Errorf("stale socket should be removed: %v", err)
+	}
+}